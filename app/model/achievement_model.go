@@ -1,11 +1,43 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	AchievementStatusDraft     = "draft"
+	AchievementStatusSubmitted = "submitted"
+	AchievementStatusVerified  = "verified"
+	AchievementStatusRejected  = "rejected"
+
+	AchievementStatusNeedsRevision = "needs_revision"
+)
+
+const (
+	AchievementTypeAcademic      = "academic"
+	AchievementTypeCompetition   = "competition"
+	AchievementTypeOrganization  = "organization"
+	AchievementTypePublication   = "publication"
+	AchievementTypeCertification = "certification"
+	AchievementTypeOther         = "other"
+)
+
+const (
+	HistoryTypeStatusChange = "status_change"
+	HistoryTypeComment      = "comment"
+)
+
+const (
+	BulkResultSuccess      = "success"
+	BulkResultNotFound     = "not_found"
+	BulkResultIllegalState = "illegal_state"
+	BulkResultNotAdvisee   = "not_your_advisee"
+	BulkResultConflict     = "conflict"
+)
+
 type AchievementMongo struct {
 	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	StudentID       string             `bson:"studentId" json:"student_id"` // Disimpan sebagai string UUID
@@ -16,38 +48,107 @@ type AchievementMongo struct {
 	Attachments     []Attachment       `bson:"attachments,omitempty" json:"attachments,omitempty"`
 	Tags            []string           `bson:"tags" json:"tags"`
 	Points          int                `bson:"points,omitempty" json:"points,omitempty"`
+	PointsVersion   int                `bson:"pointsRuleVersion,omitempty" json:"points_rule_version,omitempty"`
 	CreatedAt       time.Time          `bson:"createdAt" json:"created_at"`
 	UpdatedAt       time.Time          `bson:"updatedAt" json:"updated_at"`
+	DeletedAt       *time.Time         `bson:"deletedAt,omitempty" json:"deleted_at,omitempty"`
 }
 
 type AchievementDetails struct {
 	// Competition
 	CompetitionName  string `bson:"competitionName,omitempty" json:"competition_name,omitempty"`
-	CompetitionLevel string `bson:"competitionLevel,omitempty" json:"competition_level,omitempty"`
-	Rank             int    `bson:"rank,omitempty" json:"rank,omitempty"`
+	CompetitionLevel string `bson:"competitionLevel,omitempty" json:"competition_level,omitempty" validate:"omitempty,oneof=international national regional local"`
+	Rank             int    `bson:"rank,omitempty" json:"rank,omitempty" validate:"gte=0"`
 	MedalType        string `bson:"medalType,omitempty" json:"medal_type,omitempty"`
 
+	// Publication
+	PublicationType  string   `bson:"publicationType,omitempty" json:"publication_type,omitempty" validate:"omitempty,oneof=journal conference book"`
+	PublicationTitle string   `bson:"publicationTitle,omitempty" json:"publication_title,omitempty"`
+	Authors          []string `bson:"authors,omitempty" json:"authors,omitempty" validate:"dive,required"`
+	Publisher        string   `bson:"publisher,omitempty" json:"publisher,omitempty"`
+	ISSN             string   `bson:"issn,omitempty" json:"issn,omitempty"`
+
 	// Organization
-	OrganizationName string    `bson:"organizationName,omitempty" json:"organization_name,omitempty"`
-	Position         string    `bson:"position,omitempty" json:"position,omitempty"`
-	StartDate        time.Time `bson:"startDate,omitempty" json:"start_date,omitempty"`
-	EndDate          time.Time `bson:"endDate,omitempty" json:"end_date,omitempty"`
+	OrganizationName string             `bson:"organizationName,omitempty" json:"organization_name,omitempty"`
+	Position         string             `bson:"position,omitempty" json:"position,omitempty"`
+	Period           *AchievementPeriod `bson:"period,omitempty" json:"period,omitempty"`
+
+	// Certification
+	CertificationName   string    `bson:"certificationName,omitempty" json:"certification_name,omitempty"`
+	IssuedBy            string    `bson:"issuedBy,omitempty" json:"issued_by,omitempty"`
+	CertificationNumber string    `bson:"certificationNumber,omitempty" json:"certification_number,omitempty"`
+	ValidUntil          time.Time `bson:"validUntil,omitempty" json:"valid_until,omitempty"`
 
 	// General
-	EventDate time.Time `bson:"eventDate,omitempty" json:"event_date,omitempty"`
-	Location  string    `bson:"location,omitempty" json:"location,omitempty"`
-	Organizer string    `bson:"organizer,omitempty" json:"organizer,omitempty"`
+	EventDate    time.Time              `bson:"eventDate,omitempty" json:"event_date,omitempty"`
+	Location     string                 `bson:"location,omitempty" json:"location,omitempty"`
+	Organizer    string                 `bson:"organizer,omitempty" json:"organizer,omitempty"`
+	Score        float64                `bson:"score,omitempty" json:"score,omitempty"`
+	CustomFields map[string]interface{} `bson:"customFields,omitempty" json:"custom_fields,omitempty"`
+}
+
+type AchievementPeriod struct {
+	Start time.Time `bson:"start,omitempty" json:"start,omitempty"`
+	End   time.Time `bson:"end,omitempty" json:"end,omitempty"`
 }
 
 type Attachment struct {
+	ID         string    `bson:"id,omitempty" json:"id"`
 	FileName   string    `bson:"fileName" json:"file_name"`
 	FileURL    string    `bson:"fileUrl" json:"file_url"`
+	StorageKey string    `bson:"storageKey,omitempty" json:"-"`
+	PreviewKey string    `bson:"previewKey,omitempty" json:"-"`
+	PreviewURL string    `bson:"-" json:"preview_url,omitempty"`
 	FileType   string    `bson:"fileType" json:"file_type"`
+	Size       int64     `bson:"size,omitempty" json:"size"`
+	Checksum   string    `bson:"checksum,omitempty" json:"checksum,omitempty"`
+	ScanStatus string    `bson:"scanStatus,omitempty" json:"scan_status"`
 	UploadedAt time.Time `bson:"uploadedAt" json:"uploaded_at"`
 }
 
+// Scan states of an attachment. Files are held back from download until they
+// are clean; attachments from before scanning have no state and count as clean.
+const (
+	ScanPending  = "pending"
+	ScanClean    = "clean"
+	ScanInfected = "infected"
+)
+
+// ScanJob points the malware scanner at one stored attachment. CommentID is
+// set for comment attachments, MongoAchievementID for the achievement's own.
+type ScanJob struct {
+	MongoAchievementID string
+	CommentID          string
+	AttachmentID       string
+	StorageKey         string
+	ContentType        string
+}
+
+// AttachmentRejection says why one file of an upload was refused.
+type AttachmentRejection struct {
+	FileName string `json:"file_name"`
+	Error    string `json:"error"`
+}
+
+// AttachmentGrant is what a signed attachment link allows: downloading one
+// attachment of one achievement until ExpiresAt.
+type AttachmentGrant struct {
+	AchievementID string    `json:"a"`
+	AttachmentID  string    `json:"f"`
+	ExpiresAt     time.Time `json:"e"`
+}
+
+type CreateAttachmentLinkRequest struct {
+	ExpiresInMinutes int `json:"expires_in_minutes" validate:"omitempty,min=1,max=1440"`
+}
+
+type AttachmentLink struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
 type CreateAchievementRequest struct {
-	AchievementType string             `json:"achievement_type" validate:"required"`
+	AchievementType string             `json:"achievement_type" validate:"required,oneof=academic competition organization publication certification other"`
 	Title           string             `json:"title" validate:"required"`
 	Description     string             `json:"description" validate:"required"`
 	Details         AchievementDetails `json:"details"`
@@ -56,7 +157,7 @@ type CreateAchievementRequest struct {
 
 type UpdateAchievementRequest struct {
 	ID              string             `json:"id" validate:"required"`
-	AchievementType string             `json:"achievement_type" validate:"required"`
+	AchievementType string             `json:"achievement_type" validate:"required,oneof=academic competition organization publication certification other"`
 	Title           string             `json:"title" validate:"required"`
 	Description     string             `json:"description" validate:"required"`
 	Details         AchievementDetails `json:"details"`
@@ -69,6 +170,8 @@ type AchievementReference struct {
 	MongoAchievementID string            `json:"mongo_achievement_id"`
 	Status             string            `json:"status"`
 	RejectionNote      string            `json:"rejection_note,omitempty"`
+	RevisionNote       string            `json:"revision_note,omitempty"`
+	RevisionRound      int               `json:"revision_round,omitempty"`
 	SubmittedAt        *time.Time        `json:"submitted_at,omitempty"`
 	VerifiedAt         *time.Time        `json:"verified_at,omitempty"`
 	VerifiedBy         string            `json:"verified_by,omitempty"`
@@ -77,17 +180,31 @@ type AchievementReference struct {
 }
 
 type AchievementReferenceDetail struct {
-	ID                 string            `json:"id"`
-	MongoAchievementID string            `json:"mongo_achievement_id"`
-	Status             string            `json:"status"`
-	RejectionNote      *string           `json:"rejection_note,omitempty"`
-	SubmittedAt        *time.Time        `json:"submitted_at,omitempty"`
-	VerifiedAt         *time.Time        `json:"verified_at,omitempty"`
-	VerifiedBy         *string           `json:"verified_by,omitempty"`
-	CreatedAt          time.Time         `json:"created_at"`
-	UpdatedAt          time.Time         `json:"updated_at"`
-	Detail             *AchievementMongo `json:"detail,omitempty"`
-	UserDetail         UserResponse      `json:"user_detail"`
+	ID                 string               `json:"id"`
+	MongoAchievementID string               `json:"mongo_achievement_id"`
+	Status             string               `json:"status"`
+	RejectionNote      *string              `json:"rejection_note,omitempty"`
+	RevisionNote       *string              `json:"revision_note,omitempty"`
+	RevisionRound      int                  `json:"revision_round"`
+	SubmittedAt        *time.Time           `json:"submitted_at,omitempty"`
+	VerifiedAt         *time.Time           `json:"verified_at,omitempty"`
+	VerifiedBy         *string              `json:"verified_by,omitempty"`
+	CreatedAt          time.Time            `json:"created_at"`
+	UpdatedAt          time.Time            `json:"updated_at"`
+	DeletedAt          *time.Time           `json:"deleted_at,omitempty"`
+	BrokenAt           *time.Time           `json:"broken_at,omitempty"`
+	Detail             *AchievementMongo    `json:"detail,omitempty"`
+	UserDetail         UserResponse         `json:"user_detail"`
+	Comments           []AchievementComment `json:"comments,omitempty"`
+	Owner              ResourceOwner        `json:"-"`
+}
+
+// ResourceOwner identifies the student a record belongs to and the user
+// account of that student's advisor, for use by the access policy.
+type ResourceOwner struct {
+	StudentID     string
+	UserID        string
+	AdvisorUserID string
 }
 
 type AchievementReferenceLecturer struct {
@@ -99,6 +216,7 @@ type AchievementReferenceLecturer struct {
 	Detail             *AchievementMongo `json:"detail,omitempty"`
 	Status             string            `json:"status"`
 	CreatedAt          time.Time         `json:"created_at"`
+	BrokenAt           *time.Time        `json:"broken_at,omitempty"`
 }
 
 type AchievementReferenceStudent struct {
@@ -109,6 +227,7 @@ type AchievementReferenceStudent struct {
 	MongoAchievementID string            `json:"-"`
 	Detail             *AchievementMongo `json:"detail,omitempty"`
 	Status             string            `json:"status"`
+	BrokenAt           *time.Time        `json:"broken_at,omitempty"`
 }
 
 type AchievementReferenceAdmin struct {
@@ -121,13 +240,117 @@ type AchievementReferenceAdmin struct {
 	Detail             *AchievementMongo `json:"detail,omitempty"`
 	Status             string            `json:"status"`
 	CreatedAt          time.Time         `json:"created_at"`
+	DeletedAt          *time.Time        `json:"deleted_at,omitempty"`
+	BrokenAt           *time.Time        `json:"broken_at,omitempty"`
 }
 
 type AchievementHistory struct {
-	Action    string    `json:"action"`
-	Timestamp time.Time `json:"timestamp"`
+	ID            string    `json:"id"`
+	AchievementID string    `json:"achievement_id"`
+	Type          string    `json:"type"`
+	FromStatus    *string   `json:"from_status,omitempty"`
+	ToStatus      string    `json:"to_status,omitempty"`
+	ActorID       *string   `json:"actor_id,omitempty"`
+	ActorName     *string   `json:"actor_name,omitempty"`
+	Note          *string   `json:"note,omitempty"`
+	Timestamp     time.Time `json:"timestamp"`
 }
 
 type CreateRejection struct {
 	RejectionNote string `json:"rejection_note"`
 }
+
+type AchievementComment struct {
+	ID            string       `json:"id"`
+	AchievementID string       `json:"achievement_id"`
+	AuthorID      *string      `json:"author_id,omitempty"`
+	AuthorName    *string      `json:"author_name,omitempty"`
+	AuthorRole    *string      `json:"author_role,omitempty"`
+	Body          string       `json:"body"`
+	Attachments   []Attachment `json:"attachments,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+type CreateCommentRequest struct {
+	Body string `json:"body" form:"body" validate:"required"`
+}
+
+type CreateRevisionRequest struct {
+	RevisionNote string `json:"revision_note" validate:"required"`
+}
+
+type BulkReviewRequest struct {
+	IDs  []string `json:"ids" validate:"required,min=1,max=100,dive,uuid"`
+	Note string   `json:"note"`
+}
+
+type BulkReviewResult struct {
+	ID     string `json:"id"`
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// AchievementFilter holds the query parameters of GET /achievements. Status,
+// student and date criteria are applied in Postgres; type, level, tag, title
+// search and the title/type sort are resolved in Mongo into MongoIDs first.
+type AchievementFilter struct {
+	Status           string `query:"status" validate:"omitempty,oneof=draft submitted verified rejected needs_revision"`
+	AchievementType  string `query:"type" validate:"omitempty,oneof=academic competition organization publication certification other"`
+	CompetitionLevel string `query:"competition_level" validate:"omitempty,oneof=international national regional local"`
+	Tag              string `query:"tag"`
+	AcademicYear     string `query:"academic_year"`
+	ProgramStudy     string `query:"program_study"`
+	From             string `query:"from" validate:"omitempty,datetime=2006-01-02"`
+	To               string `query:"to" validate:"omitempty,datetime=2006-01-02"`
+	Search           string `query:"q"`
+	Sort             string `query:"sort" validate:"omitempty,oneof=created_at submitted_at verified_at status title achievement_type"`
+	Order            string `query:"order" validate:"omitempty,oneof=asc desc"`
+	PageRequest
+
+	// MongoIDs limits the list to these documents. When the order comes from
+	// Mongo they are ranked ascending (best match first for relevance), and
+	// the list applies the requested direction to that rank.
+	MongoIDs []string `query:"-"`
+	// StudentIDs limits the Mongo lookup to the documents of these students;
+	// nil leaves it unscoped.
+	StudentIDs []string `query:"-"`
+}
+
+// SortRelevance orders search results by text score. It is set by the search
+// endpoint with MongoIDs already ranked and cannot be requested on lists.
+const SortRelevance = "relevance"
+
+// AchievementSearchRequest holds the query parameters of GET
+// /achievements/search.
+type AchievementSearchRequest struct {
+	Query           string `query:"q" validate:"required,min=2,max=200"`
+	Status          string `query:"status" validate:"omitempty,oneof=draft submitted verified rejected needs_revision"`
+	AchievementType string `query:"type" validate:"omitempty,oneof=academic competition organization publication certification other"`
+	PageRequest
+}
+
+// SortsInMongo reports whether the requested order comes from a Mongo field.
+func (f AchievementFilter) SortsInMongo() bool {
+	return f.Sort == "title" || f.Sort == "achievement_type" || f.Sort == SortRelevance
+}
+
+// Descending reports the sort direction; without an explicit order dates sort
+// newest first and text columns alphabetically.
+func (f AchievementFilter) Descending() bool {
+	if f.Order != "" {
+		return f.Order == "desc"
+	}
+	return f.Sort == "" || strings.HasSuffix(f.Sort, "_at")
+}
+
+// SupportsCursor reports whether the requested order can be paged by keyset,
+// which needs rows ordered by (created_at, id).
+func (f AchievementFilter) SupportsCursor() bool {
+	return f.Sort == "" || f.Sort == "created_at"
+}
+
+// FiltersInMongo reports whether the Mongo documents must be queried before
+// the Postgres page can be selected.
+func (f AchievementFilter) FiltersInMongo() bool {
+	return f.AchievementType != "" || f.CompetitionLevel != "" || f.Tag != "" || f.Search != "" || f.SortsInMongo()
+}
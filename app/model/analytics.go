@@ -1,8 +1,9 @@
 package model
 
 type Statistics struct {
-	Tahun string   `json:"tahun"`
-	Data  Regional `json:"data"`
+	Tahun  string   `json:"tahun"`
+	Data   Regional `json:"data"`
+	Points int      `json:"points"`
 }
 
 type Regional struct {
@@ -8,5 +8,12 @@ type Claims struct {
 	FullName    string   `json:"full_name"`
 	Role        string   `json:"role"`
 	Permissions []string `json:"permissions,omitempty"`
+	// SessionID is set on access tokens and names the session they belong to.
+	SessionID string `json:"sid,omitempty"`
+	// TokenVersion is the user's token version at issue time. A token whose
+	// version is behind the current one is no longer accepted.
+	TokenVersion int64 `json:"ver"`
+	// Family is set on refresh tokens only and names the login they descend from.
+	Family string `json:"fam,omitempty"`
 	jwt.RegisteredClaims
 }
@@ -1,5 +1,12 @@
 package model
 
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
 type WebResponse[T any] struct {
 	Status string        `json:"status"`
 	Data   T             `json:"data"`
@@ -8,6 +15,75 @@ type WebResponse[T any] struct {
 }
 
 type PageMetaData struct {
-	Page int `json:"page"`
-	Size int `json:"size"`
+	Page       int    `json:"page"`
+	Size       int    `json:"size"`
+	TotalItems int    `json:"total_items"`
+	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Truncated is set when only the first candidates of a Mongo-side filter
+	// were paged, so later matches and the totals are cut off.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// PageRequest is the paging input shared by list endpoints. When Cursor is
+// set the list continues right after the row it points at and Page is ignored.
+type PageRequest struct {
+	Page   int    `query:"page" validate:"gte=1"`
+	Limit  int    `query:"limit" validate:"gte=1,lte=100"`
+	Cursor string `query:"cursor"`
+}
+
+func NewPageRequest() PageRequest {
+	return PageRequest{Page: 1, Limit: 10}
+}
+
+func (p PageRequest) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// PageResult is one page of a list together with the size of the whole list.
+type PageResult[T any] struct {
+	Items      []T
+	TotalItems int
+	NextCursor string
+}
+
+func NewPageMetaData[T any](page PageRequest, result *PageResult[T]) *PageMetaData {
+	totalPages := 0
+	if page.Limit > 0 {
+		totalPages = (result.TotalItems + page.Limit - 1) / page.Limit
+	}
+	return &PageMetaData{
+		Page:       page.Page,
+		Size:       page.Limit,
+		TotalItems: result.TotalItems,
+		TotalPages: totalPages,
+		NextCursor: result.NextCursor,
+	}
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageCursor marks the last row of a page for keyset pagination over
+// (created_at, id).
+type PageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        string    `json:"id"`
+}
+
+func (c PageCursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodePageCursor(value string) (*PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	cursor := &PageCursor{}
+	if err := json.Unmarshal(raw, cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return cursor, nil
 }
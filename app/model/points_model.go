@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// PointRule awards Points to achievements of AchievementType. Empty
+// CompetitionLevel / MedalType and a zero Rank match any value.
+type PointRule struct {
+	AchievementType  string `json:"achievement_type" validate:"required"`
+	CompetitionLevel string `json:"competition_level,omitempty"`
+	Rank             int    `json:"rank,omitempty" validate:"gte=0"`
+	MedalType        string `json:"medal_type,omitempty"`
+	Points           int    `json:"points" validate:"gte=0"`
+}
+
+type PointRuleSet struct {
+	ID        string      `json:"id"`
+	Version   int         `json:"version"`
+	Rules     []PointRule `json:"rules"`
+	CreatedBy *string     `json:"created_by,omitempty"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type CreatePointRuleSetRequest struct {
+	Rules []PointRule `json:"rules" validate:"required,min=1,dive"`
+}
+
+type RecalculatePointsResult struct {
+	Version int `json:"version"`
+	Updated int `json:"updated"`
+}
+
+type PeriodPoints struct {
+	Tahun  string `json:"tahun" bson:"tahun"`
+	Points int    `json:"points" bson:"points"`
+}
+
+type StudentPoints struct {
+	StudentID string         `json:"student_id"`
+	Total     int            `json:"total"`
+	Periods   []PeriodPoints `json:"periods"`
+}
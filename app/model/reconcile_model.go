@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// ReconcileRequest tunes a consistency scan between achievement_references
+// and the student_achievements collection.
+type ReconcileRequest struct {
+	Repair    bool `json:"repair" query:"repair"`
+	BatchSize int  `json:"batch_size" query:"batch_size" validate:"min=1,max=1000"`
+}
+
+// ReferenceLink is the part of a reference row a reconciliation scan needs.
+type ReferenceLink struct {
+	ID                 string
+	MongoAchievementID string
+}
+
+// ReconcileReport lists what a scan found. Orphan documents are Mongo
+// documents without a reference row; dangling references point to a Mongo
+// document that no longer exists. With Repair, orphans are deleted and
+// dangling references get broken_at set.
+type ReconcileReport struct {
+	ScannedDocuments   int       `json:"scanned_documents"`
+	ScannedReferences  int       `json:"scanned_references"`
+	OrphanDocuments    []string  `json:"orphan_documents"`
+	DanglingReferences []string  `json:"dangling_references"`
+	Repaired           bool      `json:"repaired"`
+	StartedAt          time.Time `json:"started_at"`
+	FinishedAt         time.Time `json:"finished_at"`
+}
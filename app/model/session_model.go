@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Session is one login of a user. Its ID is the refresh token family, so
+// revoking a session also stops its refresh tokens.
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Device     string    `json:"device"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// Current marks the session of the token making the request.
+	Current bool `json:"current"`
+}
+
+type RevokeSessionsResponse struct {
+	Revoked int `json:"revoked"`
+}
@@ -2,6 +2,12 @@ package model
 
 import "database/sql"
 
+const (
+	RoleStudent  = "mahasiswa"
+	RoleLecturer = "lecturer"
+	RoleAdmin    = "admin"
+)
+
 type UserCreateRequest struct {
 	Username        string          `json:"username" validate:"required"`
 	Email           string          `json:"email" validate:"required,email"`
@@ -30,6 +36,22 @@ type UserUpdateRole struct {
 type LoginRequest struct {
 	Username string `json:"username" validate:"required"`
 	Password string `json:"password" validate:"required"`
+	// Device is an optional client-chosen label shown in the session list.
+	Device string `json:"device"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
 }
 
 type RefreshTokenRequest struct {
@@ -60,6 +82,7 @@ type UserResponse struct {
 	Username        string          `json:"username"`
 	FullName        string          `json:"full_name"`
 	Role            string          `json:"role,omitempty"`
+	IsActive        bool            `json:"is_active"`
 	StudentProfile  *StudentCreate  `json:"student_profile,omitempty"`
 	LecturerProfile *LecturerCreate `json:"lecturer_profile,omitempty"`
 }
@@ -90,6 +113,18 @@ type User struct {
 	RoleId       string
 	RoleName     string
 	Permissions  []string
+	IsActive     bool
+}
+
+const (
+	UserStatusActive   = "active"
+	UserStatusInactive = "inactive"
+)
+
+// UserFilter narrows the user list. An empty Status lists every user.
+type UserFilter struct {
+	Status string `query:"status" validate:"omitempty,oneof=active inactive"`
+	PageRequest
 }
 
 type UserProfile struct {
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"prisma/app/model"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type AchievementCommentRepository interface {
+	Save(ctx context.Context, comment model.AchievementComment) (*model.AchievementComment, error)
+	FindByAchievementID(ctx context.Context, id string) ([]model.AchievementComment, error)
+	SetAttachmentScanStatus(ctx context.Context, id string, attachmentID string, status string) error
+	FindPendingScans(ctx context.Context) ([]model.ScanJob, error)
+}
+
+// storedAttachment is an attachment as kept in the attachments column. Unlike
+// the API form it includes the storage keys.
+type storedAttachment struct {
+	model.Attachment
+	StorageKey string `json:"storage_key,omitempty"`
+	PreviewKey string `json:"preview_key,omitempty"`
+}
+
+func marshalAttachments(attachments []model.Attachment) (string, error) {
+	stored := make([]storedAttachment, 0, len(attachments))
+	for _, attachment := range attachments {
+		stored = append(stored, storedAttachment{
+			Attachment: attachment,
+			StorageKey: attachment.StorageKey,
+			PreviewKey: attachment.PreviewKey,
+		})
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return "", fmt.Errorf("marshal attachments: %w", err)
+	}
+	return string(data), nil
+}
+
+func unmarshalAttachments(data string) ([]model.Attachment, error) {
+	var stored []storedAttachment
+	if err := json.Unmarshal([]byte(data), &stored); err != nil {
+		return nil, fmt.Errorf("unmarshal attachments: %w", err)
+	}
+	attachments := make([]model.Attachment, 0, len(stored))
+	for _, attachment := range stored {
+		attachment.Attachment.StorageKey = attachment.StorageKey
+		attachment.Attachment.PreviewKey = attachment.PreviewKey
+		attachments = append(attachments, attachment.Attachment)
+	}
+	return attachments, nil
+}
+
+type achievementCommentRepository struct {
+	Log *logrus.Logger
+	DB  *sql.DB
+}
+
+func NewAchievementCommentRepository(log *logrus.Logger, db *sql.DB) AchievementCommentRepository {
+	return &achievementCommentRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func (repo *achievementCommentRepository) Save(ctx context.Context, comment model.AchievementComment) (*model.AchievementComment, error) {
+	if comment.Attachments == nil {
+		comment.Attachments = []model.Attachment{}
+	}
+	attachments, err := marshalAttachments(comment.Attachments)
+	if err != nil {
+		return nil, err
+	}
+
+	comment.CreatedAt = time.Now()
+	SQL := `INSERT INTO achievement_comments(achievement_reference_id, author_id, body, attachments, created_at)
+			VALUES ($1, $2, $3, $4, $5) RETURNING id`
+	err = repo.DB.QueryRowContext(ctx, SQL, comment.AchievementID, comment.AuthorID, comment.Body,
+		attachments, comment.CreatedAt).Scan(&comment.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (repo *achievementCommentRepository) FindByAchievementID(ctx context.Context, id string) ([]model.AchievementComment, error) {
+	SQL := `SELECT c.id,c.achievement_reference_id,c.author_id,u.full_name,r.name,c.body,c.attachments,c.created_at
+			FROM achievement_comments c
+			LEFT JOIN users as u ON u.id = c.author_id
+			LEFT JOIN roles as r ON r.id = u.role_id
+			WHERE c.achievement_reference_id = $1
+			ORDER BY c.created_at ASC, c.id ASC`
+
+	rows, err := repo.DB.QueryContext(ctx, SQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []model.AchievementComment{}
+	for rows.Next() {
+		comment := model.AchievementComment{}
+		var attachments string
+		err := rows.Scan(&comment.ID, &comment.AchievementID, &comment.AuthorID, &comment.AuthorName,
+			&comment.AuthorRole, &comment.Body, &attachments, &comment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		comment.Attachments, err = unmarshalAttachments(attachments)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// SetAttachmentScanStatus records the scan result of one attachment of a
+// comment.
+func (repo *achievementCommentRepository) SetAttachmentScanStatus(ctx context.Context, id string, attachmentID string, status string) error {
+	SQL := `UPDATE achievement_comments SET attachments = (
+				SELECT jsonb_agg(CASE WHEN a->>'id' = $2 THEN jsonb_set(a, '{scan_status}', to_jsonb($3::text)) ELSE a END)
+				FROM jsonb_array_elements(attachments) AS a)
+			WHERE id = $1 AND jsonb_array_length(attachments) > 0`
+	_, err := repo.DB.ExecContext(ctx, SQL, id, attachmentID, status)
+	return err
+}
+
+// FindPendingScans returns a job for every comment attachment still waiting
+// for its malware scan.
+func (repo *achievementCommentRepository) FindPendingScans(ctx context.Context) ([]model.ScanJob, error) {
+	SQL := `SELECT id, attachments FROM achievement_comments
+			WHERE attachments @> $1::jsonb`
+	pending := fmt.Sprintf(`[{"scan_status": %q}]`, model.ScanPending)
+
+	rows, err := repo.DB.QueryContext(ctx, SQL, pending)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	jobs := []model.ScanJob{}
+	for rows.Next() {
+		var id, data string
+		if err := rows.Scan(&id, &data); err != nil {
+			return nil, err
+		}
+		attachments, err := unmarshalAttachments(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, attachment := range attachments {
+			if attachment.ScanStatus == model.ScanPending {
+				jobs = append(jobs, model.ScanJob{
+					CommentID:    id,
+					AttachmentID: attachment.ID,
+					StorageKey:   attachment.StorageKey,
+					ContentType:  attachment.FileType,
+				})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"prisma/app/model"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type AchievementHistoryRepository interface {
+	Save(ctx context.Context, tx *sql.Tx, history model.AchievementHistory) (*model.AchievementHistory, error)
+	FindByAchievementID(ctx context.Context, id string) ([]model.AchievementHistory, error)
+}
+
+type achievementHistoryRepository struct {
+	Log *logrus.Logger
+	DB  *sql.DB
+}
+
+func NewAchievementHistoryRepository(log *logrus.Logger, db *sql.DB) AchievementHistoryRepository {
+	return &achievementHistoryRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func (repo *achievementHistoryRepository) Save(ctx context.Context, tx *sql.Tx, history model.AchievementHistory) (*model.AchievementHistory, error) {
+	history.Timestamp = time.Now()
+	SQL := `INSERT INTO achievement_status_history(achievement_reference_id, from_status, to_status, actor_id, note, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := tx.QueryRowContext(ctx, SQL, history.AchievementID, history.FromStatus, history.ToStatus,
+		history.ActorID, history.Note, history.Timestamp).Scan(&history.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+func (repo *achievementHistoryRepository) FindByAchievementID(ctx context.Context, id string) ([]model.AchievementHistory, error) {
+	SQL := `SELECT h.id,h.achievement_reference_id,h.from_status,h.to_status,h.actor_id,u.full_name,h.note,h.created_at
+			FROM achievement_status_history h
+			LEFT JOIN users as u ON u.id = h.actor_id
+			WHERE h.achievement_reference_id = $1
+			ORDER BY h.created_at ASC, h.id ASC`
+
+	rows, err := repo.DB.QueryContext(ctx, SQL, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	histories := []model.AchievementHistory{}
+	for rows.Next() {
+		history := model.AchievementHistory{Type: model.HistoryTypeStatusChange}
+		err := rows.Scan(&history.ID, &history.AchievementID, &history.FromStatus, &history.ToStatus,
+			&history.ActorID, &history.ActorName, &history.Note, &history.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
@@ -12,15 +12,29 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// ErrStatusChanged is returned by UpdateStatus when the achievement is no
+// longer in the status the change was decided on.
+var ErrStatusChanged = errors.New("achievement status was changed by someone else")
+
 type AchievementReferenceRepository interface {
-	Create(ctx context.Context, achievement model.AchievementReference) (*model.AchievementReference, error)
-	Update(ctx context.Context, achievement model.AchievementReference) (*model.AchievementReference, error)
+	Create(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference) (*model.AchievementReference, error)
+	Update(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference) (*model.AchievementReference, error)
+	UpdateStatus(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference, from string) (*model.AchievementReference, error)
 	Delete(ctx context.Context, id string) error
 	FindByID(ctx context.Context, id string) (*model.AchievementReferenceDetail, error)
-	FindByLecturer(ctx context.Context, id string, page int, limit int) ([]model.AchievementReferenceLecturer, error)
-	FindByStudent(ctx context.Context, id string, page int, limit int) ([]model.AchievementReferenceStudent, error)
-	FindAll(ctx context.Context, page int, limit int) ([]model.AchievementReferenceAdmin, error)
-	FindByStudentId(ctx context.Context, id string, page int, limit int) ([]model.AchievementReferenceAdmin, error)
+	FindByLecturer(ctx context.Context, id string, filter model.AchievementFilter) (*model.PageResult[model.AchievementReferenceLecturer], error)
+	FindByStudent(ctx context.Context, id string, filter model.AchievementFilter) (*model.PageResult[model.AchievementReferenceStudent], error)
+	FindAll(ctx context.Context, filter model.AchievementFilter) (*model.PageResult[model.AchievementReferenceAdmin], error)
+	FindByStudentId(ctx context.Context, id string, page model.PageRequest) (*model.PageResult[model.AchievementReferenceAdmin], error)
+	FindMongoIDsByStatus(ctx context.Context, status string) ([]string, error)
+	FindLinks(ctx context.Context, afterID string, limit int) ([]model.ReferenceLink, error)
+	FindExistingMongoIDs(ctx context.Context, mongoIDs []string) ([]string, error)
+	MarkBroken(ctx context.Context, ids []string) error
+	FindDeletedByID(ctx context.Context, id string) (*model.AchievementReferenceDetail, error)
+	FindTrash(ctx context.Context, studentID string, page model.PageRequest) (*model.PageResult[model.AchievementReferenceAdmin], error)
+	Restore(ctx context.Context, id string) error
+	FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]model.ReferenceLink, error)
+	Purge(ctx context.Context, id string) error
 }
 
 type achievementReferenceRepository struct {
@@ -35,54 +49,33 @@ func NewAchievementReferenceRepository(log *logrus.Logger, db *sql.DB) Achieveme
 	}
 }
 
-func (repo *achievementReferenceRepository) FindByStudentId(ctx context.Context, id string, page int, limit int) ([]model.AchievementReferenceAdmin, error) {
-	skip := (page - 1) * limit
-	SQL := `SELECT a.id,a.mongo_achievement_id,a.status,u.username,u.full_name,u.email,
-			s.program_study,s.academic_year,s.student_id,l.department,u2.username,u2.email,u2.full_name FROM achievement_references a
-			JOIN students as s ON s.id = a.student_id
-			JOIN lecturers as l ON l.id = s.advisor_id
-            JOIN users as u ON u.id = s.user_id
-			JOIN users as u2 ON u2.id = l.user_id
-			WHERE a.status != 'DELETED' AND s.id = $1                                                                                  
-			LIMIT $2 OFFSET $3`
-
-	rows, err := repo.DB.QueryContext(ctx, SQL, id, limit, skip)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	achievements := []model.AchievementReferenceAdmin{}
-	for rows.Next() {
-		achievement := model.AchievementReferenceAdmin{}
-		err := rows.Scan(&achievement.ID, &achievement.MongoAchievementID, &achievement.Status,
-			&achievement.Student.Username, &achievement.Student.FullName, &achievement.Student.Email,
-			&achievement.Student.StudentProfile.ProgramStudy, &achievement.Student.StudentProfile.AcademicYear,
-			&achievement.Student.StudentProfile.StudentID, &achievement.Lecturer.LecturerProfile.Department, &achievement.Lecturer.Username,
-			&achievement.Lecturer.Email, &achievement.Lecturer.FullName)
-		if err != nil {
-			return nil, err
-		}
-		achievements = append(achievements, achievement)
-	}
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return achievements, nil
+func (repo *achievementReferenceRepository) FindByStudentId(ctx context.Context, id string, page model.PageRequest) (*model.PageResult[model.AchievementReferenceAdmin], error) {
+	return repo.findAdmin(ctx, []string{"a.deleted_at IS NULL", "s.id = $1"}, []any{id}, "a.created_at DESC, a.id DESC", page, true, true)
 }
 
-func (repo *achievementReferenceRepository) Create(ctx context.Context, achievement model.AchievementReference) (*model.AchievementReference, error) {
+func (repo *achievementReferenceRepository) Create(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference) (*model.AchievementReference, error) {
 	ts := time.Now()
 	SQL := "INSERT INTO achievement_references(student_id, mongo_achievement_id, status, created_at,updated_at) VALUES ($1, $2, $3, $4, $5) RETURNING id"
-	err := repo.DB.QueryRowContext(ctx, SQL, achievement.StudentID, achievement.MongoAchievementID, achievement.Status, ts, ts).Scan(&achievement.ID)
+	err := tx.QueryRowContext(ctx, SQL, achievement.StudentID, achievement.MongoAchievementID, achievement.Status, ts, ts).Scan(&achievement.ID)
 	if err != nil {
 		return nil, err
 	}
+	achievement.CreatedAt = ts
 	return &achievement, nil
 }
 
-func (repo *achievementReferenceRepository) Update(ctx context.Context, achievement model.AchievementReference) (*model.AchievementReference, error) {
+func (repo *achievementReferenceRepository) Update(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference) (*model.AchievementReference, error) {
+	return repo.update(ctx, tx, achievement, "")
+}
+
+// UpdateStatus is Update for a status change: it only applies while the
+// status is still from, so two reviewers deciding on the same achievement
+// cannot both win.
+func (repo *achievementReferenceRepository) UpdateStatus(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference, from string) (*model.AchievementReference, error) {
+	return repo.update(ctx, tx, achievement, from)
+}
+
+func (repo *achievementReferenceRepository) update(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference, from string) (*model.AchievementReference, error) {
 	ts := time.Now()
 
 	var setClauses []string
@@ -116,6 +109,18 @@ func (repo *achievementReferenceRepository) Update(ctx context.Context, achievem
 		argId++
 	}
 
+	if achievement.RevisionNote != "" {
+		setClauses = append(setClauses, fmt.Sprintf("revision_note = $%d", argId))
+		args = append(args, achievement.RevisionNote)
+		argId++
+	}
+
+	if achievement.RevisionRound > 0 {
+		setClauses = append(setClauses, fmt.Sprintf("revision_round = $%d", argId))
+		args = append(args, achievement.RevisionRound)
+		argId++
+	}
+
 	setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", argId))
 	args = append(args, ts)
 	argId++
@@ -124,8 +129,13 @@ func (repo *achievementReferenceRepository) Update(ctx context.Context, achievem
 
 	SQL := fmt.Sprintf("UPDATE achievement_references SET %s WHERE id = $%d", setQuery, argId)
 	args = append(args, achievement.ID)
+	if from != "" {
+		argId++
+		SQL += fmt.Sprintf(" AND status = $%d", argId)
+		args = append(args, from)
+	}
 
-	res, err := repo.DB.ExecContext(ctx, SQL, args...)
+	res, err := tx.ExecContext(ctx, SQL, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -133,14 +143,20 @@ func (repo *achievementReferenceRepository) Update(ctx context.Context, achievem
 	if err != nil {
 		return nil, err
 	}
+	if aff == 0 && from != "" {
+		return nil, ErrStatusChanged
+	}
 	if aff == 0 {
 		return nil, errors.New("no rows affected")
 	}
 
 	return &achievement, nil
 }
+
+// Delete moves the reference to the trash. It stays restorable until the
+// purge job removes it for good.
 func (repo *achievementReferenceRepository) Delete(ctx context.Context, id string) error {
-	SQL := "UPDATE achievement_references SET status = 'DELETED' WHERE id = $1"
+	SQL := "UPDATE achievement_references SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL"
 	res, err := repo.DB.ExecContext(ctx, SQL, id)
 	if err != nil {
 		return err
@@ -156,16 +172,28 @@ func (repo *achievementReferenceRepository) Delete(ctx context.Context, id strin
 }
 
 func (repo *achievementReferenceRepository) FindByID(ctx context.Context, id string) (*model.AchievementReferenceDetail, error) {
-	SQL := `SELECT a.id,a.status,a.mongo_achievement_id,a.submitted_at,a.verified_at,
-     a.verified_by,a.rejection_note,a.created_at,a.updated_at,
-    u.username,u.full_name,u.email,s.student_id,s.academic_year,s.program_study FROM achievement_references as a
+	return repo.findByID(ctx, id, "a.deleted_at IS NULL")
+}
+
+// FindDeletedByID looks up a reference that is in the trash.
+func (repo *achievementReferenceRepository) FindDeletedByID(ctx context.Context, id string) (*model.AchievementReferenceDetail, error) {
+	return repo.findByID(ctx, id, "a.deleted_at IS NOT NULL")
+}
+
+func (repo *achievementReferenceRepository) findByID(ctx context.Context, id string, deleted string) (*model.AchievementReferenceDetail, error) {
+	SQL := fmt.Sprintf(`SELECT a.id,a.status,a.mongo_achievement_id,a.submitted_at,a.verified_at,
+     a.verified_by,a.rejection_note,a.revision_note,a.revision_round,a.created_at,a.updated_at,a.deleted_at,
+    a.broken_at,u.username,u.full_name,u.email,s.student_id,s.academic_year,s.program_study,
+    s.id,s.user_id,l.user_id FROM achievement_references as a
         JOIN students as s ON s.id = a.student_id
-        JOIN users as u ON u.id = s.user_id   
-           WHERE a.id = $1 AND a.status != 'DELETED'`
+        JOIN users as u ON u.id = s.user_id
+        LEFT JOIN lecturers as l ON l.id = s.advisor_id
+           WHERE a.id = $1 AND %s`, deleted)
 
 	achievement := model.AchievementReferenceDetail{}
 	achievement.UserDetail = model.UserResponse{}
 	achievement.UserDetail.StudentProfile = &model.StudentCreate{}
+	var advisorUserID sql.NullString
 
 	err := repo.DB.QueryRowContext(ctx, SQL, id).Scan(
 		&achievement.ID,
@@ -175,45 +203,64 @@ func (repo *achievementReferenceRepository) FindByID(ctx context.Context, id str
 		&achievement.VerifiedAt,
 		&achievement.VerifiedBy,
 		&achievement.RejectionNote,
+		&achievement.RevisionNote,
+		&achievement.RevisionRound,
 		&achievement.CreatedAt,
 		&achievement.UpdatedAt,
+		&achievement.DeletedAt,
+		&achievement.BrokenAt,
 		&achievement.UserDetail.Username,
 		&achievement.UserDetail.FullName,
 		&achievement.UserDetail.Email,
 		&achievement.UserDetail.StudentProfile.StudentID,
 		&achievement.UserDetail.StudentProfile.AcademicYear,
 		&achievement.UserDetail.StudentProfile.ProgramStudy,
+		&achievement.Owner.StudentID,
+		&achievement.Owner.UserID,
+		&advisorUserID,
 	)
 
 	if err != nil {
 		return nil, err
 	}
+	achievement.Owner.AdvisorUserID = advisorUserID.String
 
 	return &achievement, nil
 }
 
-func (repo *achievementReferenceRepository) FindByLecturer(ctx context.Context, id string, page int, limit int) ([]model.AchievementReferenceLecturer, error) {
-	skip := (page - 1) * limit
-	SQL := `SELECT a.id,a.mongo_achievement_id,a.status,u.username,u.full_name,u.email,
-			s.program_study,s.academic_year,s.student_id FROM achievement_references a
+func (repo *achievementReferenceRepository) FindByLecturer(ctx context.Context, id string, filter model.AchievementFilter) (*model.PageResult[model.AchievementReferenceLecturer], error) {
+	from := `FROM achievement_references a
 			JOIN students as s ON s.id = a.student_id
 			JOIN lecturers as l ON l.id = s.advisor_id
-            JOIN users as u ON u.id = s.user_id
-			WHERE l.user_id = $1 AND a.status != 'DELETED'
-			LIMIT $2 OFFSET $3`
+            JOIN users as u ON u.id = s.user_id`
+	conditions, args, orderBy := achievementFilterSQL(filter, []string{"l.user_id = $1"}, []any{id})
+	total, err := countRows(ctx, repo.DB, from, conditions, args)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := repo.DB.QueryContext(ctx, SQL, id, limit, skip)
+	conditions, args, limit, err := pageSQL(filter.PageRequest, "a.created_at", "a.id", filter.Descending(), conditions, args)
+	if err != nil {
+		return nil, err
+	}
+	SQL := fmt.Sprintf(`SELECT a.id,a.mongo_achievement_id,a.status,a.created_at,a.broken_at,u.username,u.full_name,u.email,
+			s.program_study,s.academic_year,s.student_id %s
+			%s
+			ORDER BY %s
+			%s`, from, whereSQL(conditions), orderBy, limit)
+
+	rows, err := repo.DB.QueryContext(ctx, SQL, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	achievements := []model.AchievementReferenceLecturer{}
-
+	cursors := []model.PageCursor{}
 	for rows.Next() {
 		achievement := model.AchievementReferenceLecturer{}
-		achievement.Student = model.UserResponse{}
-		err := rows.Scan(&achievement.ID, &achievement.MongoAchievementID, &achievement.Status,
+		achievement.Student = model.UserResponse{StudentProfile: &model.StudentCreate{}}
+		err := rows.Scan(&achievement.ID, &achievement.MongoAchievementID, &achievement.Status, &achievement.CreatedAt, &achievement.BrokenAt,
 			&achievement.Student.Username, &achievement.Student.FullName, &achievement.Student.Email,
 			&achievement.Student.StudentProfile.ProgramStudy, &achievement.Student.StudentProfile.AcademicYear,
 			&achievement.Student.StudentProfile.StudentID)
@@ -221,66 +268,107 @@ func (repo *achievementReferenceRepository) FindByLecturer(ctx context.Context,
 			return nil, err
 		}
 		achievements = append(achievements, achievement)
+		cursors = append(cursors, model.PageCursor{CreatedAt: achievement.CreatedAt, ID: achievement.ID})
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return achievements, nil
+	result := &model.PageResult[model.AchievementReferenceLecturer]{TotalItems: total}
+	result.Items, result.NextCursor = nextPage(achievements, cursors, filter.Limit)
+	if !filter.SupportsCursor() {
+		result.NextCursor = ""
+	}
+	return result, nil
 }
 
-func (repo *achievementReferenceRepository) FindByStudent(ctx context.Context, id string, page int, limit int) ([]model.AchievementReferenceStudent, error) {
-	skip := (page - 1) * limit
-	SQL := `SELECT a.id,a.mongo_achievement_id,a.status
-			FROM achievement_references a
+func (repo *achievementReferenceRepository) FindByStudent(ctx context.Context, id string, filter model.AchievementFilter) (*model.PageResult[model.AchievementReferenceStudent], error) {
+	from := `FROM achievement_references a
 			JOIN students as s ON s.id = a.student_id
-            JOIN users as u ON u.id = s.user_id
-			WHERE s.user_id = $1 AND a.status != 'DELETED'
-			LIMIT $2 OFFSET $3`
+            JOIN users as u ON u.id = s.user_id`
+	conditions, args, orderBy := achievementFilterSQL(filter, []string{"s.user_id = $1"}, []any{id})
+	total, err := countRows(ctx, repo.DB, from, conditions, args)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions, args, limit, err := pageSQL(filter.PageRequest, "a.created_at", "a.id", filter.Descending(), conditions, args)
+	if err != nil {
+		return nil, err
+	}
+	SQL := fmt.Sprintf(`SELECT a.id,a.mongo_achievement_id,a.status,a.created_at,a.broken_at %s
+			%s
+			ORDER BY %s
+			%s`, from, whereSQL(conditions), orderBy, limit)
 
-	rows, err := repo.DB.QueryContext(ctx, SQL, id, limit, skip)
+	rows, err := repo.DB.QueryContext(ctx, SQL, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	achievements := []model.AchievementReferenceStudent{}
+	cursors := []model.PageCursor{}
 	for rows.Next() {
 		achievement := model.AchievementReferenceStudent{}
-		err := rows.Scan(&achievement.ID, &achievement.MongoAchievementID, &achievement.Status)
+		err := rows.Scan(&achievement.ID, &achievement.MongoAchievementID, &achievement.Status, &achievement.CreatedAt, &achievement.BrokenAt)
 		if err != nil {
 			return nil, err
 		}
 		achievements = append(achievements, achievement)
+		cursors = append(cursors, model.PageCursor{CreatedAt: achievement.CreatedAt, ID: achievement.ID})
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return achievements, nil
+	result := &model.PageResult[model.AchievementReferenceStudent]{TotalItems: total}
+	result.Items, result.NextCursor = nextPage(achievements, cursors, filter.Limit)
+	if !filter.SupportsCursor() {
+		result.NextCursor = ""
+	}
+	return result, nil
+}
+
+func (repo *achievementReferenceRepository) FindAll(ctx context.Context, filter model.AchievementFilter) (*model.PageResult[model.AchievementReferenceAdmin], error) {
+	conditions, args, orderBy := achievementFilterSQL(filter, nil, nil)
+	return repo.findAdmin(ctx, conditions, args, orderBy, filter.PageRequest, filter.Descending(), filter.SupportsCursor())
 }
 
-func (repo *achievementReferenceRepository) FindAll(ctx context.Context, page int, limit int) ([]model.AchievementReferenceAdmin, error) {
-	skip := (page - 1) * limit
-	SQL := `SELECT a.id,a.mongo_achievement_id,a.status,u.username,u.full_name,u.email,
-			s.program_study,s.academic_year,s.student_id,l.department,u2.username,u2.email,u2.full_name FROM achievement_references a
+func (repo *achievementReferenceRepository) findAdmin(ctx context.Context, conditions []string, args []any, orderBy string, page model.PageRequest, descending bool, keyset bool) (*model.PageResult[model.AchievementReferenceAdmin], error) {
+	from := `FROM achievement_references a
 			JOIN students as s ON s.id = a.student_id
 			JOIN lecturers as l ON l.id = s.advisor_id
             JOIN users as u ON u.id = s.user_id
-			JOIN users as u2 ON u2.id = l.user_id
-			WHERE a.status != 'DELETED'                                                                                   
-			LIMIT $1 OFFSET $2`
+			JOIN users as u2 ON u2.id = l.user_id`
+	total, err := countRows(ctx, repo.DB, from, conditions, args)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions, args, limit, err := pageSQL(page, "a.created_at", "a.id", descending, conditions, args)
+	if err != nil {
+		return nil, err
+	}
+	SQL := fmt.Sprintf(`SELECT a.id,a.mongo_achievement_id,a.status,a.created_at,a.deleted_at,a.broken_at,u.username,u.full_name,u.email,
+			s.program_study,s.academic_year,s.student_id,l.department,u2.username,u2.email,u2.full_name %s
+			%s
+			ORDER BY %s
+			%s`, from, whereSQL(conditions), orderBy, limit)
 
-	rows, err := repo.DB.QueryContext(ctx, SQL, limit, skip)
+	rows, err := repo.DB.QueryContext(ctx, SQL, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	achievements := []model.AchievementReferenceAdmin{}
+	cursors := []model.PageCursor{}
 	for rows.Next() {
 		achievement := model.AchievementReferenceAdmin{}
-		err := rows.Scan(&achievement.ID, &achievement.MongoAchievementID, &achievement.Status,
+		achievement.Student = model.UserResponse{StudentProfile: &model.StudentCreate{}}
+		achievement.Lecturer = model.UserResponse{LecturerProfile: &model.LecturerCreate{}}
+		err := rows.Scan(&achievement.ID, &achievement.MongoAchievementID, &achievement.Status, &achievement.CreatedAt, &achievement.DeletedAt, &achievement.BrokenAt,
 			&achievement.Student.Username, &achievement.Student.FullName, &achievement.Student.Email,
 			&achievement.Student.StudentProfile.ProgramStudy, &achievement.Student.StudentProfile.AcademicYear,
 			&achievement.Student.StudentProfile.StudentID, &achievement.Lecturer.LecturerProfile.Department, &achievement.Lecturer.Username,
@@ -289,10 +377,223 @@ func (repo *achievementReferenceRepository) FindAll(ctx context.Context, page in
 			return nil, err
 		}
 		achievements = append(achievements, achievement)
+		cursors = append(cursors, model.PageCursor{CreatedAt: achievement.CreatedAt, ID: achievement.ID})
 	}
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return achievements, nil
+	result := &model.PageResult[model.AchievementReferenceAdmin]{TotalItems: total}
+	result.Items, result.NextCursor = nextPage(achievements, cursors, page.Limit)
+	if !keyset {
+		result.NextCursor = ""
+	}
+	return result, nil
+}
+
+// achievementFilterSQL appends the Postgres side of filter to the caller's
+// conditions and args, and returns the ORDER BY clause. When the order comes
+// from Mongo, rows follow the position of their id in filter.MongoIDs, in the
+// requested direction.
+func achievementFilterSQL(filter model.AchievementFilter, conditions []string, args []any) ([]string, []any, string) {
+	add := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	conditions = append(conditions, "a.deleted_at IS NULL")
+	if filter.Status != "" {
+		add("a.status = $%d", filter.Status)
+	}
+	if filter.AcademicYear != "" {
+		add("s.academic_year = $%d", filter.AcademicYear)
+	}
+	if filter.ProgramStudy != "" {
+		add("s.program_study = $%d", filter.ProgramStudy)
+	}
+	if filter.From != "" {
+		add("a.created_at >= $%d::date", filter.From)
+	}
+	if filter.To != "" {
+		add("a.created_at < $%d::date + INTERVAL '1 day'", filter.To)
+	}
+	mongoIDs := 0
+	if filter.MongoIDs != nil {
+		add("a.mongo_achievement_id = ANY($%d::text[])", filter.MongoIDs)
+		mongoIDs = len(args)
+	}
+
+	direction := "ASC"
+	if filter.Descending() {
+		direction = "DESC"
+	}
+	var orderBy string
+	switch filter.Sort {
+	case "title", "achievement_type", model.SortRelevance:
+		if mongoIDs > 0 {
+			orderBy = fmt.Sprintf("array_position($%d::text[], a.mongo_achievement_id::text) %s, a.id %s", mongoIDs, direction, direction)
+		} else {
+			orderBy = fmt.Sprintf("a.id %s", direction)
+		}
+	case "submitted_at", "verified_at", "status":
+		orderBy = fmt.Sprintf("a.%s %s NULLS LAST, a.id", filter.Sort, direction)
+	default:
+		orderBy = fmt.Sprintf("a.created_at %s, a.id %s", direction, direction)
+	}
+	return conditions, args, orderBy
+}
+
+func (repo *achievementReferenceRepository) FindMongoIDsByStatus(ctx context.Context, status string) ([]string, error) {
+	SQL := `SELECT mongo_achievement_id FROM achievement_references WHERE status = $1 AND deleted_at IS NULL`
+	rows, err := repo.DB.QueryContext(ctx, SQL, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FindLinks returns up to limit references ordered by id, starting after
+// afterID, for scans that walk the whole table in batches.
+func (repo *achievementReferenceRepository) FindLinks(ctx context.Context, afterID string, limit int) ([]model.ReferenceLink, error) {
+	SQL := `SELECT id, mongo_achievement_id FROM achievement_references ORDER BY id LIMIT $1`
+	args := []any{limit}
+	if afterID != "" {
+		SQL = `SELECT id, mongo_achievement_id FROM achievement_references WHERE id > $2 ORDER BY id LIMIT $1`
+		args = append(args, afterID)
+	}
+	rows, err := repo.DB.QueryContext(ctx, SQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []model.ReferenceLink{}
+	for rows.Next() {
+		var link model.ReferenceLink
+		if err := rows.Scan(&link.ID, &link.MongoAchievementID); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// FindExistingMongoIDs returns the subset of mongoIDs that some reference
+// points to.
+func (repo *achievementReferenceRepository) FindExistingMongoIDs(ctx context.Context, mongoIDs []string) ([]string, error) {
+	SQL := `SELECT DISTINCT mongo_achievement_id FROM achievement_references WHERE mongo_achievement_id = ANY($1::text[])`
+	rows, err := repo.DB.QueryContext(ctx, SQL, mongoIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// MarkBroken flags references whose Mongo document is gone. References
+// already flagged keep their original broken_at.
+func (repo *achievementReferenceRepository) MarkBroken(ctx context.Context, ids []string) error {
+	SQL := `UPDATE achievement_references SET broken_at = NOW() WHERE id = ANY($1::text[]::uuid[]) AND broken_at IS NULL`
+	_, err := repo.DB.ExecContext(ctx, SQL, ids)
+	return err
+}
+
+// FindTrash lists deleted references, newest first. An empty studentID lists
+// the trash of every student.
+func (repo *achievementReferenceRepository) FindTrash(ctx context.Context, studentID string, page model.PageRequest) (*model.PageResult[model.AchievementReferenceAdmin], error) {
+	conditions := []string{"a.deleted_at IS NOT NULL"}
+	args := []any{}
+	if studentID != "" {
+		conditions = append(conditions, "s.id = $1")
+		args = append(args, studentID)
+	}
+	return repo.findAdmin(ctx, conditions, args, "a.created_at DESC, a.id DESC", page, true, true)
+}
+
+func (repo *achievementReferenceRepository) Restore(ctx context.Context, id string) error {
+	SQL := "UPDATE achievement_references SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL"
+	res, err := repo.DB.ExecContext(ctx, SQL, id)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return errors.New("no rows affected")
+	}
+	return nil
+}
+
+// FindDeletedBefore returns up to limit references deleted before the given
+// time, oldest deletion first.
+func (repo *achievementReferenceRepository) FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]model.ReferenceLink, error) {
+	SQL := `SELECT id, mongo_achievement_id FROM achievement_references
+			WHERE deleted_at IS NOT NULL AND deleted_at < $1
+			ORDER BY deleted_at, id LIMIT $2`
+	rows, err := repo.DB.QueryContext(ctx, SQL, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []model.ReferenceLink{}
+	for rows.Next() {
+		var link model.ReferenceLink
+		if err := rows.Scan(&link.ID, &link.MongoAchievementID); err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// Purge removes a deleted reference for good, together with its status
+// history and comments.
+func (repo *achievementReferenceRepository) Purge(ctx context.Context, id string) error {
+	SQL := "DELETE FROM achievement_references WHERE id = $1 AND deleted_at IS NOT NULL"
+	res, err := repo.DB.ExecContext(ctx, SQL, id)
+	if err != nil {
+		return err
+	}
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return errors.New("no rows affected")
+	}
+	return nil
 }
@@ -5,12 +5,22 @@ import (
 	"errors"
 	"prisma/app/model"
 	"prisma/utils"
+	"regexp"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrAchievementNotFound is returned by FindById when no document has the id.
+	ErrAchievementNotFound = errors.New("achievement not found")
+	// ErrAttachmentNotFound is returned when changing an attachment the
+	// document does not have (anymore).
+	ErrAttachmentNotFound = errors.New("attachment not found")
 )
 
 type AchievementRepository interface {
@@ -18,6 +28,19 @@ type AchievementRepository interface {
 	Update(ctx context.Context, Achievement model.AchievementMongo) (*model.AchievementMongo, error)
 	FindAll(ctx context.Context, Id []string) ([]model.AchievementMongo, error)
 	FindById(ctx context.Context, id string) (*model.AchievementMongo, error)
+	UpdatePoints(ctx context.Context, id string, points int, version int) error
+	FindIDs(ctx context.Context, filter model.AchievementFilter, limit int) ([]string, error)
+	Replace(ctx context.Context, Achievement model.AchievementMongo) error
+	MarkDeleted(ctx context.Context, id string, deletedAt *time.Time) error
+	Delete(ctx context.Context, id string) error
+	FindIDsAfter(ctx context.Context, afterID string, createdBefore time.Time, limit int) ([]string, error)
+	FindExistingIDs(ctx context.Context, ids []string) ([]string, error)
+	AddAttachments(ctx context.Context, id string, attachments []model.Attachment) error
+	RemoveAttachment(ctx context.Context, id string, attachmentID string) error
+	ReplaceAttachment(ctx context.Context, id string, attachmentID string, attachment model.Attachment) error
+	SetAttachmentScanStatus(ctx context.Context, id string, attachmentID string, status string, previewKey string) error
+	FindTextMatches(ctx context.Context, text string, achievementType string, studentIDs []string, limit int) ([]string, error)
+	FindPendingScans(ctx context.Context) ([]model.ScanJob, error)
 }
 
 type AchievementRepositoryImpl struct {
@@ -42,19 +65,20 @@ func (repo *AchievementRepositoryImpl) Create(ctx context.Context, Achievement m
 	return &Achievement, nil
 }
 
+// Update saves the editable fields of Achievement. Attachments are left out,
+// since the scan worker writes to them concurrently; they change through
+// AddAttachments, RemoveAttachment and ReplaceAttachment.
 func (repo *AchievementRepositoryImpl) Update(ctx context.Context, Achievement model.AchievementMongo) (*model.AchievementMongo, error) {
 	Achievement.UpdatedAt = time.Now()
 
 	updateData := bson.M{
-		"student_id":      Achievement.StudentID,
-		"achievment_type": Achievement.AchievementType,
+		"studentId":       Achievement.StudentID,
+		"achievementType": Achievement.AchievementType,
 		"title":           Achievement.Title,
 		"description":     Achievement.Description,
 		"details":         Achievement.Details,
-		"attachments":     Achievement.Attachments,
 		"tags":            Achievement.Tags,
-		"updated_at":      time.Now(),
-		"points":          Achievement.Points,
+		"updatedAt":       Achievement.UpdatedAt,
 	}
 	update := bson.M{
 		"$set": updateData,
@@ -104,9 +128,304 @@ func (repo *AchievementRepositoryImpl) FindById(ctx context.Context, id string)
 
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("achievement not found")
+			return nil, ErrAchievementNotFound
 		}
 		return nil, err
 	}
 	return achievement, nil
 }
+
+func (repo *AchievementRepositoryImpl) UpdatePoints(ctx context.Context, id string, points int, version int) error {
+	oid, err := utils.ToObjectId(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"points":            points,
+			"pointsRuleVersion": version,
+		},
+	}
+	_, err = repo.collection.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+// Replace overwrites the stored document with Achievement as is, restoring a
+// snapshot taken before an update or a delete.
+func (repo *AchievementRepositoryImpl) Replace(ctx context.Context, Achievement model.AchievementMongo) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := repo.collection.ReplaceOne(ctx, bson.M{"_id": Achievement.ID}, Achievement, opts)
+	return err
+}
+
+// MarkDeleted stamps deletedAt on the document, or clears it when deletedAt
+// is nil.
+func (repo *AchievementRepositoryImpl) MarkDeleted(ctx context.Context, id string, deletedAt *time.Time) error {
+	oid, err := utils.ToObjectId(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{"$unset": bson.M{"deletedAt": ""}}
+	if deletedAt != nil {
+		update = bson.M{"$set": bson.M{"deletedAt": deletedAt}}
+	}
+	_, err = repo.collection.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	return err
+}
+
+func (repo *AchievementRepositoryImpl) Delete(ctx context.Context, id string) error {
+	oid, err := utils.ToObjectId(id)
+	if err != nil {
+		return err
+	}
+	_, err = repo.collection.DeleteOne(ctx, bson.M{"_id": oid})
+	return err
+}
+
+// FindIDs returns the ids of up to limit live documents matching the Mongo
+// side of filter, ordered by the requested Mongo sort field when there is one.
+func (repo *AchievementRepositoryImpl) FindIDs(ctx context.Context, filter model.AchievementFilter, limit int) ([]string, error) {
+	query := bson.M{"deletedAt": bson.M{"$exists": false}}
+	if filter.StudentIDs != nil {
+		query["studentId"] = bson.M{"$in": filter.StudentIDs}
+	}
+	if filter.AchievementType != "" {
+		query["achievementType"] = filter.AchievementType
+	}
+	if filter.CompetitionLevel != "" {
+		query["details.competitionLevel"] = filter.CompetitionLevel
+	}
+	if filter.Tag != "" {
+		query["tags"] = filter.Tag
+	}
+	if filter.Search != "" {
+		query["title"] = primitive.Regex{Pattern: regexp.QuoteMeta(filter.Search), Options: "i"}
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetLimit(int64(limit))
+	if filter.SortsInMongo() {
+		field := "title"
+		if filter.Sort == "achievement_type" {
+			field = "achievementType"
+		}
+		direction := 1
+		if filter.Descending() {
+			direction = -1
+		}
+		opts.SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}})
+	} else {
+		// Newest first, so a cut-off list keeps the most recent documents
+		opts.SetSort(bson.D{{Key: "_id", Value: -1}})
+	}
+	return repo.findIDs(ctx, query, opts)
+}
+
+// FindTextMatches runs a full-text search over titles, descriptions,
+// competition names, organizers and tags, and returns the ids of up to limit
+// documents, best match first. A non-nil studentIDs keeps only the documents
+// of those students.
+func (repo *AchievementRepositoryImpl) FindTextMatches(ctx context.Context, text string, achievementType string, studentIDs []string, limit int) ([]string, error) {
+	query := bson.M{
+		"$text":     bson.M{"$search": text},
+		"deletedAt": bson.M{"$exists": false},
+	}
+	if studentIDs != nil {
+		query["studentId"] = bson.M{"$in": studentIDs}
+	}
+	if achievementType != "" {
+		query["achievementType"] = achievementType
+	}
+
+	score := bson.M{"$meta": "textScore"}
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1, "score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return repo.findIDs(ctx, query, opts)
+}
+
+// findIDs runs an _id-only query and returns the ids as hex strings.
+func (repo *AchievementRepositoryImpl) findIDs(ctx context.Context, query bson.M, opts *options.FindOptions) ([]string, error) {
+	res, err := repo.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close(ctx)
+
+	ids := []string{}
+	for res.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := res.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID.Hex())
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FindIDsAfter returns up to limit document ids in ascending order, starting
+// after afterID and skipping documents created at or after createdBefore.
+func (repo *AchievementRepositoryImpl) FindIDsAfter(ctx context.Context, afterID string, createdBefore time.Time, limit int) ([]string, error) {
+	query := bson.M{"createdAt": bson.M{"$lt": createdBefore}}
+	if afterID != "" {
+		oid, err := utils.ToObjectId(afterID)
+		if err != nil {
+			return nil, err
+		}
+		query["_id"] = bson.M{"$gt": oid}
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"_id": 1}).
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	return repo.findIDs(ctx, query, opts)
+}
+
+// FindExistingIDs returns the subset of ids that still have a document.
+func (repo *AchievementRepositoryImpl) FindExistingIDs(ctx context.Context, ids []string) ([]string, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		// A reference holding a malformed id can never resolve.
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	if len(oids) == 0 {
+		return []string{}, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+	return repo.findIDs(ctx, bson.M{"_id": bson.M{"$in": oids}}, opts)
+}
+
+// AddAttachments appends attachments to the document. Documents saved by
+// older versions may hold null instead of an array, which $push refuses.
+func (repo *AchievementRepositoryImpl) AddAttachments(ctx context.Context, id string, attachments []model.Attachment) error {
+	oid, err := utils.ToObjectId(id)
+	if err != nil {
+		return err
+	}
+
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{
+		"attachments": bson.M{"$concatArrays": bson.A{
+			bson.M{"$ifNull": bson.A{"$attachments", bson.A{}}},
+			bson.M{"$literal": attachments},
+		}},
+		"updatedAt": time.Now(),
+	}}}}
+	res, err := repo.collection.UpdateOne(ctx, bson.M{"_id": oid}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrAchievementNotFound
+	}
+	return nil
+}
+
+// RemoveAttachment takes the attachment with attachmentID out of the
+// document.
+func (repo *AchievementRepositoryImpl) RemoveAttachment(ctx context.Context, id string, attachmentID string) error {
+	oid, err := utils.ToObjectId(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$pull": bson.M{"attachments": bson.M{"id": attachmentID}},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	}
+	res, err := repo.collection.UpdateOne(ctx, bson.M{"_id": oid, "attachments.id": attachmentID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
+
+// ReplaceAttachment puts attachment in the place of the attachment with
+// attachmentID.
+func (repo *AchievementRepositoryImpl) ReplaceAttachment(ctx context.Context, id string, attachmentID string, attachment model.Attachment) error {
+	oid, err := utils.ToObjectId(id)
+	if err != nil {
+		return err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"attachments.$": attachment,
+			"updatedAt":     time.Now(),
+		},
+	}
+	res, err := repo.collection.UpdateOne(ctx, bson.M{"_id": oid, "attachments.id": attachmentID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrAttachmentNotFound
+	}
+	return nil
+}
+
+// SetAttachmentScanStatus records the scan result of one attachment, and its
+// preview key when one was rendered, without touching the rest of the
+// document.
+func (repo *AchievementRepositoryImpl) SetAttachmentScanStatus(ctx context.Context, id string, attachmentID string, status string, previewKey string) error {
+	oid, err := utils.ToObjectId(id)
+	if err != nil {
+		return err
+	}
+
+	opts := options.Update().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"a.id": attachmentID}},
+	})
+	set := bson.M{"attachments.$[a].scanStatus": status}
+	if previewKey != "" {
+		set["attachments.$[a].previewKey"] = previewKey
+	}
+	_, err = repo.collection.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": set}, opts)
+	return err
+}
+
+// FindPendingScans returns a job for every attachment still waiting for its
+// malware scan.
+func (repo *AchievementRepositoryImpl) FindPendingScans(ctx context.Context) ([]model.ScanJob, error) {
+	opts := options.Find().SetProjection(bson.M{"attachments": 1})
+	res, err := repo.collection.Find(ctx, bson.M{"attachments.scanStatus": model.ScanPending}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close(ctx)
+
+	jobs := []model.ScanJob{}
+	for res.Next(ctx) {
+		var doc model.AchievementMongo
+		if err := res.Decode(&doc); err != nil {
+			return nil, err
+		}
+		for _, attachment := range doc.Attachments {
+			if attachment.ScanStatus == model.ScanPending {
+				jobs = append(jobs, model.ScanJob{
+					MongoAchievementID: doc.ID.Hex(),
+					AttachmentID:       attachment.ID,
+					StorageKey:         attachment.StorageKey,
+					ContentType:        attachment.FileType,
+				})
+			}
+		}
+	}
+	if err := res.Err(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
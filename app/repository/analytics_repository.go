@@ -12,6 +12,7 @@ import (
 type AnalyticsRepository interface {
 	Statistics(ctx context.Context) ([]model.Statistics, error)
 	Reporting(ctx context.Context, id string) ([]*model.Statistics, error)
+	Points(ctx context.Context, id string) ([]model.PeriodPoints, error)
 }
 
 type AnalyticsRepositoryImpl struct {
@@ -28,6 +29,10 @@ func NewAnalyticsRepository(Log *logrus.Logger, db *mongo.Database) *AnalyticsRe
 
 func (repo *AnalyticsRepositoryImpl) Statistics(ctx context.Context) ([]model.Statistics, error) {
 	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "deletedAt", Value: bson.D{{Key: "$exists", Value: false}}},
+		}}},
+
 		{{Key: "$group", Value: bson.D{
 			{Key: "_id", Value: bson.D{{Key: "$year", Value: "$createdAt"}}},
 			{Key: "international", Value: bson.D{
@@ -66,6 +71,7 @@ func (repo *AnalyticsRepositoryImpl) Statistics(ctx context.Context) ([]model.St
 					}},
 				}},
 			}},
+			{Key: "points", Value: bson.D{{Key: "$sum", Value: "$points"}}},
 		}}},
 
 		{{Key: "$project", Value: bson.D{
@@ -77,6 +83,7 @@ func (repo *AnalyticsRepositoryImpl) Statistics(ctx context.Context) ([]model.St
 				{Key: "regional", Value: "$regional"},
 				{Key: "local", Value: "$local"},
 			}},
+			{Key: "points", Value: "$points"},
 		}}},
 
 		{{Key: "$sort", Value: bson.D{{Key: "tahun", Value: -1}}}},
@@ -100,6 +107,7 @@ func (repo *AnalyticsRepositoryImpl) Reporting(ctx context.Context, id string) (
 	pipeline := mongo.Pipeline{
 		{{Key: "$match", Value: bson.D{
 			{Key: "studentId", Value: id},
+			{Key: "deletedAt", Value: bson.D{{Key: "$exists", Value: false}}},
 		}}},
 
 		{{Key: "$group", Value: bson.D{
@@ -136,6 +144,7 @@ func (repo *AnalyticsRepositoryImpl) Reporting(ctx context.Context, id string) (
 					}},
 				}},
 			}},
+			{Key: "points", Value: bson.D{{Key: "$sum", Value: "$points"}}},
 		}}},
 
 		{{Key: "$project", Value: bson.D{
@@ -147,6 +156,7 @@ func (repo *AnalyticsRepositoryImpl) Reporting(ctx context.Context, id string) (
 				{Key: "regional", Value: "$regional"},
 				{Key: "local", Value: "$local"},
 			}},
+			{Key: "points", Value: "$points"},
 		}}},
 
 		{{Key: "$sort", Value: bson.D{{Key: "tahun", Value: -1}}}},
@@ -169,3 +179,38 @@ func (repo *AnalyticsRepositoryImpl) Reporting(ctx context.Context, id string) (
 
 	return stats, nil
 }
+
+func (repo *AnalyticsRepositoryImpl) Points(ctx context.Context, id string) ([]model.PeriodPoints, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{
+			{Key: "studentId", Value: id},
+			{Key: "deletedAt", Value: bson.D{{Key: "$exists", Value: false}}},
+		}}},
+
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "$year", Value: "$createdAt"}}},
+			{Key: "points", Value: bson.D{{Key: "$sum", Value: "$points"}}},
+		}}},
+
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "tahun", Value: bson.D{{Key: "$toString", Value: "$_id"}}},
+			{Key: "points", Value: "$points"},
+		}}},
+
+		{{Key: "$sort", Value: bson.D{{Key: "tahun", Value: -1}}}},
+	}
+
+	cursor, err := repo.DB.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	periods := []model.PeriodPoints{}
+	if err := cursor.All(ctx, &periods); err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
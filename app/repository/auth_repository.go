@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"prisma/app/model"
 	"prisma/utils"
@@ -12,9 +14,58 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+var (
+	// ErrRefreshTokenRevoked is returned for a refresh token whose family was
+	// logged out, revoked or has expired.
+	ErrRefreshTokenRevoked = errors.New("refresh token revoked")
+	// ErrRefreshTokenReused is returned when a refresh token that was already
+	// rotated is presented again. The whole family is revoked when it happens.
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+	// ErrTokenVersionStale is returned for a token issued before the role,
+	// permissions, activity state or password of its user changed.
+	ErrTokenVersionStale = errors.New("token issued before the user's access changed")
+	// ErrResetTokenInvalid is returned for a password reset token that is
+	// unknown, expired or already used.
+	ErrResetTokenInvalid = errors.New("reset token is invalid or expired")
+)
+
+// rotateScript swaps the current token ID of a family from ARGV[1] to
+// ARGV[2]. It returns 1 on success, 0 when the family no longer exists and
+// -1 when ARGV[1] is not the current token, in which case the family is
+// deleted.
+var rotateScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return 0
+end
+if current ~= ARGV[1] then
+	redis.call('DEL', KEYS[1])
+	return -1
+end
+redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+return 1
+`)
+
 type AuthRepository interface {
-	Logout(ctx context.Context, RefreshToken string) error
-	RefreshToken(ctx context.Context, RefreshToken string, jwtKey []byte) (string, error)
+	// Logout ends the family of a refresh token signed with jwtKey. Tokens
+	// that do not verify are ignored, as they cannot be refreshed anyway.
+	Logout(ctx context.Context, RefreshToken string, jwtKey []byte) error
+	// TrackRefreshToken starts the family of a refresh token issued at login.
+	TrackRefreshToken(ctx context.Context, RefreshToken string) error
+	// RefreshToken returns a new access token and a new refresh token of the
+	// same family, invalidating RefreshToken.
+	RefreshToken(ctx context.Context, RefreshToken string, jwtKey []byte) (string, string, error)
+	// TokenVersion returns the current token version of a user, 0 when it was
+	// never bumped.
+	TokenVersion(ctx context.Context, UserID string) (int64, error)
+	// BumpTokenVersion makes every token issued to the user so far stale.
+	BumpTokenVersion(ctx context.Context, UserID string) error
+	// SavePasswordReset stores a reset token for the user, replacing the one
+	// issued before it.
+	SavePasswordReset(ctx context.Context, Token string, UserID string, ttl time.Duration) error
+	// ConsumePasswordReset returns the user of a reset token and deletes it,
+	// so each token works once.
+	ConsumePasswordReset(ctx context.Context, Token string) (string, error)
 }
 
 type AuthRepositoryImplements struct {
@@ -29,37 +80,99 @@ func NewLogoutRepository(DB *redis.Client, Log *logrus.Logger) AuthRepository {
 	}
 }
 
-func (l *AuthRepositoryImplements) Logout(ctx context.Context, RefreshToken string) error {
-	exp, err := utils.ExtractExpiration(RefreshToken)
+func familyKey(family string) string {
+	return "refresh_family:" + family
+}
+
+func tokenVersionKey(UserID string) string {
+	return "token_version:" + UserID
+}
+
+// passwordResetKey stores reset tokens by hash, so a leaked Redis dump does
+// not hand out working tokens.
+func passwordResetKey(Token string) string {
+	sum := sha256.Sum256([]byte(Token))
+	return "password_reset:" + hex.EncodeToString(sum[:])
+}
+
+func userPasswordResetKey(UserID string) string {
+	return "password_reset_user:" + UserID
+}
+
+func (l *AuthRepositoryImplements) Logout(ctx context.Context, RefreshToken string, jwtKey []byte) error {
+	// Verified first, so a forged token cannot end another user's family
+	claims, err := utils.ValidateToken(RefreshToken, jwtKey)
 	if err != nil {
-		return err
+		return nil
 	}
+	// Tokens without a family predate rotation and can no longer refresh
+	if claims.Family == "" {
+		return nil
+	}
+	return l.DB.Del(ctx, familyKey(claims.Family)).Err()
+}
 
-	ttl := time.Until(exp)
+func (l *AuthRepositoryImplements) TrackRefreshToken(ctx context.Context, RefreshToken string) error {
+	token, _, err := new(jwt.Parser).ParseUnverified(RefreshToken, &model.Claims{})
+	if err != nil {
+		return err
+	}
+	claims, ok := token.Claims.(*model.Claims)
+	if !ok || claims.Family == "" || claims.ID == "" {
+		return errors.New("refresh token has no family")
+	}
 
-	return l.DB.Set(ctx, "blacklist:"+RefreshToken, "1", ttl).Err()
+	return l.DB.Set(ctx, familyKey(claims.Family), claims.ID, utils.RefreshTokenTTL).Err()
 }
 
-func (l *AuthRepositoryImplements) RefreshToken(ctx context.Context, refreshToken string, jwtKey []byte) (string, error) {
+func (l *AuthRepositoryImplements) RefreshToken(ctx context.Context, refreshToken string, jwtKey []byte) (string, string, error) {
 
 	claims, err := utils.ValidateToken(refreshToken, jwtKey)
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	// Tokens without a family predate rotation or are access tokens.
+	if claims.Family == "" || claims.ID == "" {
+		return "", "", ErrRefreshTokenRevoked
 	}
 
-	isBlacklisted, _ := l.DB.Get(ctx, "blacklist:"+refreshToken).Result()
-	if isBlacklisted != "" {
-		return "", errors.New("refresh token banned")
+	version, err := l.TokenVersion(ctx, claims.UserID)
+	if err != nil {
+		return "", "", err
+	}
+	// Permissions are copied forward, so they must still be current
+	if claims.TokenVersion != version {
+		return "", "", ErrTokenVersionStale
 	}
 
-	accessExp := time.Now().Add(15 * time.Minute)
+	refreshString, nextID, err := utils.GenerateRefreshToken(*claims, claims.Family, jwtKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	rotated, err := rotateScript.Run(ctx, l.DB, []string{familyKey(claims.Family)},
+		claims.ID, nextID, utils.RefreshTokenTTL.Milliseconds()).Int()
+	if err != nil {
+		return "", "", err
+	}
+	switch rotated {
+	case 0:
+		return "", "", ErrRefreshTokenRevoked
+	case -1:
+		l.Log.Warnf("refresh token reuse detected for user %s, family %s revoked", claims.UserID, claims.Family)
+		return "", "", ErrRefreshTokenReused
+	}
+
+	accessExp := time.Now().Add(utils.AccessTokenTTL)
 
 	newClaims := model.Claims{
-		UserID:      claims.UserID,
-		Username:    claims.Username,
-		FullName:    claims.FullName,
-		Role:        claims.Role,
-		Permissions: claims.Permissions,
+		UserID:       claims.UserID,
+		Username:     claims.Username,
+		FullName:     claims.FullName,
+		Role:         claims.Role,
+		Permissions:  claims.Permissions,
+		SessionID:    claims.Family,
+		TokenVersion: claims.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(accessExp),
 		},
@@ -68,8 +181,52 @@ func (l *AuthRepositoryImplements) RefreshToken(ctx context.Context, refreshToke
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, newClaims)
 	accessString, err := token.SignedString(jwtKey)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	return accessString, nil
+	return accessString, refreshString, nil
+}
+
+func (l *AuthRepositoryImplements) TokenVersion(ctx context.Context, UserID string) (int64, error) {
+	version, err := l.DB.Get(ctx, tokenVersionKey(UserID)).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+func (l *AuthRepositoryImplements) BumpTokenVersion(ctx context.Context, UserID string) error {
+	return l.DB.Incr(ctx, tokenVersionKey(UserID)).Err()
+}
+
+func (l *AuthRepositoryImplements) SavePasswordReset(ctx context.Context, Token string, UserID string, ttl time.Duration) error {
+	previous, err := l.DB.Get(ctx, userPasswordResetKey(UserID)).Result()
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	key := passwordResetKey(Token)
+	_, err = l.DB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if previous != "" {
+			pipe.Del(ctx, previous)
+		}
+		pipe.Set(ctx, key, UserID, ttl)
+		pipe.Set(ctx, userPasswordResetKey(UserID), key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (l *AuthRepositoryImplements) ConsumePasswordReset(ctx context.Context, Token string) (string, error) {
+	UserID, err := l.DB.GetDel(ctx, passwordResetKey(Token)).Result()
+	if err == redis.Nil {
+		return "", ErrResetTokenInvalid
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := l.DB.Del(ctx, userPasswordResetKey(UserID)).Err(); err != nil {
+		l.Log.Warnf("failed to clear password reset of user %s: %v", UserID, err)
+	}
+	return UserID, nil
 }
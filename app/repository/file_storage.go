@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ErrFileNotFound is returned by FileStorage.Open when nothing is stored
+// under the key.
+var ErrFileNotFound = errors.New("file not found")
+
+// FileStorage keeps uploaded files under slash-separated keys such as
+// "achievements/1700000000_sertifikat.pdf". Delete of a missing key is not an
+// error.
+type FileStorage interface {
+	Save(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (*StoredFile, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// StoredFile is an open file; the caller closes it.
+type StoredFile struct {
+	io.ReadCloser
+	Size int64
+}
+
+// CleanStorageKey normalizes key and rejects keys that would escape the
+// storage root.
+func CleanStorageKey(key string) (string, error) {
+	cleaned := strings.TrimPrefix(path.Clean("/"+key), "/")
+	if cleaned == "" || cleaned != strings.TrimPrefix(key, "/") {
+		return "", errors.New("invalid storage key")
+	}
+	return cleaned, nil
+}
+
+type LocalFileStorage struct {
+	root string
+}
+
+func NewLocalFileStorage(root string) FileStorage {
+	return &LocalFileStorage{root: root}
+}
+
+func (s *LocalFileStorage) path(key string) (string, error) {
+	cleaned, err := CleanStorageKey(key)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}
+
+// Save writes to a temporary file first so readers never see a partial file.
+func (s *LocalFileStorage) Save(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *LocalFileStorage) Open(ctx context.Context, key string) (*StoredFile, error) {
+	target, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(target)
+	if os.IsNotExist(err) {
+		return nil, ErrFileNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &StoredFile{ReadCloser: file, Size: info.Size()}, nil
+}
+
+func (s *LocalFileStorage) Delete(ctx context.Context, key string) error {
+	target, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
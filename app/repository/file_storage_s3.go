@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3FileStorage stores files in a bucket of any S3-compatible service,
+// including a local MinIO.
+type S3FileStorage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3FileStorage(endpoint string, accessKey string, secretKey string, bucket string, region string, useSSL bool) (FileStorage, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+		Region: region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &S3FileStorage{client: client, bucket: bucket}, nil
+}
+
+func (s *S3FileStorage) Save(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	cleaned, err := CleanStorageKey(key)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, cleaned, content, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3FileStorage) Open(ctx context.Context, key string) (*StoredFile, error) {
+	cleaned, err := CleanStorageKey(key)
+	if err != nil {
+		return nil, err
+	}
+	object, err := s.client.GetObject(ctx, s.bucket, cleaned, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject is lazy; Stat is the first request that reaches the bucket.
+	info, err := object.Stat()
+	if err != nil {
+		object.Close()
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrFileNotFound
+		}
+		return nil, err
+	}
+	return &StoredFile{ReadCloser: object, Size: info.Size}, nil
+}
+
+// Delete succeeds for missing keys, as S3 DeleteObject does.
+func (s *S3FileStorage) Delete(ctx context.Context, key string) error {
+	cleaned, err := CleanStorageKey(key)
+	if err != nil {
+		return err
+	}
+	return s.client.RemoveObject(ctx, s.bucket, cleaned, minio.RemoveObjectOptions{})
+}
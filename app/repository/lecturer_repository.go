@@ -3,14 +3,16 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"prisma/app/model"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
 type LecturerRepository interface {
 	Save(ctx context.Context, tx *sql.Tx, Lecturer *model.Lecturer) (*model.Lecturer, error)
-	FindAll(ctx context.Context) ([]model.UserProfile, error)
+	FindAll(ctx context.Context, page model.PageRequest) (*model.PageResult[model.UserProfile], error)
 	FindById(ctx context.Context, id string) (*model.UserProfile, error)
 	DeleteById(ctx context.Context, tx *sql.Tx, id string) error
 	FindAllAdvices(ctx context.Context, id string) ([]model.UserProfile, error)
@@ -74,31 +76,49 @@ func (repo *LecturerRepositoryImpl) Save(ctx context.Context, tx *sql.Tx, Lectur
 	return Lecturer, nil
 }
 
-func (repo *LecturerRepositoryImpl) FindAll(ctx context.Context) ([]model.UserProfile, error) {
-	SQL := `SELECT u.username,u.email,u.full_name,l.id,l.department 
-			FROM lecturers l
+func (repo *LecturerRepositoryImpl) FindAll(ctx context.Context, page model.PageRequest) (*model.PageResult[model.UserProfile], error) {
+	from := `FROM lecturers l
 			JOIN users u ON l.user_id = u.id`
+	total, err := countRows(ctx, repo.DB, from, nil, nil)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := repo.DB.QueryContext(ctx, SQL)
+	conditions, args, limit, err := pageSQL(page, "l.created_at", "l.id", true, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	SQL := fmt.Sprintf(`SELECT u.username,u.email,u.full_name,l.id,l.department,l.created_at
+			%s
+			%s
+			ORDER BY l.created_at DESC, l.id DESC
+			%s`, from, whereSQL(conditions), limit)
+
+	rows, err := repo.DB.QueryContext(ctx, SQL, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	lecturers := []model.UserProfile{}
+	cursors := []model.PageCursor{}
 	for rows.Next() {
 		var user model.UserProfile
-		rows.Scan(&user.User.Username, &user.User.Email, &user.User.FullName,
-			&user.LecturerID, &user.Department)
+		var createdAt time.Time
+		err := rows.Scan(&user.User.Username, &user.User.Email, &user.User.FullName,
+			&user.LecturerID, &user.Department, &createdAt)
+		if err != nil {
+			return nil, err
+		}
 		lecturers = append(lecturers, user)
+		cursors = append(cursors, model.PageCursor{CreatedAt: createdAt, ID: user.LecturerID.String})
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
 
-	return lecturers, nil
+	result := &model.PageResult[model.UserProfile]{TotalItems: total}
+	result.Items, result.NextCursor = nextPage(lecturers, cursors, page.Limit)
+	return result, nil
 }
 
 func (repo *LecturerRepositoryImpl) FindById(ctx context.Context, id string) (*model.UserProfile, error) {
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MailSender delivers plain text mail.
+type MailSender interface {
+	Send(ctx context.Context, to string, subject string, body string) error
+}
+
+// SMTPMailSender sends through an SMTP relay, authenticating with PLAIN auth
+// when a username is set.
+type SMTPMailSender struct {
+	address string
+	auth    smtp.Auth
+	from    string
+}
+
+func NewSMTPMailSender(host string, port int, username string, password string, from string) MailSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailSender{
+		address: net.JoinHostPort(host, strconv.Itoa(port)),
+		auth:    auth,
+		from:    from,
+	}
+}
+
+func (m *SMTPMailSender) Send(ctx context.Context, to string, subject string, body string) error {
+	// Header values must not be able to inject headers of their own
+	if strings.ContainsAny(to, "\r\n") || strings.ContainsAny(subject, "\r\n") {
+		return errors.New("mail header contains a line break")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
+		m.from, to, subject, strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(m.address, m.auth, m.from, []string{to}, []byte(message))
+}
+
+// LogMailSender writes mail to the log instead of sending it, for development.
+// Reset links end up in the log, so never use it in production.
+type LogMailSender struct {
+	Log *logrus.Logger
+}
+
+func NewLogMailSender(Log *logrus.Logger) MailSender {
+	return &LogMailSender{Log: Log}
+}
+
+func (m *LogMailSender) Send(ctx context.Context, to string, subject string, body string) error {
+	m.Log.Infof("mail to %s: %s\n%s", to, subject, body)
+	return nil
+}
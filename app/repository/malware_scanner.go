@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamChunkSize is the largest INSTREAM chunk sent to clamd; its default
+// StreamMaxLength still caps the whole stream.
+const clamChunkSize = 32 << 10
+
+// ScanResult is the verdict of a malware scan. Signature names what was found
+// when Infected is set.
+type ScanResult struct {
+	Infected  bool
+	Signature string
+}
+
+// MalwareScanner inspects file content for malware.
+type MalwareScanner interface {
+	Scan(ctx context.Context, content io.Reader) (*ScanResult, error)
+}
+
+// ClamAVScanner streams content to a clamd daemon over its INSTREAM command.
+// network and address are passed to net.Dial, e.g. "unix" and
+// "/run/clamav/clamd.ctl" or "tcp" and "localhost:3310".
+type ClamAVScanner struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+func NewClamAVScanner(network string, address string, timeout time.Duration) MalwareScanner {
+	return &ClamAVScanner{network: network, address: address, timeout: timeout}
+}
+
+func (s *ClamAVScanner) Scan(ctx context.Context, content io.Reader) (*ScanResult, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, s.network, s.address)
+	if err != nil {
+		return nil, fmt.Errorf("connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(s.timeout))
+	}
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, err
+	}
+	chunk := make([]byte, clamChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := content.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return nil, err
+			}
+			if _, err := conn.Write(chunk[:n]); err != nil {
+				return nil, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	// A zero length chunk ends the stream
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := conn.Write(size); err != nil {
+		return nil, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read clamd reply: %w", err)
+	}
+	return parseClamReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamReply reads replies such as "stream: OK" and
+// "stream: Eicar-Test-Signature FOUND".
+func parseClamReply(reply string) (*ScanResult, error) {
+	verdict := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case verdict == "OK":
+		return &ScanResult{}, nil
+	case strings.HasSuffix(verdict, " FOUND"):
+		return &ScanResult{Infected: true, Signature: strings.TrimSuffix(verdict, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("clamd: %s", reply)
+	}
+}
+
+// NoopMalwareScanner reports every file clean. It is meant for development
+// setups without a clamd.
+type NoopMalwareScanner struct{}
+
+func NewNoopMalwareScanner() MalwareScanner {
+	return &NoopMalwareScanner{}
+}
+
+func (s *NoopMalwareScanner) Scan(ctx context.Context, content io.Reader) (*ScanResult, error) {
+	return &ScanResult{}, nil
+}
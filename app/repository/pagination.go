@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"prisma/app/model"
+	"strings"
+)
+
+// pageSQL appends the keyset condition of page to conditions and returns the
+// LIMIT/OFFSET clause. Rows must be ordered by (createdAt, id) in the given
+// direction. One row more than the page size is fetched so nextPage can tell
+// whether the list goes on.
+func pageSQL(page model.PageRequest, createdAt string, id string, descending bool, conditions []string, args []any) ([]string, []any, string, error) {
+	if page.Cursor != "" {
+		cursor, err := model.DecodePageCursor(page.Cursor)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		op := ">"
+		if descending {
+			op = "<"
+		}
+		args = append(args, cursor.CreatedAt, cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, %s) %s ($%d, $%d)", createdAt, id, op, len(args)-1, len(args)))
+		args = append(args, page.Limit+1)
+		return conditions, args, fmt.Sprintf("LIMIT $%d", len(args)), nil
+	}
+
+	args = append(args, page.Limit+1, page.Offset())
+	return conditions, args, fmt.Sprintf("LIMIT $%d OFFSET $%d", len(args)-1, len(args)), nil
+}
+
+// countRows counts the rows of from matching conditions, for total_items.
+func countRows(ctx context.Context, db *sql.DB, from string, conditions []string, args []any) (int, error) {
+	SQL := "SELECT COUNT(*) " + from
+	if len(conditions) > 0 {
+		SQL += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	var total int
+	if err := db.QueryRowContext(ctx, SQL, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// whereSQL joins conditions into a WHERE clause, or nothing when empty.
+func whereSQL(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
+
+// nextPage drops the extra row fetched by pageSQL and returns the cursor of
+// the last row kept, or an empty cursor on the last page.
+func nextPage[T any](items []T, cursors []model.PageCursor, limit int) ([]T, string) {
+	if len(items) <= limit {
+		return items, ""
+	}
+	return items[:limit], cursors[limit-1].Encode()
+}
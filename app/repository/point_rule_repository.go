@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"prisma/app/model"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// PointRuleRepository stores the point rule table. Rule sets are never
+// updated in place: every change is saved as a new version so past scores
+// can be recalculated against the rules that produced them.
+type PointRuleRepository interface {
+	Create(ctx context.Context, ruleSet model.PointRuleSet) (*model.PointRuleSet, error)
+	FindLatest(ctx context.Context) (*model.PointRuleSet, error)
+	FindByVersion(ctx context.Context, version int) (*model.PointRuleSet, error)
+	FindAll(ctx context.Context) ([]model.PointRuleSet, error)
+}
+
+type pointRuleRepository struct {
+	Log *logrus.Logger
+	DB  *sql.DB
+}
+
+func NewPointRuleRepository(log *logrus.Logger, db *sql.DB) PointRuleRepository {
+	return &pointRuleRepository{
+		Log: log,
+		DB:  db,
+	}
+}
+
+func (repo *pointRuleRepository) Create(ctx context.Context, ruleSet model.PointRuleSet) (*model.PointRuleSet, error) {
+	rules, err := json.Marshal(ruleSet.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("marshal rules: %w", err)
+	}
+
+	ruleSet.CreatedAt = time.Now()
+	SQL := `INSERT INTO point_rule_sets(version, rules, created_by, created_at)
+			SELECT COALESCE(MAX(version), 0) + 1, $1, $2, $3 FROM point_rule_sets
+			RETURNING id, version`
+	err = repo.DB.QueryRowContext(ctx, SQL, string(rules), ruleSet.CreatedBy, ruleSet.CreatedAt).
+		Scan(&ruleSet.ID, &ruleSet.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &ruleSet, nil
+}
+
+func (repo *pointRuleRepository) FindLatest(ctx context.Context) (*model.PointRuleSet, error) {
+	SQL := `SELECT id, version, rules, created_by, created_at FROM point_rule_sets
+			ORDER BY version DESC LIMIT 1`
+	return repo.scan(repo.DB.QueryRowContext(ctx, SQL))
+}
+
+func (repo *pointRuleRepository) FindByVersion(ctx context.Context, version int) (*model.PointRuleSet, error) {
+	SQL := `SELECT id, version, rules, created_by, created_at FROM point_rule_sets
+			WHERE version = $1`
+	return repo.scan(repo.DB.QueryRowContext(ctx, SQL, version))
+}
+
+func (repo *pointRuleRepository) FindAll(ctx context.Context) ([]model.PointRuleSet, error) {
+	SQL := `SELECT id, version, rules, created_by, created_at FROM point_rule_sets
+			ORDER BY version DESC`
+	rows, err := repo.DB.QueryContext(ctx, SQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ruleSets := []model.PointRuleSet{}
+	for rows.Next() {
+		ruleSet, err := repo.scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		ruleSets = append(ruleSets, *ruleSet)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ruleSets, nil
+}
+
+func (repo *pointRuleRepository) scan(row interface{ Scan(dest ...any) error }) (*model.PointRuleSet, error) {
+	ruleSet := model.PointRuleSet{}
+	var rules string
+	if err := row.Scan(&ruleSet.ID, &ruleSet.Version, &rules, &ruleSet.CreatedBy, &ruleSet.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(rules), &ruleSet.Rules); err != nil {
+		return nil, fmt.Errorf("unmarshal rules: %w", err)
+	}
+	return &ruleSet, nil
+}
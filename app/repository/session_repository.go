@@ -0,0 +1,194 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"prisma/app/model"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// touchScript records a use of session KEYS[1] at ARGV[1] (unix ms) when it
+// still exists. A positive ARGV[2] also pushes the expiry of the session and
+// of the user's session set KEYS[2] that many milliseconds ahead.
+var touchScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[1], 'last_used_at', ARGV[1])
+local ttl = tonumber(ARGV[2])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+	redis.call('PEXPIRE', KEYS[2], ttl)
+end
+return 1
+`)
+
+// SessionRepository keeps login sessions in Redis: a hash per session and a
+// set of session IDs per user. Sessions expire with their refresh token
+// family.
+type SessionRepository interface {
+	Create(ctx context.Context, session model.Session, ttl time.Duration) error
+	FindByUser(ctx context.Context, UserID string) ([]model.Session, error)
+	// Touch reports whether the session is still active and records its use.
+	// A non-zero ttl also extends its expiry.
+	Touch(ctx context.Context, UserID string, SessionID string, ttl time.Duration) (bool, error)
+	// Revoke ends a session of UserID and reports whether it existed.
+	Revoke(ctx context.Context, UserID string, SessionID string) (bool, error)
+	// RevokeAll ends every session of UserID and returns how many there were.
+	RevokeAll(ctx context.Context, UserID string) (int, error)
+}
+
+type SessionRepositoryImpl struct {
+	DB  *redis.Client
+	Log *logrus.Logger
+}
+
+func NewSessionRepository(DB *redis.Client, Log *logrus.Logger) SessionRepository {
+	return &SessionRepositoryImpl{
+		DB:  DB,
+		Log: Log,
+	}
+}
+
+func sessionKey(SessionID string) string {
+	return "session:" + SessionID
+}
+
+func userSessionsKey(UserID string) string {
+	return "user_sessions:" + UserID
+}
+
+func (r *SessionRepositoryImpl) Create(ctx context.Context, session model.Session, ttl time.Duration) error {
+	_, err := r.DB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, sessionKey(session.ID), map[string]interface{}{
+			"user_id":      session.UserID,
+			"device":       session.Device,
+			"ip":           session.IP,
+			"user_agent":   session.UserAgent,
+			"created_at":   session.CreatedAt.UnixMilli(),
+			"last_used_at": session.LastUsedAt.UnixMilli(),
+		})
+		pipe.PExpire(ctx, sessionKey(session.ID), ttl)
+		pipe.SAdd(ctx, userSessionsKey(session.UserID), session.ID)
+		pipe.PExpire(ctx, userSessionsKey(session.UserID), ttl)
+		return nil
+	})
+	return err
+}
+
+func (r *SessionRepositoryImpl) FindByUser(ctx context.Context, UserID string) ([]model.Session, error) {
+	IDs, err := r.DB.SMembers(ctx, userSessionsKey(UserID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	pipe := r.DB.Pipeline()
+	fields := make([]*redis.MapStringStringCmd, len(IDs))
+	for i, ID := range IDs {
+		fields[i] = pipe.HGetAll(ctx, sessionKey(ID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && len(IDs) > 0 {
+		return nil, err
+	}
+
+	sessions := make([]model.Session, 0, len(IDs))
+	var expired []interface{}
+	for i, cmd := range fields {
+		values := cmd.Val()
+		if len(values) == 0 {
+			expired = append(expired, IDs[i])
+			continue
+		}
+		sessions = append(sessions, model.Session{
+			ID:         IDs[i],
+			UserID:     values["user_id"],
+			Device:     values["device"],
+			IP:         values["ip"],
+			UserAgent:  values["user_agent"],
+			CreatedAt:  parseUnixMilli(values["created_at"]),
+			LastUsedAt: parseUnixMilli(values["last_used_at"]),
+		})
+	}
+	// Expired sessions leave their ID behind in the set
+	if len(expired) > 0 {
+		if err := r.DB.SRem(ctx, userSessionsKey(UserID), expired...).Err(); err != nil {
+			r.Log.Warnf("failed to prune expired sessions of user %s: %v", UserID, err)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+	})
+	return sessions, nil
+}
+
+func (r *SessionRepositoryImpl) Touch(ctx context.Context, UserID string, SessionID string, ttl time.Duration) (bool, error) {
+	active, err := touchScript.Run(ctx, r.DB, []string{sessionKey(SessionID), userSessionsKey(UserID)},
+		time.Now().UnixMilli(), ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return active == 1, nil
+}
+
+func (r *SessionRepositoryImpl) Revoke(ctx context.Context, UserID string, SessionID string) (bool, error) {
+	owner, err := r.DB.HGet(ctx, sessionKey(SessionID), "user_id").Result()
+	if err == redis.Nil || (err == nil && owner != UserID) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = r.DB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKey(SessionID), familyKey(SessionID))
+		pipe.SRem(ctx, userSessionsKey(UserID), SessionID)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *SessionRepositoryImpl) RevokeAll(ctx context.Context, UserID string) (int, error) {
+	IDs, err := r.DB.SMembers(ctx, userSessionsKey(UserID)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(IDs) == 0 {
+		return 0, nil
+	}
+
+	sessionKeys := make([]string, len(IDs))
+	familyKeys := make([]string, len(IDs))
+	for i, ID := range IDs {
+		sessionKeys[i] = sessionKey(ID)
+		familyKeys[i] = familyKey(ID)
+	}
+
+	var revoked *redis.IntCmd
+	_, err = r.DB.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		revoked = pipe.Del(ctx, sessionKeys...)
+		pipe.Del(ctx, familyKeys...)
+		pipe.Del(ctx, userSessionsKey(UserID))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(revoked.Val()), nil
+}
+
+func parseUnixMilli(value string) time.Time {
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
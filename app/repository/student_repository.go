@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"prisma/app/model"
 
 	"github.com/sirupsen/logrus"
@@ -10,9 +11,11 @@ import (
 
 type StudentRepository interface {
 	Save(ctx context.Context, tx *sql.Tx, Student *model.Student) (*model.Student, error)
-	FindAll(ctx context.Context) ([]model.UserProfile, error)
+	FindAll(ctx context.Context, page model.PageRequest) (*model.PageResult[model.UserProfile], error)
 	FindById(ctx context.Context, id string) (*model.UserProfile, error)
 	FindByUserId(ctx context.Context, userid string) (*model.Student, error)
+	FindIDsByAdvisor(ctx context.Context, userid string) ([]string, error)
+	FindOwner(ctx context.Context, id string) (*model.ResourceOwner, error)
 	DeleteById(ctx context.Context, tx *sql.Tx, id string) error
 	UpdateById(ctx context.Context, Student *model.Student) (*model.Student, error)
 }
@@ -64,28 +67,49 @@ func (repo *StudentRepositoryImpl) Save(ctx context.Context, tx *sql.Tx, Student
 	return Student, nil
 }
 
-func (repo *StudentRepositoryImpl) FindAll(ctx context.Context) ([]model.UserProfile, error) {
-	SQL := `SELECT u.username,u.email,u.full_name,s.id,s.student_id,s.program_study,s.academic_year,s.advisor_id
-	FROM students s
+func (repo *StudentRepositoryImpl) FindAll(ctx context.Context, page model.PageRequest) (*model.PageResult[model.UserProfile], error) {
+	from := `FROM students s
 	JOIN users u ON u.id = s.user_id`
-	rows, err := repo.DB.QueryContext(ctx, SQL)
+	total, err := countRows(ctx, repo.DB, from, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions, args, limit, err := pageSQL(page, "s.created_at", "s.id", true, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	SQL := fmt.Sprintf(`SELECT u.username,u.email,u.full_name,s.id,s.student_id,s.program_study,s.academic_year,s.advisor_id,s.created_at
+	%s
+	%s
+	ORDER BY s.created_at DESC, s.id DESC
+	%s`, from, whereSQL(conditions), limit)
+	rows, err := repo.DB.QueryContext(ctx, SQL, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var users []model.UserProfile
+	users := []model.UserProfile{}
+	cursors := []model.PageCursor{}
 	for rows.Next() {
 		var Student model.UserProfile
-		rows.Scan(
+		err := rows.Scan(
 			&Student.User.Username, &Student.User.Email, &Student.User.FullName,
 			&Student.User.ID, &Student.StudentID, &Student.ProgramStudy,
-			&Student.AcademicYear, &Student.AdvisorID)
+			&Student.AcademicYear, &Student.AdvisorID, &Student.StudentCreatedAt)
+		if err != nil {
+			return nil, err
+		}
 		users = append(users, Student)
+		cursors = append(cursors, model.PageCursor{CreatedAt: Student.StudentCreatedAt.Time, ID: Student.User.ID})
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return users, nil
+
+	result := &model.PageResult[model.UserProfile]{TotalItems: total}
+	result.Items, result.NextCursor = nextPage(users, cursors, page.Limit)
+	return result, nil
 }
 
 func (repo *StudentRepositoryImpl) FindByUserId(ctx context.Context, id string) (*model.Student, error) {
@@ -98,6 +122,47 @@ func (repo *StudentRepositoryImpl) FindByUserId(ctx context.Context, id string)
 	return &Student, nil
 }
 
+// FindIDsByAdvisor returns the ids of the students advised by the lecturer
+// with the given user id.
+func (repo *StudentRepositoryImpl) FindIDsByAdvisor(ctx context.Context, userid string) ([]string, error) {
+	SQL := `SELECT s.id FROM students s
+			JOIN lecturers l ON l.id = s.advisor_id
+			WHERE l.user_id = $1`
+	rows, err := repo.DB.QueryContext(ctx, SQL, userid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FindOwner returns the user account of a student and of their advisor, for
+// use by the access policy.
+func (repo *StudentRepositoryImpl) FindOwner(ctx context.Context, id string) (*model.ResourceOwner, error) {
+	SQL := `SELECT s.id, s.user_id, l.user_id FROM students s
+			LEFT JOIN lecturers l ON l.id = s.advisor_id
+			WHERE s.id = $1`
+	owner := model.ResourceOwner{}
+	var advisorUserID sql.NullString
+	if err := repo.DB.QueryRowContext(ctx, SQL, id).Scan(&owner.StudentID, &owner.UserID, &advisorUserID); err != nil {
+		return nil, err
+	}
+	owner.AdvisorUserID = advisorUserID.String
+	return &owner, nil
+}
+
 func (repo *StudentRepositoryImpl) FindById(ctx context.Context, id string) (*model.UserProfile, error) {
 	Student := model.UserProfile{}
 	SQL := `SELECT u.username,u.email,u.full_name,s.id,s.student_id,s.program_study,s.academic_year,s.advisor_id
@@ -7,18 +7,28 @@ import (
 	"errors"
 	"fmt"
 	"prisma/app/model"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
 
+// ErrUserNotFound is returned when no user has the given id or name.
+var ErrUserNotFound = errors.New("user not found")
+
 type UserRepository interface {
 	Save(ctx context.Context, tx *sql.Tx, User *model.User) (*model.User, error)
 	Update(ctx context.Context, User model.User) (*model.User, error)
 	UpdateRole(ctx context.Context, tx *sql.Tx, User model.User) (*model.User, error)
+	// Delete removes the user row for good; deactivate with SetActive instead
+	// unless the account must be erased.
 	Delete(ctx context.Context, UserId string) error
+	SetActive(ctx context.Context, UserId string, active bool) error
 	FindById(ctx context.Context, UserId string) (*model.UserProfile, error)
-	FindAll(ctx context.Context) (*[]model.User, error)
+	FindAll(ctx context.Context, filter model.UserFilter) (*model.PageResult[model.User], error)
 	FindByUsername(ctx context.Context, Username string) (*model.User, error)
+	FindByEmail(ctx context.Context, Email string) (*model.User, error)
+	FindPasswordHash(ctx context.Context, UserId string) (string, error)
+	UpdatePassword(ctx context.Context, UserId string, PasswordHash string) error
 }
 
 type UserRepositoryImpl struct {
@@ -41,7 +51,7 @@ func (repo *UserRepositoryImpl) UpdateRole(ctx context.Context, tx *sql.Tx, User
 	}
 
 	if rows == 0 {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	} else {
 		return &User, nil
 	}
@@ -85,7 +95,7 @@ func (repo *UserRepositoryImpl) Update(ctx context.Context, User model.User) (*m
 	}
 
 	if rows == 0 {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	} else {
 		return &User, nil
 	}
@@ -101,8 +111,25 @@ func (repo *UserRepositoryImpl) Delete(ctx context.Context, UserId string) error
 	return nil
 }
 
+func (repo *UserRepositoryImpl) SetActive(ctx context.Context, UserId string, active bool) error {
+	SQL := "UPDATE users SET is_active = $1, updated_at = NOW() WHERE id = $2;"
+	res, err := repo.DB.ExecContext(ctx, SQL, active, UserId)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 func (repo *UserRepositoryImpl) FindById(ctx context.Context, UserId string) (*model.UserProfile, error) {
 	SQL := `SELECT u.id,u.email,u.username,u.full_name,u.role_id,r.name as role_name,
+			COALESCE(u.is_active, TRUE),
        		s.id as student_id,
        		s.program_study,
 			s.academic_year,
@@ -124,6 +151,7 @@ func (repo *UserRepositoryImpl) FindById(ctx context.Context, UserId string) (*m
 		&user.User.FullName,
 		&user.User.RoleId,
 		&user.User.RoleName,
+		&user.User.IsActive,
 		&user.StudentID,
 		&user.ProgramStudy,
 		&user.AcademicYear,
@@ -135,7 +163,7 @@ func (repo *UserRepositoryImpl) FindById(ctx context.Context, UserId string) (*m
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
@@ -143,37 +171,68 @@ func (repo *UserRepositoryImpl) FindById(ctx context.Context, UserId string) (*m
 	return &user, nil
 }
 
-func (repo *UserRepositoryImpl) FindAll(ctx context.Context) (*[]model.User, error) {
-	SQL := `SELECT u.id,u.email,u.username,u.full_name,r.name
-			FROM users u 
+func (repo *UserRepositoryImpl) FindAll(ctx context.Context, filter model.UserFilter) (*model.PageResult[model.User], error) {
+	from := `FROM users u
     		INNER JOIN roles r ON u.role_id = r.id`
-	rows, err := repo.DB.QueryContext(ctx, SQL)
+	var conditions []string
+	var args []any
+	switch filter.Status {
+	case model.UserStatusActive:
+		conditions = append(conditions, "COALESCE(u.is_active, TRUE)")
+	case model.UserStatusInactive:
+		conditions = append(conditions, "NOT COALESCE(u.is_active, TRUE)")
+	}
+	total, err := countRows(ctx, repo.DB, from, conditions, args)
 	if err != nil {
-		repo.Log.Fatalf("Error finding all users from database: %v", err)
+		return nil, err
+	}
+
+	conditions, args, limit, err := pageSQL(filter.PageRequest, "u.created_at", "u.id", true, conditions, args)
+	if err != nil {
+		return nil, err
+	}
+	SQL := fmt.Sprintf(`SELECT u.id,u.email,u.username,u.full_name,r.name,COALESCE(u.is_active, TRUE),u.created_at
+			%s
+			%s
+			ORDER BY u.created_at DESC, u.id DESC
+			%s`, from, whereSQL(conditions), limit)
+	rows, err := repo.DB.QueryContext(ctx, SQL, args...)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
-	var users []model.User
+
+	users := []model.User{}
+	cursors := []model.PageCursor{}
 	for rows.Next() {
 		var user model.User
+		var createdAt time.Time
 		err := rows.Scan(
 			&user.ID,
 			&user.Email,
 			&user.Username,
 			&user.FullName,
-			&user.RoleName)
+			&user.RoleName,
+			&user.IsActive,
+			&createdAt)
 		if err != nil {
-			repo.Log.Fatalf("Error finding all users from database: %v", err)
 			return nil, err
 		}
 
 		users = append(users, user)
+		cursors = append(cursors, model.PageCursor{CreatedAt: createdAt, ID: user.ID})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return &users, nil
+	result := &model.PageResult[model.User]{TotalItems: total}
+	result.Items, result.NextCursor = nextPage(users, cursors, filter.Limit)
+	return result, nil
 }
 
 func (repo *UserRepositoryImpl) FindByUsername(ctx context.Context, Username string) (*model.User, error) {
-	SQL := `SELECT u.id,u.username,u.full_name,u.password_hash,r.name,
+	SQL := `SELECT u.id,u.username,u.full_name,u.password_hash,r.name,COALESCE(u.is_active, TRUE),
 			COALESCE(
         			TO_JSON(JSON_AGG(p.resource || ':' || p.action)),
        			 '[]'
@@ -183,7 +242,7 @@ func (repo *UserRepositoryImpl) FindByUsername(ctx context.Context, Username str
 			LEFT JOIN role_permissions rp ON u.role_id = rp.role_id
 			LEFT JOIN permissions p ON rp.permission_id = p.id
 			WHERE u.username = $1 
-			GROUP BY u.id,u.username,u.full_name,u.password_hash,r.name;`
+			GROUP BY u.id,u.username,u.full_name,u.password_hash,r.name,u.is_active;`
 
 	var user model.User
 	var permStr string
@@ -193,6 +252,7 @@ func (repo *UserRepositoryImpl) FindByUsername(ctx context.Context, Username str
 		&user.FullName,
 		&user.PasswordHash,
 		&user.RoleName,
+		&user.IsActive,
 		&permStr,
 	)
 	if err != nil {
@@ -204,3 +264,52 @@ func (repo *UserRepositoryImpl) FindByUsername(ctx context.Context, Username str
 	}
 	return &user, nil
 }
+
+func (repo *UserRepositoryImpl) FindByEmail(ctx context.Context, Email string) (*model.User, error) {
+	SQL := `SELECT id,username,email,full_name,COALESCE(is_active, TRUE) FROM users WHERE LOWER(email) = LOWER($1);`
+
+	var user model.User
+	err := repo.DB.QueryRowContext(ctx, SQL, Email).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.FullName,
+		&user.IsActive,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (repo *UserRepositoryImpl) FindPasswordHash(ctx context.Context, UserId string) (string, error) {
+	SQL := "SELECT password_hash FROM users WHERE id = $1;"
+
+	var hash string
+	if err := repo.DB.QueryRowContext(ctx, SQL, UserId).Scan(&hash); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", ErrUserNotFound
+		}
+		return "", err
+	}
+	return hash, nil
+}
+
+func (repo *UserRepositoryImpl) UpdatePassword(ctx context.Context, UserId string, PasswordHash string) error {
+	SQL := "UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2;"
+	res, err := repo.DB.ExecContext(ctx, SQL, PasswordHash, UserId)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
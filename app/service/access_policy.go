@@ -0,0 +1,41 @@
+package service
+
+import (
+	"errors"
+	"prisma/app/model"
+)
+
+var ErrForbidden = errors.New("you are not allowed to access this resource")
+
+// AccessPolicy decides whether the caller may act on a resource owned by a
+// student: students only on their own, lecturers only on their advisees',
+// admins on everything.
+type AccessPolicy interface {
+	Authorize(claims *model.Claims, owner model.ResourceOwner) error
+}
+
+type AccessPolicyImpl struct{}
+
+func NewAccessPolicy() AccessPolicy {
+	return &AccessPolicyImpl{}
+}
+
+func (p *AccessPolicyImpl) Authorize(claims *model.Claims, owner model.ResourceOwner) error {
+	if claims == nil {
+		return ErrForbidden
+	}
+
+	switch claims.Role {
+	case model.RoleAdmin:
+		return nil
+	case model.RoleStudent:
+		if owner.UserID != "" && owner.UserID == claims.UserID {
+			return nil
+		}
+	case model.RoleLecturer:
+		if owner.AdvisorUserID != "" && owner.AdvisorUserID == claims.UserID {
+			return nil
+		}
+	}
+	return ErrForbidden
+}
@@ -0,0 +1,349 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"prisma/app/model"
+	"prisma/app/repository"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrAttachmentNotFound = repository.ErrAttachmentNotFound
+	ErrAttachmentPending  = errors.New("attachment is still being scanned for malware")
+	ErrAttachmentInfected = errors.New("attachment was flagged as malware")
+)
+
+// legacyUploadURL prefixed the storage key in FileURL while uploads were
+// served as static files.
+const legacyUploadURL = "/uploads"
+
+// sharedAttachmentURL is where signed attachment links are served without
+// login.
+const sharedAttachmentURL = "/api/v1/shared/attachments"
+
+// previewSuffix turns the storage key of a file into the key of its preview.
+const previewSuffix = ".preview.jpg"
+
+// defaultLinkMinutes is how long a signed attachment link lasts when the
+// request does not say.
+const defaultLinkMinutes = 15
+
+// attachmentURL is the authenticated download path of an attachment.
+func attachmentURL(achievementID string, attachmentID string) string {
+	return fmt.Sprintf("/api/v1/achievements/%s/attachments/%s", achievementID, url.PathEscape(attachmentID))
+}
+
+// saveUploads stores validated uploads and returns their attachments. When
+// one file fails the ones already stored are removed again.
+func (s *AchievementServiceImpl) saveUploads(ctx context.Context, achievementID string, uploads []CheckedUpload) ([]model.Attachment, error) {
+	var attachments []model.Attachment
+
+	for _, upload := range uploads {
+		id := primitive.NewObjectID().Hex()
+		key := fmt.Sprintf("achievements/%s_%s", id, upload.FileName)
+		checksum, err := s.saveUpload(ctx, key, upload)
+		if err != nil {
+			if cleanupErr := removeAttachments(ctx, s.storage, attachments); cleanupErr != nil {
+				s.Log.Warnf("remove uploads after failed upload: %v", cleanupErr)
+			}
+			return nil, err
+		}
+
+		attachments = append(attachments, model.Attachment{
+			ID:         id,
+			FileName:   upload.FileName,
+			FileURL:    attachmentURL(achievementID, id),
+			StorageKey: key,
+			FileType:   upload.ContentType,
+			Size:       upload.File.Size,
+			Checksum:   checksum,
+			ScanStatus: model.ScanPending,
+			UploadedAt: time.Now(),
+		})
+	}
+	return attachments, nil
+}
+
+// saveUpload stores upload under key and returns the hex SHA-256 of what was
+// written.
+func (s *AchievementServiceImpl) saveUpload(ctx context.Context, key string, upload CheckedUpload) (string, error) {
+	content, err := upload.File.Open()
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	if err := s.storage.Save(ctx, key, io.TeeReader(content, hash), upload.File.Size, upload.ContentType); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// attachmentUsage is the size taken by the attachments of an achievement and
+// of its comments, which share one quota.
+func (s *AchievementServiceImpl) attachmentUsage(ctx context.Context, achievementID string, doc *model.AchievementMongo) (int64, error) {
+	comments, err := s.repoComment.FindByAchievementID(ctx, achievementID)
+	if err != nil {
+		return 0, err
+	}
+
+	var used int64
+	for _, attachment := range doc.Attachments {
+		used += attachment.Size
+	}
+	for _, comment := range comments {
+		for _, attachment := range comment.Attachments {
+			used += attachment.Size
+		}
+	}
+	return used, nil
+}
+
+// attachmentKey returns the storage key of attachment. Attachments uploaded
+// before keys were recorded are found through their URL.
+func attachmentKey(attachment model.Attachment) string {
+	if attachment.StorageKey != "" {
+		return attachment.StorageKey
+	}
+	return strings.TrimPrefix(attachment.FileURL, legacyUploadURL+"/")
+}
+
+// attachmentID returns the id of attachment. Attachments uploaded before ids
+// were recorded are known by their file name in storage.
+func attachmentID(attachment model.Attachment) string {
+	if attachment.ID != "" {
+		return attachment.ID
+	}
+	return path.Base(attachmentKey(attachment))
+}
+
+// indexAttachment returns the position of the attachment with id, or -1.
+func indexAttachment(attachments []model.Attachment, id string) int {
+	return slices.IndexFunc(attachments, func(attachment model.Attachment) bool {
+		return attachmentID(attachment) == id
+	})
+}
+
+// linkAttachments points the FileURL of each attachment at the download
+// endpoint. Only call it on data about to be returned, never before saving.
+func linkAttachments(achievementID string, attachments []model.Attachment) {
+	for i := range attachments {
+		attachments[i].ID = attachmentID(attachments[i])
+		attachments[i].FileURL = attachmentURL(achievementID, attachments[i].ID)
+		if attachments[i].PreviewKey != "" {
+			attachments[i].PreviewURL = attachments[i].FileURL + "/preview"
+		}
+	}
+}
+
+// scanJobs builds the malware scan jobs of freshly stored attachments.
+func scanJobs(mongoAchievementID string, commentID string, attachments []model.Attachment) []model.ScanJob {
+	jobs := make([]model.ScanJob, 0, len(attachments))
+	for _, attachment := range attachments {
+		jobs = append(jobs, model.ScanJob{
+			MongoAchievementID: mongoAchievementID,
+			CommentID:          commentID,
+			AttachmentID:       attachment.ID,
+			StorageKey:         attachment.StorageKey,
+			ContentType:        attachment.FileType,
+		})
+	}
+	return jobs
+}
+
+// checkScanned holds back attachments that are not known to be clean.
+// Attachments stored before scanning existed have no status until the
+// migration marks them pending, and are held back as well.
+func checkScanned(attachment model.Attachment) error {
+	switch attachment.ScanStatus {
+	case model.ScanClean:
+		return nil
+	case model.ScanInfected:
+		return ErrAttachmentInfected
+	}
+	return ErrAttachmentPending
+}
+
+// linkDetail applies linkAttachments to everything an achievement response
+// carries.
+func linkDetail(achievement *model.AchievementReferenceDetail) {
+	if achievement.Detail != nil {
+		linkAttachments(achievement.ID, achievement.Detail.Attachments)
+	}
+	for i := range achievement.Comments {
+		linkAttachments(achievement.ID, achievement.Comments[i].Attachments)
+	}
+}
+
+// removeAttachments deletes the stored files of attachments and their
+// previews.
+func removeAttachments(ctx context.Context, storage repository.FileStorage, attachments []model.Attachment) error {
+	var errs []error
+	for _, attachment := range attachments {
+		if err := storage.Delete(ctx, attachmentKey(attachment)); err != nil {
+			errs = append(errs, err)
+		}
+		if attachment.PreviewKey == "" {
+			continue
+		}
+		if err := storage.Delete(ctx, attachment.PreviewKey); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// findAttachment looks id up among the achievement's own attachments and
+// those of its comments.
+func (s *AchievementServiceImpl) findAttachment(ctx context.Context, achievement *model.AchievementReferenceDetail, id string) (*model.Attachment, error) {
+	doc, err := s.repoAchievement.FindById(ctx, achievement.MongoAchievementID)
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range doc.Attachments {
+		if attachmentID(attachment) == id {
+			return &attachment, nil
+		}
+	}
+
+	comments, err := s.repoComment.FindByAchievementID(ctx, achievement.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, comment := range comments {
+		for _, attachment := range comment.Attachments {
+			if attachmentID(attachment) == id {
+				return &attachment, nil
+			}
+		}
+	}
+	return nil, ErrAttachmentNotFound
+}
+
+// serveAttachment sends an attachment, or its preview, to a caller allowed to
+// see the achievement.
+func (s *AchievementServiceImpl) serveAttachment(c *fiber.Ctx, preview bool) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+	val := ctx.Value("user")
+
+	AttachmentID, err := url.PathUnescape(c.Params("attachmentId"))
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s not found", id),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	Attachment, err := s.findAttachment(ctx, Achievement, AttachmentID)
+	if errors.Is(err, ErrAttachmentNotFound) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	return s.sendAttachment(c, Attachment, preview)
+}
+
+// sendAttachment streams the stored file of attachment as a download, or its
+// preview inline, once it has passed the malware scan.
+func (s *AchievementServiceImpl) sendAttachment(c *fiber.Ctx, attachment *model.Attachment, preview bool) error {
+	if err := checkScanned(*attachment); err != nil {
+		status := fiber.StatusConflict
+		if errors.Is(err, ErrAttachmentInfected) {
+			status = fiber.StatusForbidden
+		}
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(status).JSON(response)
+	}
+
+	key := attachmentKey(*attachment)
+	if preview {
+		if attachment.PreviewKey == "" {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: ErrNoPreview.Error(),
+			}
+			return c.Status(fiber.StatusNotFound).JSON(response)
+		}
+		key = attachment.PreviewKey
+	}
+
+	file, err := s.storage.Open(c.UserContext(), key)
+	if errors.Is(err, repository.ErrFileNotFound) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if preview {
+		c.Set(fiber.HeaderContentType, "image/jpeg")
+		return c.SendStream(file, int(file.Size))
+	}
+
+	c.Attachment(attachment.FileName)
+	if attachment.FileType != "" {
+		c.Set(fiber.HeaderContentType, attachment.FileType)
+	}
+	return c.SendStream(file, int(file.Size))
+}
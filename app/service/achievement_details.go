@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+	"prisma/app/model"
+	"strings"
+)
+
+// DetailsError reports the type-specific detail fields a request is missing,
+// mirroring the student_achievements schema in db/migrations_mongo.
+type DetailsError struct {
+	AchievementType string
+	Missing         []string
+	Reason          string
+}
+
+func (e *DetailsError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("invalid details for %s achievement: %s", e.AchievementType, e.Reason)
+	}
+	return fmt.Sprintf("%s achievement requires details: %s", e.AchievementType, strings.Join(e.Missing, ", "))
+}
+
+// validateDetails enforces the required detail fields of each achievement
+// type. Academic and other achievements have no required details.
+func validateDetails(achievementType string, details model.AchievementDetails) error {
+	missing := []string{}
+	require := func(present bool, field string) {
+		if !present {
+			missing = append(missing, "details."+field)
+		}
+	}
+
+	switch achievementType {
+	case model.AchievementTypeCompetition:
+		require(details.CompetitionName != "", "competition_name")
+		require(details.CompetitionLevel != "", "competition_level")
+	case model.AchievementTypePublication:
+		require(details.PublicationType != "", "publication_type")
+		require(details.PublicationTitle != "", "publication_title")
+		require(len(details.Authors) > 0, "authors")
+	case model.AchievementTypeOrganization:
+		require(details.OrganizationName != "", "organization_name")
+		require(details.Position != "", "position")
+		require(details.Period != nil && !details.Period.Start.IsZero(), "period.start")
+	case model.AchievementTypeCertification:
+		require(details.CertificationName != "", "certification_name")
+		require(details.IssuedBy != "", "issued_by")
+	}
+
+	if len(missing) > 0 {
+		return &DetailsError{AchievementType: achievementType, Missing: missing}
+	}
+
+	if details.Period != nil && !details.Period.End.IsZero() && details.Period.End.Before(details.Period.Start) {
+		return &DetailsError{AchievementType: achievementType, Reason: "details.period.end is before details.period.start"}
+	}
+	return nil
+}
@@ -1,50 +1,198 @@
 package service
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-	"os"
+	"mime/multipart"
+	"net/url"
 	"prisma/app/model"
 	"prisma/app/repository"
+	"slices"
 	"sort"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// searchCandidateLimit caps how many of the best text matches within the
+// caller's scope are paged; weaker matches beyond it are not returned and the
+// response is marked truncated.
+const searchCandidateLimit = 1000
+
+// listCandidateLimit caps how many Mongo matches of a list filter are paged in
+// Postgres; the response is marked truncated when more matched.
+const listCandidateLimit = 1000
+
 type AchievementService interface {
 	Create(c *fiber.Ctx) error
 	Update(c *fiber.Ctx) error
 	Delete(c *fiber.Ctx) error
 	FindByID(c *fiber.Ctx) error
 	FindAll(c *fiber.Ctx) error
+	Search(c *fiber.Ctx) error
 	Verify(c *fiber.Ctx) error
 	Submit(c *fiber.Ctx) error
+	Reopen(c *fiber.Ctx) error
 	History(c *fiber.Ctx) error
 	Attachment(c *fiber.Ctx) error
 	Reject(c *fiber.Ctx) error
+	RequestRevision(c *fiber.Ctx) error
+	Comments(c *fiber.Ctx) error
+	CreateComment(c *fiber.Ctx) error
+	BulkVerify(c *fiber.Ctx) error
+	BulkReject(c *fiber.Ctx) error
+	Trash(c *fiber.Ctx) error
+	Restore(c *fiber.Ctx) error
+	DownloadAttachment(c *fiber.Ctx) error
+	PreviewAttachment(c *fiber.Ctx) error
+	DeleteAttachment(c *fiber.Ctx) error
+	ReplaceAttachment(c *fiber.Ctx) error
+	CreateAttachmentLink(c *fiber.Ctx) error
+	SharedAttachment(c *fiber.Ctx) error
 }
 
 type AchievementServiceImpl struct {
 	repoAchievement         repository.AchievementRepository
 	repoStudent             repository.StudentRepository
 	repoAchivementReference repository.AchievementReferenceRepository
+	repoHistory             repository.AchievementHistoryRepository
+	repoComment             repository.AchievementCommentRepository
+	workflow                AchievementWorkflow
+	policy                  AccessPolicy
+	points                  PointsEngine
+	storage                 repository.FileStorage
+	uploads                 AttachmentValidator
+	scans                   AttachmentScanner
+	signer                  URLSigner
+	DB                      *sql.DB
 	validate                *validator.Validate
 	Log                     *logrus.Logger
 }
 
-func NewAchievementService(repo repository.AchievementRepository, repoStudent repository.StudentRepository, repoAchievementReference repository.AchievementReferenceRepository, validate *validator.Validate, Log *logrus.Logger) *AchievementServiceImpl {
+func NewAchievementService(repo repository.AchievementRepository, repoStudent repository.StudentRepository, repoAchievementReference repository.AchievementReferenceRepository, repoHistory repository.AchievementHistoryRepository, repoComment repository.AchievementCommentRepository, policy AccessPolicy, points PointsEngine, storage repository.FileStorage, uploads AttachmentValidator, scans AttachmentScanner, signer URLSigner, DB *sql.DB, validate *validator.Validate, Log *logrus.Logger) *AchievementServiceImpl {
 	return &AchievementServiceImpl{
 		repoAchievement:         repo,
 		validate:                validate,
 		repoStudent:             repoStudent,
 		repoAchivementReference: repoAchievementReference,
+		repoHistory:             repoHistory,
+		repoComment:             repoComment,
+		workflow:                NewAchievementWorkflow(),
+		policy:                  policy,
+		points:                  points,
+		storage:                 storage,
+		uploads:                 uploads,
+		scans:                   scans,
+		signer:                  signer,
+		DB:                      DB,
 		Log:                     Log,
 	}
 }
 
+// transition moves an achievement to next.Status and records the change in
+// the status history within the same transaction.
+func (s *AchievementServiceImpl) transition(ctx context.Context, current *model.AchievementReferenceDetail, next model.AchievementReference, actorID string, note string) (*model.AchievementReference, error) {
+	if err := s.workflow.Transition(current.Status, next.Status); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updated, err := s.applyTransition(ctx, tx, current, next, actorID, note)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// awardPoints scores a freshly verified achievement. The verification is
+// already committed at this point, so a failure is only logged; the score can
+// be restored with a recalculation.
+func (s *AchievementServiceImpl) awardPoints(ctx context.Context, mongoAchievementID string) {
+	if err := s.points.Award(ctx, mongoAchievementID); err != nil {
+		s.Log.Errorf("award points to achievement %s: %v", mongoAchievementID, err)
+	}
+}
+
+// createReference inserts the Postgres reference of a new achievement and its
+// first history entry in one transaction.
+func (s *AchievementServiceImpl) createReference(ctx context.Context, ref model.AchievementReference, actorID string) (*model.AchievementReference, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := s.repoAchivementReference.Create(ctx, tx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.repoHistory.Save(ctx, tx, model.AchievementHistory{
+		AchievementID: created.ID,
+		ToStatus:      created.Status,
+		ActorID:       &actorID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// touchReference bumps updated_at of the reference after its Mongo document
+// changed, so Postgres-side listings see the edit.
+func (s *AchievementServiceImpl) touchReference(ctx context.Context, id string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.repoAchivementReference.Update(ctx, tx, model.AchievementReference{ID: id}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// applyTransition writes an already validated status change and its history
+// entry using the caller's transaction.
+func (s *AchievementServiceImpl) applyTransition(ctx context.Context, tx *sql.Tx, current *model.AchievementReferenceDetail, next model.AchievementReference, actorID string, note string) (*model.AchievementReference, error) {
+	updated, err := s.repoAchivementReference.UpdateStatus(ctx, tx, next, current.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	history := model.AchievementHistory{
+		AchievementID: current.ID,
+		FromStatus:    &current.Status,
+		ToStatus:      next.Status,
+		ActorID:       &actorID,
+	}
+	if note != "" {
+		history.Note = &note
+	}
+	if _, err := s.repoHistory.Save(ctx, tx, history); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
 // Create godoc
 // @Summary      Create a new achievement
 // @Description  Create a new achievement draft for a student.
@@ -68,6 +216,10 @@ func (s *AchievementServiceImpl) Create(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "data": err.Error()})
 	}
 
+	if err := validateDetails(request.AchievementType, request.Details); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "data": err.Error()})
+	}
+
 	ctx := c.UserContext()
 	val := ctx.Value("user")
 
@@ -89,22 +241,33 @@ func (s *AchievementServiceImpl) Create(c *fiber.Ctx) error {
 		Tags:            request.Tags,
 	}
 
-	createdMongo, err := s.repoAchievement.Create(ctx, *achievement)
-	if err != nil {
-		response := model.WebResponse[string]{
-			Status: "error",
-			Errors: err.Error(),
-		}
-		return c.Status(fiber.StatusInternalServerError).JSON(response)
-	}
-
-	ref := model.AchievementReference{
-		StudentID:          Student.ID,
-		MongoAchievementID: createdMongo.ID.Hex(),
-		Status:             "draft",
-	}
-
-	createdRef, err := s.repoAchivementReference.Create(ctx, ref)
+	var createdMongo *model.AchievementMongo
+	var createdRef *model.AchievementReference
+	err = NewSaga(s.Log).
+		Step(SagaStep{
+			Name: "create mongo achievement",
+			Action: func(ctx context.Context) error {
+				var err error
+				createdMongo, err = s.repoAchievement.Create(ctx, *achievement)
+				return err
+			},
+			Compensate: func(ctx context.Context) error {
+				return s.repoAchievement.Delete(ctx, createdMongo.ID.Hex())
+			},
+		}).
+		Step(SagaStep{
+			Name: "create achievement reference",
+			Action: func(ctx context.Context) error {
+				var err error
+				createdRef, err = s.createReference(ctx, model.AchievementReference{
+					StudentID:          Student.ID,
+					MongoAchievementID: createdMongo.ID.Hex(),
+					Status:             model.AchievementStatusDraft,
+				}, val.(*model.Claims).UserID)
+				return err
+			},
+		}).
+		Run(ctx)
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
@@ -123,7 +286,7 @@ func (s *AchievementServiceImpl) Create(c *fiber.Ctx) error {
 
 // Update godoc
 // @Summary      Update an achievement
-// @Description  Update achievement details. Students can only update if status is 'draft'.
+// @Description  Update achievement details. Students can only update if status is 'draft' or 'needs_revision'.
 // @Tags         Achievement
 // @Accept       json
 // @Produce      json
@@ -131,6 +294,8 @@ func (s *AchievementServiceImpl) Create(c *fiber.Ctx) error {
 // @Param        request body model.UpdateAchievementRequest true "Update Request"
 // @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
 // @Failure      400  {object}  model.WebResponse[string]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Security     BearerAuth
 // @Router       /achievements/{id} [put]
@@ -145,6 +310,23 @@ func (s *AchievementServiceImpl) Update(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(response)
 	}
 	request.ID = Id
+
+	if err := s.validate.Struct(request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	if err := validateDetails(request.AchievementType, request.Details); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
 	ctx := c.UserContext()
 	val := ctx.Value("user")
 	Achievement, err := s.repoAchivementReference.FindByID(ctx, request.ID)
@@ -156,33 +338,60 @@ func (s *AchievementServiceImpl) Update(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 
-	if val.(*model.Claims).Role == "mahasiswa" && Achievement.Status != "draft" {
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
-			Errors: fmt.Sprintf("Achievement %s is already submitted", request.ID),
+			Errors: err.Error(),
 		}
-		return c.Status(fiber.StatusBadRequest).JSON(response)
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	if val.(*model.Claims).Role == model.RoleStudent && !s.workflow.CanEdit(Achievement.Status) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s cannot be edited while %s", request.ID, Achievement.Status),
+		}
+		return c.Status(fiber.StatusConflict).JSON(response)
 	}
 
-	id, err := primitive.ObjectIDFromHex(Achievement.ID)
+	achievementObj, err := s.repoAchievement.FindById(ctx, Achievement.MongoAchievementID)
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
 			Errors: err.Error(),
 		}
-		return c.Status(fiber.StatusBadRequest).JSON(response)
-	}
-	achievementObj := &model.AchievementMongo{
-		ID:              id,
-		StudentID:       val.(*model.Claims).UserID,
-		AchievementType: request.AchievementType,
-		Title:           request.Title,
-		Description:     request.Description,
-		Details:         request.Details,
-		Tags:            request.Tags,
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
-
-	achievementObj, err = s.repoAchievement.Update(ctx, *achievementObj)
+	snapshot := *achievementObj
+	achievementObj.StudentID = Achievement.Owner.StudentID
+	achievementObj.AchievementType = request.AchievementType
+	achievementObj.Title = request.Title
+	achievementObj.Description = request.Description
+	achievementObj.Details = request.Details
+	achievementObj.Tags = request.Tags
+
+	err = NewSaga(s.Log).
+		Step(SagaStep{
+			Name: "update mongo achievement",
+			Action: func(ctx context.Context) error {
+				var err error
+				achievementObj, err = s.repoAchievement.Update(ctx, *achievementObj)
+				return err
+			},
+			// Update leaves the attachments alone, so restoring through it
+			// cannot undo a scan verdict recorded in the meantime
+			Compensate: func(ctx context.Context) error {
+				_, err := s.repoAchievement.Update(ctx, snapshot)
+				return err
+			},
+		}).
+		Step(SagaStep{
+			Name: "touch achievement reference",
+			Action: func(ctx context.Context) error {
+				return s.touchReference(ctx, Achievement.ID)
+			},
+		}).
+		Run(ctx)
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
@@ -191,6 +400,7 @@ func (s *AchievementServiceImpl) Update(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 	Achievement.Detail = achievementObj
+	linkDetail(Achievement)
 
 	response := model.WebResponse[model.AchievementReferenceDetail]{
 		Status: "success",
@@ -202,21 +412,65 @@ func (s *AchievementServiceImpl) Update(c *fiber.Ctx) error {
 
 // Delete godoc
 // @Summary      Delete an achievement
-// @Description  Delete achievement by ID.
+// @Description  Move an achievement to the trash. It can be restored until the purge job removes it after the retention period.
 // @Tags         Achievement
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Achievement ID"
 // @Success      200  {object}  model.WebResponse[string]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Security     BearerAuth
 // @Router       /achievements/{id} [delete]
 func (s *AchievementServiceImpl) Delete(c *fiber.Ctx) error {
-	//TODO implement me
 	Id := c.Params("id")
 	ctx := c.UserContext()
+	val := ctx.Value("user")
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, Id)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	if val.(*model.Claims).Role == model.RoleStudent && !s.workflow.CanEdit(Achievement.Status) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s cannot be deleted while %s", Id, Achievement.Status),
+		}
+		return c.Status(fiber.StatusConflict).JSON(response)
+	}
 
-	err := s.repoAchivementReference.Delete(ctx, Id)
+	deletedAt := time.Now()
+	err = NewSaga(s.Log).
+		Step(SagaStep{
+			Name: "mark mongo achievement deleted",
+			Action: func(ctx context.Context) error {
+				return s.repoAchievement.MarkDeleted(ctx, Achievement.MongoAchievementID, &deletedAt)
+			},
+			Compensate: func(ctx context.Context) error {
+				return s.repoAchievement.MarkDeleted(ctx, Achievement.MongoAchievementID, nil)
+			},
+		}).
+		Step(SagaStep{
+			Name: "delete achievement reference",
+			Action: func(ctx context.Context) error {
+				return s.repoAchivementReference.Delete(ctx, Id)
+			},
+		}).
+		Run(ctx)
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
@@ -231,20 +485,178 @@ func (s *AchievementServiceImpl) Delete(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(response)
 }
 
+// Trash godoc
+// @Summary      List deleted achievements
+// @Description  Students see their own deleted achievements, admins see every student's. Deleted achievements can be restored until they are purged.
+// @Tags         Achievement
+// @Produce      json
+// @Param        page query int false "Page number" default(1)
+// @Param        limit query int false "Page size (max 100)" default(10)
+// @Param        cursor query string false "Cursor from paging.next_cursor; continues after that row instead of using page"
+// @Success      200  {object}  model.WebResponse[[]model.AchievementReferenceAdmin]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/trash [get]
+func (s *AchievementServiceImpl) Trash(c *fiber.Ctx) error {
+	Page, err := parsePageRequest(c)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	ctx := c.UserContext()
+	claims := ctx.Value("user").(*model.Claims)
+
+	studentID := ""
+	switch claims.Role {
+	case model.RoleAdmin:
+	case model.RoleStudent:
+		Student, err := s.repoStudent.FindByUserId(ctx, claims.UserID)
+		if err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
+			}
+			return c.Status(fiber.StatusNotFound).JSON(response)
+		}
+		studentID = Student.ID
+	default:
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: ErrForbidden.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	Achievements, err := s.repoAchivementReference.FindTrash(ctx, studentID, Page)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(listStatusCode(err, fiber.StatusInternalServerError)).JSON(response)
+	}
+
+	mongoIDs := make([]string, 0, len(Achievements.Items))
+	for _, ach := range Achievements.Items {
+		mongoIDs = append(mongoIDs, ach.MongoAchievementID)
+	}
+	details, err := s.loadDetails(ctx, mongoIDs)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	for i := range Achievements.Items {
+		if detail, found := details[Achievements.Items[i].MongoAchievementID]; found {
+			Achievements.Items[i].Title = detail.Title
+			Achievements.Items[i].Type = detail.AchievementType
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[[]model.AchievementReferenceAdmin]{
+		Status: "success",
+		Data:   Achievements.Items,
+		Paging: model.NewPageMetaData(Page, Achievements),
+	})
+}
+
+// Restore godoc
+// @Summary      Restore a deleted achievement
+// @Description  Take an achievement out of the trash with the status it had when it was deleted.
+// @Tags         Achievement
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/restore [post]
+func (s *AchievementServiceImpl) Restore(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+	val := ctx.Value("user")
+
+	Achievement, err := s.repoAchivementReference.FindDeletedByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s is not in the trash", id),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	err = NewSaga(s.Log).
+		Step(SagaStep{
+			Name: "unmark mongo achievement deleted",
+			Action: func(ctx context.Context) error {
+				return s.repoAchievement.MarkDeleted(ctx, Achievement.MongoAchievementID, nil)
+			},
+			Compensate: func(ctx context.Context) error {
+				return s.repoAchievement.MarkDeleted(ctx, Achievement.MongoAchievementID, Achievement.DeletedAt)
+			},
+		}).
+		Step(SagaStep{
+			Name: "restore achievement reference",
+			Action: func(ctx context.Context) error {
+				return s.repoAchivementReference.Restore(ctx, id)
+			},
+		}).
+		Run(ctx)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	Achievement.DeletedAt = nil
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[*model.AchievementReferenceDetail]{
+		Status: "success",
+		Data:   Achievement,
+	})
+}
+
 // FindByID godoc
 // @Summary      Get achievement by ID
-// @Description  Retrieve full details of an achievement including mongo data.
+// @Description  Retrieve full details of an achievement including mongo data and the comment thread.
 // @Tags         Achievement
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Achievement ID"
 // @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
+// @Failure      403  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Security     BearerAuth
 // @Router       /achievements/{id} [get]
 func (s *AchievementServiceImpl) FindByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	ctx := c.UserContext()
+	val := ctx.Value("user")
 
 	Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
 	if err != nil {
@@ -255,6 +667,14 @@ func (s *AchievementServiceImpl) FindByID(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
 	AchievementObj, err := s.repoAchievement.FindById(ctx, Achievement.MongoAchievementID)
 	if err != nil {
 		response := model.WebResponse[string]{
@@ -265,6 +685,17 @@ func (s *AchievementServiceImpl) FindByID(c *fiber.Ctx) error {
 	}
 	Achievement.Detail = AchievementObj
 
+	Comments, err := s.repoComment.FindByAchievementID(ctx, Achievement.ID)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	Achievement.Comments = Comments
+	linkDetail(Achievement)
+
 	return c.Status(fiber.StatusOK).JSON(model.WebResponse[*model.AchievementReferenceDetail]{
 		Status: "success",
 		Data:   Achievement,
@@ -273,25 +704,68 @@ func (s *AchievementServiceImpl) FindByID(c *fiber.Ctx) error {
 
 // FindAll godoc
 // @Summary      Get all achievements
-// @Description  Get achievements with pagination. Results filtered by user role (admin, mahasiswa, lecturer).
+// @Description  Get achievements with pagination, filters and sorting. Results are scoped by user role (admin, mahasiswa, lecturer).
 // @Tags         Achievement
 // @Accept       json
 // @Produce      json
 // @Param        page query int false "Page number"
-// @Param        limit query int false "Limit per page"
+// @Param        limit query int false "Limit per page (max 100)"
+// @Param        cursor query string false "Cursor from paging.next_cursor; only with the default created_at sort and without type, competition_level, tag or q"
+// @Param        status query string false "Status" Enums(draft, submitted, verified, rejected, needs_revision)
+// @Param        type query string false "Achievement type" Enums(academic, competition, organization, publication, certification, other)
+// @Param        competition_level query string false "Competition level" Enums(international, national, regional, local)
+// @Param        tag query string false "Tag"
+// @Param        academic_year query string false "Student academic year"
+// @Param        program_study query string false "Student program study"
+// @Param        from query string false "Created on or after (YYYY-MM-DD)"
+// @Param        to query string false "Created on or before (YYYY-MM-DD)"
+// @Param        q query string false "Search in title"
+// @Param        sort query string false "Sort column" Enums(created_at, submitted_at, verified_at, status, title, achievement_type)
+// @Param        order query string false "Sort order" Enums(asc, desc)
 // @Success      200  {object}  model.WebResponse[[]model.AchievementReferenceDetail]
+// @Failure      400  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Security     BearerAuth
 // @Router       /achievements [get]
 func (s *AchievementServiceImpl) FindAll(c *fiber.Ctx) error {
+	filter := model.AchievementFilter{PageRequest: model.NewPageRequest()}
+	if err := c.QueryParser(&filter); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+	if err := s.validate.Struct(filter); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+	if filter.Cursor != "" && !filter.SupportsCursor() {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: "cursor pagination is only available when sorting by created_at",
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+	// Mongo-side filters page a capped candidate list, which a cursor would
+	// walk past without notice, so they use page numbers like search does
+	if filter.Cursor != "" && filter.FiltersInMongo() {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: "cursor pagination is not available with the type, competition_level, tag or q filters",
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
 
-	Page := c.QueryInt("page", 1)
-	Limit := c.QueryInt("limit", 10)
-	ctx := c.UserContext()
-	val := ctx.Value("user")
-	var response model.WebResponse[any]
-	if val.(*model.Claims).Role == "admin" {
-		Achievements, err := s.repoAchivementReference.FindAll(ctx, Page, Limit)
+	// Mongo-side criteria narrow the candidate set before Postgres pages it,
+	// so the page size and totals stay exact up to listCandidateLimit.
+	truncated := false
+	if filter.FiltersInMongo() {
+		ctx := c.UserContext()
+		scope, err := s.studentScope(ctx, ctx.Value("user").(*model.Claims))
 		if err != nil {
 			response := model.WebResponse[string]{
 				Status: "error",
@@ -299,16 +773,12 @@ func (s *AchievementServiceImpl) FindAll(c *fiber.Ctx) error {
 			}
 			return c.Status(fiber.StatusInternalServerError).JSON(response)
 		}
-		var oids []string
-		for _, ach := range Achievements {
-			oids = append(oids, ach.MongoAchievementID)
+		if scope != nil && len(scope) == 0 {
+			return emptyList(c, filter.PageRequest)
 		}
+		filter.StudentIDs = scope
 
-		if len(oids) == 0 {
-			response.Data = []any{} // Return array kosong
-			return c.Status(fiber.StatusOK).JSON(response)
-		}
-		AchievementObjs, err := s.repoAchievement.FindAll(ctx, oids)
+		ids, err := s.repoAchievement.FindIDs(ctx, filter, listCandidateLimit+1)
 		if err != nil {
 			response := model.WebResponse[string]{
 				Status: "error",
@@ -316,44 +786,79 @@ func (s *AchievementServiceImpl) FindAll(c *fiber.Ctx) error {
 			}
 			return c.Status(fiber.StatusInternalServerError).JSON(response)
 		}
-
-		detailMap := make(map[primitive.ObjectID]model.AchievementMongo)
-		for _, detail := range AchievementObjs {
-			detailMap[detail.ID] = detail
+		if len(ids) == 0 {
+			return emptyList(c, filter.PageRequest)
 		}
+		if len(ids) > listCandidateLimit {
+			ids, truncated = ids[:listCandidateLimit], true
+		}
+		// Mongo ranks in the requested direction so that the cut keeps the
+		// right end; the list expects the ascending rank
+		if filter.SortsInMongo() && filter.Descending() {
+			slices.Reverse(ids)
+		}
+		filter.MongoIDs = ids
+	}
 
-		for i := range Achievements {
-			mongoID, err := primitive.ObjectIDFromHex(Achievements[i].MongoAchievementID)
-
-			if err == nil {
-				if detail, found := detailMap[mongoID]; found {
-					Achievements[i].Title = detail.Title
-					Achievements[i].Type = detail.AchievementType
-					Achievements[i].CreatedAt = detail.CreatedAt
+	return s.listAchievements(c, filter, truncated)
+}
 
-				}
-			}
+// studentScope returns the students whose achievements the caller may list:
+// nil for admins, who are not scoped, the caller's own record for students
+// and their advisees for lecturers.
+func (s *AchievementServiceImpl) studentScope(ctx context.Context, claims *model.Claims) ([]string, error) {
+	switch claims.Role {
+	case model.RoleAdmin:
+		return nil, nil
+	case model.RoleStudent:
+		Student, err := s.repoStudent.FindByUserId(ctx, claims.UserID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return []string{}, nil
 		}
-		response.Data = Achievements
-	} else if val.(*model.Claims).Role == "mahasiswa" {
-		Achievements, err := s.repoAchivementReference.FindByStudent(ctx, val.(*model.Claims).UserID, Page, Limit)
 		if err != nil {
-			response := model.WebResponse[string]{
-				Status: "error",
-				Errors: err.Error(),
-			}
-			return c.Status(fiber.StatusInternalServerError).JSON(response)
+			return nil, err
 		}
-		var oids []string
-		for _, ach := range Achievements {
-			oids = append(oids, ach.MongoAchievementID)
-		}
-
-		if len(oids) == 0 {
-			response.Data = []any{} // Return array kosong
-			return c.Status(fiber.StatusOK).JSON(response)
+		return []string{Student.ID}, nil
+	case model.RoleLecturer:
+		return s.repoStudent.FindIDsByAdvisor(ctx, claims.UserID)
+	}
+	return []string{}, nil
+}
+
+// emptyList answers a list request that has no results.
+func emptyList(c *fiber.Ctx, page model.PageRequest) error {
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[any]{
+		Status: "success",
+		Data:   []any{},
+		Paging: model.NewPageMetaData(page, &model.PageResult[any]{}),
+	})
+}
+
+// listAchievements pages the achievements matching filter within the
+// caller's scope: all for admins, their own for students and their advisees'
+// for lecturers. truncated marks a candidate list that was cut off.
+func (s *AchievementServiceImpl) listAchievements(c *fiber.Ctx, filter model.AchievementFilter, truncated bool) error {
+	ctx := c.UserContext()
+	val := ctx.Value("user")
+	var response model.WebResponse[any]
+	response.Status = "success"
+	response.Paging = model.NewPageMetaData(filter.PageRequest, &model.PageResult[any]{})
+
+	var mongoIDs []string
+	switch val.(*model.Claims).Role {
+	case model.RoleAdmin:
+		Achievements, err := s.repoAchivementReference.FindAll(ctx, filter)
+		if err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
+			}
+			return c.Status(listStatusCode(err, fiber.StatusInternalServerError)).JSON(response)
+		}
+		for _, ach := range Achievements.Items {
+			mongoIDs = append(mongoIDs, ach.MongoAchievementID)
 		}
-		AchievementObjs, err := s.repoAchievement.FindAll(ctx, oids)
+		details, err := s.loadDetails(ctx, mongoIDs)
 		if err != nil {
 			response := model.WebResponse[string]{
 				Status: "error",
@@ -361,27 +866,28 @@ func (s *AchievementServiceImpl) FindAll(c *fiber.Ctx) error {
 			}
 			return c.Status(fiber.StatusInternalServerError).JSON(response)
 		}
-
-		detailMap := make(map[primitive.ObjectID]model.AchievementMongo)
-		for _, detail := range AchievementObjs {
-			detailMap[detail.ID] = detail
+		for i := range Achievements.Items {
+			if detail, found := details[Achievements.Items[i].MongoAchievementID]; found {
+				Achievements.Items[i].Title = detail.Title
+				Achievements.Items[i].Type = detail.AchievementType
+				Achievements.Items[i].CreatedAt = detail.CreatedAt
+			}
 		}
-
-		for i := range Achievements {
-			mongoID, err := primitive.ObjectIDFromHex(Achievements[i].MongoAchievementID)
-
-			if err == nil {
-				if detail, found := detailMap[mongoID]; found {
-					Achievements[i].Title = detail.Title
-					Achievements[i].Type = detail.AchievementType
-					Achievements[i].CreatedAt = detail.CreatedAt
-
-				}
+		response.Data = Achievements.Items
+		response.Paging = model.NewPageMetaData(filter.PageRequest, Achievements)
+	case model.RoleStudent:
+		Achievements, err := s.repoAchivementReference.FindByStudent(ctx, val.(*model.Claims).UserID, filter)
+		if err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
 			}
+			return c.Status(listStatusCode(err, fiber.StatusInternalServerError)).JSON(response)
+		}
+		for _, ach := range Achievements.Items {
+			mongoIDs = append(mongoIDs, ach.MongoAchievementID)
 		}
-		response.Data = Achievements
-	} else if val.(*model.Claims).Role == "lecturer" {
-		Achievements, err := s.repoAchivementReference.FindByLecturer(ctx, val.(*model.Claims).UserID, Page, Limit)
+		details, err := s.loadDetails(ctx, mongoIDs)
 		if err != nil {
 			response := model.WebResponse[string]{
 				Status: "error",
@@ -389,16 +895,28 @@ func (s *AchievementServiceImpl) FindAll(c *fiber.Ctx) error {
 			}
 			return c.Status(fiber.StatusInternalServerError).JSON(response)
 		}
-		var oids []string
-		for _, ach := range Achievements {
-			oids = append(oids, ach.MongoAchievementID)
+		for i := range Achievements.Items {
+			if detail, found := details[Achievements.Items[i].MongoAchievementID]; found {
+				Achievements.Items[i].Title = detail.Title
+				Achievements.Items[i].Type = detail.AchievementType
+				Achievements.Items[i].CreatedAt = detail.CreatedAt
+			}
 		}
-
-		if len(oids) == 0 {
-			response.Data = []any{} // Return array kosong
-			return c.Status(fiber.StatusOK).JSON(response)
+		response.Data = Achievements.Items
+		response.Paging = model.NewPageMetaData(filter.PageRequest, Achievements)
+	case model.RoleLecturer:
+		Achievements, err := s.repoAchivementReference.FindByLecturer(ctx, val.(*model.Claims).UserID, filter)
+		if err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
+			}
+			return c.Status(listStatusCode(err, fiber.StatusInternalServerError)).JSON(response)
+		}
+		for _, ach := range Achievements.Items {
+			mongoIDs = append(mongoIDs, ach.MongoAchievementID)
 		}
-		AchievementObjs, err := s.repoAchievement.FindAll(ctx, oids)
+		details, err := s.loadDetails(ctx, mongoIDs)
 		if err != nil {
 			response := model.WebResponse[string]{
 				Status: "error",
@@ -406,32 +924,116 @@ func (s *AchievementServiceImpl) FindAll(c *fiber.Ctx) error {
 			}
 			return c.Status(fiber.StatusInternalServerError).JSON(response)
 		}
-
-		detailMap := make(map[primitive.ObjectID]model.AchievementMongo)
-		for _, detail := range AchievementObjs {
-			detailMap[detail.ID] = detail
+		for i := range Achievements.Items {
+			if detail, found := details[Achievements.Items[i].MongoAchievementID]; found {
+				Achievements.Items[i].Title = detail.Title
+				Achievements.Items[i].Type = detail.AchievementType
+				Achievements.Items[i].CreatedAt = detail.CreatedAt
+			}
 		}
+		response.Data = Achievements.Items
+		response.Paging = model.NewPageMetaData(filter.PageRequest, Achievements)
+	}
+	response.Paging.Truncated = truncated
+	if filter.MongoIDs != nil {
+		// Candidate lists are paged by number only
+		response.Paging.NextCursor = ""
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
 
-		for i := range Achievements {
-			mongoID, err := primitive.ObjectIDFromHex(Achievements[i].MongoAchievementID)
+// Search godoc
+// @Summary      Search achievements
+// @Description  Full-text search over titles, descriptions, competition names, organizers and tags, best match first. Results are scoped by role like the achievement list.
+// @Tags         Achievement
+// @Produce      json
+// @Param        q query string true "Search words, or a \"quoted phrase\""
+// @Param        status query string false "Status" Enums(draft, submitted, verified, rejected, needs_revision)
+// @Param        type query string false "Achievement type" Enums(academic, competition, organization, publication, certification, other)
+// @Param        page query int false "Page number"
+// @Param        limit query int false "Limit per page (max 100)"
+// @Success      200  {object}  model.WebResponse[[]model.AchievementReferenceDetail]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/search [get]
+func (s *AchievementServiceImpl) Search(c *fiber.Ctx) error {
+	request := model.AchievementSearchRequest{PageRequest: model.NewPageRequest()}
+	if err := c.QueryParser(&request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+	if err := s.validate.Struct(request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+	if request.Cursor != "" {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: "cursor pagination is not available for search results",
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
 
-			if err == nil {
-				if detail, found := detailMap[mongoID]; found {
-					Achievements[i].Title = detail.Title
-					Achievements[i].Type = detail.AchievementType
-					Achievements[i].CreatedAt = detail.CreatedAt
+	// The role scope is applied in Mongo, so the candidate limit only cuts
+	// matches the caller could see
+	ctx := c.UserContext()
+	scope, err := s.studentScope(ctx, ctx.Value("user").(*model.Claims))
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	if scope != nil && len(scope) == 0 {
+		return emptyList(c, request.PageRequest)
+	}
 
-				}
-			}
+	ids, err := s.repoAchievement.FindTextMatches(ctx, request.Query, request.AchievementType, scope, searchCandidateLimit+1)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
 		}
-		response.Data = Achievements
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
-	response.Status = "success"
-	response.Paging = &model.PageMetaData{
-		Page: Page,
-		Size: Limit,
+	if len(ids) == 0 {
+		return emptyList(c, request.PageRequest)
 	}
-	return c.Status(fiber.StatusOK).JSON(response)
+	truncated := false
+	if len(ids) > searchCandidateLimit {
+		ids, truncated = ids[:searchCandidateLimit], true
+	}
+
+	return s.listAchievements(c, model.AchievementFilter{
+		Status:      request.Status,
+		Sort:        model.SortRelevance,
+		PageRequest: request.PageRequest,
+		MongoIDs:    ids,
+	}, truncated)
+}
+
+// loadDetails fetches the Mongo documents of a page keyed by their hex id.
+func (s *AchievementServiceImpl) loadDetails(ctx context.Context, mongoIDs []string) (map[string]model.AchievementMongo, error) {
+	details := map[string]model.AchievementMongo{}
+	if len(mongoIDs) == 0 {
+		return details, nil
+	}
+	AchievementObjs, err := s.repoAchievement.FindAll(ctx, mongoIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range AchievementObjs {
+		details[detail.ID.Hex()] = detail
+	}
+	return details, nil
 }
 
 // Verify godoc
@@ -442,9 +1044,11 @@ func (s *AchievementServiceImpl) FindAll(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      string  true  "Achievement ID"
 // @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Security     BearerAuth
-// @Router       /achievements/{id}/verify [patch]
+// @Router       /achievements/{id}/verify [post]
 func (s *AchievementServiceImpl) Verify(c *fiber.Ctx) error {
 	id := c.Params("id")
 	ctx := c.UserContext()
@@ -457,29 +1061,39 @@ func (s *AchievementServiceImpl) Verify(c *fiber.Ctx) error {
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
 	now := time.Now()
 	AchievementRefer := &model.AchievementReference{
 		MongoAchievementID: Achievement.MongoAchievementID,
 		StudentID:          Achievement.UserDetail.StudentProfile.StudentID,
 		ID:                 Achievement.ID,
-		Status:             "verified",
+		Status:             model.AchievementStatusVerified,
 		SubmittedAt:        Achievement.SubmittedAt,
 		VerifiedAt:         &now,
 		VerifiedBy:         val.(*model.Claims).UserID,
 		Detail:             Achievement.Detail,
 	}
 
-	AchievementRefer, err = s.repoAchivementReference.Update(ctx, *AchievementRefer)
+	AchievementRefer, err = s.transition(ctx, Achievement, *AchievementRefer, val.(*model.Claims).UserID, "")
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
 			Errors: err.Error(),
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(response)
+		return c.Status(transitionStatusCode(err)).JSON(response)
 	}
-	Achievement.Status = "verified"
+	Achievement.Status = AchievementRefer.Status
 	Achievement.VerifiedBy = &val.(*model.Claims).UserID
 	Achievement.VerifiedAt = &now
+	s.awardPoints(ctx, Achievement.MongoAchievementID)
 
 	response := model.WebResponse[*model.AchievementReferenceDetail]{
 		Status: "success",
@@ -497,9 +1111,11 @@ func (s *AchievementServiceImpl) Verify(c *fiber.Ctx) error {
 // @Produce      json
 // @Param        id   path      string  true  "Achievement ID"
 // @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Security     BearerAuth
-// @Router       /achievements/{id}/submit [patch]
+// @Router       /achievements/{id}/submit [post]
 func (s *AchievementServiceImpl) Submit(c *fiber.Ctx) error {
 	id := c.Params("id")
 	ctx := c.UserContext()
@@ -514,27 +1130,34 @@ func (s *AchievementServiceImpl) Submit(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
 	now := time.Now()
 	AchievementRefer := &model.AchievementReference{
 		MongoAchievementID: Achievement.MongoAchievementID,
 		StudentID:          Achievement.UserDetail.StudentProfile.StudentID,
 		ID:                 Achievement.ID,
-		Status:             "submitted",
-		SubmittedAt:        Achievement.SubmittedAt,
-		VerifiedAt:         &now,
-		VerifiedBy:         val.(*model.Claims).UserID,
+		Status:             model.AchievementStatusSubmitted,
+		SubmittedAt:        &now,
 		Detail:             Achievement.Detail,
 	}
 
-	AchievementRefer, err = s.repoAchivementReference.Update(ctx, *AchievementRefer)
+	AchievementRefer, err = s.transition(ctx, Achievement, *AchievementRefer, val.(*model.Claims).UserID, "")
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
 			Errors: err.Error(),
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(response)
+		return c.Status(transitionStatusCode(err)).JSON(response)
 	}
 	Achievement.Status = AchievementRefer.Status
+	Achievement.SubmittedAt = &now
 
 	response := model.WebResponse[*model.AchievementReferenceDetail]{
 		Status: "success",
@@ -543,20 +1166,86 @@ func (s *AchievementServiceImpl) Submit(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(response)
 }
 
+// Reopen godoc
+// @Summary      Reopen a rejected achievement
+// @Description  Move a rejected achievement back to draft so that it can be edited and submitted again. The rejection note stays in the history.
+// @Tags         Achievement
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/reopen [post]
+func (s *AchievementServiceImpl) Reopen(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+	claims := ctx.Value("user").(*model.Claims)
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: "achievement not found",
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(claims, Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	next := model.AchievementReference{
+		MongoAchievementID: Achievement.MongoAchievementID,
+		StudentID:          Achievement.Owner.StudentID,
+		ID:                 Achievement.ID,
+		Status:             model.AchievementStatusDraft,
+	}
+	if _, err := s.transition(ctx, Achievement, next, claims.UserID, ""); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(transitionStatusCode(err)).JSON(response)
+	}
+	Achievement.Status = model.AchievementStatusDraft
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[*model.AchievementReferenceDetail]{
+		Status: "success",
+		Data:   Achievement,
+	})
+}
+
 // History godoc
 // @Summary      Get achievement history
-// @Description  Get the timeline history of an achievement.
+// @Description  Get the ordered timeline of an achievement: every status change with who made it and why, interleaved with comments.
 // @Tags         Achievement
 // @Accept       json
 // @Produce      json
 // @Param        id   path      string  true  "Achievement ID"
 // @Success      200  {object}  model.WebResponse[[]model.AchievementHistory]
+// @Failure      403  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Security     BearerAuth
 // @Router       /achievements/{id}/history [get]
 func (s *AchievementServiceImpl) History(c *fiber.Ctx) error {
 	id := c.Params("id")
 	ctx := c.UserContext()
+	val := ctx.Value("user")
 
 	achievement, err := s.repoAchivementReference.FindByID(ctx, id)
 	if err != nil {
@@ -567,36 +1256,47 @@ func (s *AchievementServiceImpl) History(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 
-	histories := make([]model.AchievementHistory, 0, 3)
-
-	histories = append(histories, model.AchievementHistory{
-		Action:    "Dibuat",
-		Timestamp: achievement.CreatedAt,
-	})
+	if err := s.policy.Authorize(val.(*model.Claims), achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
 
-	if achievement.SubmittedAt != nil {
-		histories = append(histories, model.AchievementHistory{
-			Action:    "Diajukan",
-			Timestamp: *achievement.SubmittedAt,
-		})
+	histories, err := s.repoHistory.FindByAchievementID(ctx, achievement.ID)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 
-	if achievement.VerifiedAt != nil {
-		actionLabel := "Diverifikasi"
-		if achievement.Status == "rejected" {
-			actionLabel = "rejected"
-		} else if achievement.Status == "verified" {
-			actionLabel = "verified"
+	comments, err := s.repoComment.FindByAchievementID(ctx, achievement.ID)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
 		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
 
+	for _, comment := range comments {
+		body := comment.Body
 		histories = append(histories, model.AchievementHistory{
-			Action:    actionLabel,
-			Timestamp: *achievement.VerifiedAt,
+			ID:            comment.ID,
+			AchievementID: comment.AchievementID,
+			Type:          model.HistoryTypeComment,
+			ActorID:       comment.AuthorID,
+			ActorName:     comment.AuthorName,
+			Note:          &body,
+			Timestamp:     comment.CreatedAt,
 		})
 	}
 
-	sort.Slice(histories, func(i, j int) bool {
-		return histories[i].Timestamp.After(histories[j].Timestamp)
+	sort.SliceStable(histories, func(i, j int) bool {
+		return histories[i].Timestamp.Before(histories[j].Timestamp)
 	})
 
 	return c.JSON(model.WebResponse[[]model.AchievementHistory]{
@@ -607,20 +1307,23 @@ func (s *AchievementServiceImpl) History(c *fiber.Ctx) error {
 
 // Attachment godoc
 // @Summary      Upload attachment
-// @Description  Upload file attachments for an achievement.
+// @Description  Upload file attachments for an achievement. The type is detected from the file content and must be on the allow-list (PDF, PNG, JPEG by default); sizes are limited per file and per achievement. When any file is rejected nothing is stored and data lists the reason for each rejected file. New files are pending until the background malware scan marks them clean.
 // @Tags         Achievement
 // @Accept       multipart/form-data
 // @Produce      json
 // @Param        id   path      string  true  "Achievement ID"
 // @Param        attachments formData file true "Files to upload"
 // @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
-// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      400  {object}  model.WebResponse[[]model.AttachmentRejection]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Security     BearerAuth
 // @Router       /achievements/{id}/attachments [post]
 func (s *AchievementServiceImpl) Attachment(c *fiber.Ctx) error {
 	id := c.Params("id")
 	ctx := c.UserContext()
+	val := ctx.Value("user")
 
 	form, err := c.MultipartForm()
 	if err != nil {
@@ -646,6 +1349,21 @@ func (s *AchievementServiceImpl) Attachment(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := s.policy.Authorize(val.(*model.Claims), achievementRef.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	if !s.workflow.CanEdit(achievementRef.Status) {
+		return c.Status(fiber.StatusConflict).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s cannot be edited while %s", id, achievementRef.Status),
+		})
+	}
+
 	achievementObj, err := s.repoAchievement.FindById(ctx, achievementRef.MongoAchievementID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
@@ -654,48 +1372,56 @@ func (s *AchievementServiceImpl) Attachment(c *fiber.Ctx) error {
 		})
 	}
 
-	var newAttachments []model.Attachment
-
-	baseDir := "./public/uploads/achievements"
-	baseURL := "/uploads/achievements"
-
-	if _, err := os.Stat(baseDir); os.IsNotExist(err) {
-		os.MkdirAll(baseDir, 0755)
+	used, err := s.attachmentUsage(ctx, achievementRef.ID, achievementObj)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 
-	for _, file := range files {
-		uniqueName := fmt.Sprintf("%d_%s", time.Now().UnixNano(), file.Filename)
-		savePath := fmt.Sprintf("%s/%s", baseDir, uniqueName)
-
-		if err := c.SaveFile(file, savePath); err != nil {
-			response := model.WebResponse[string]{
-				Status: "error",
-				Errors: err.Error(),
-			}
-			return c.Status(fiber.StatusInternalServerError).JSON(response)
+	uploads, err := s.uploads.Validate(files, used)
+	var uploadErr *UploadError
+	if errors.As(err, &uploadErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[[]model.AttachmentRejection]{
+			Status: "error",
+			Data:   uploadErr.Rejections,
+			Errors: err.Error(),
+		})
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
 		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
 
-		attachment := model.Attachment{
-			FileName:   file.Filename,
-			FileURL:    fmt.Sprintf("%s/%s", baseURL, uniqueName),
-			FileType:   file.Header.Get("Content-Type"),
-			UploadedAt: time.Now(),
+	newAttachments, err := s.saveUploads(ctx, achievementRef.ID, uploads)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
 		}
-
-		newAttachments = append(newAttachments, attachment)
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
 
-	achievementObj.Attachments = append(achievementObj.Attachments, newAttachments...)
-
-	AchievementObj, err := s.repoAchievement.Update(ctx, *achievementObj)
-	if err != nil {
+	if err := s.repoAchievement.AddAttachments(ctx, achievementRef.MongoAchievementID, newAttachments); err != nil {
+		if cleanupErr := removeAttachments(context.WithoutCancel(ctx), s.storage, newAttachments); cleanupErr != nil {
+			s.Log.Warnf("remove uploads of achievement %s after failed update: %v", id, cleanupErr)
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
 			Status: "error",
 			Errors: "Gagal update database: " + err.Error(),
 		})
 	}
 
-	achievementRef.Detail = AchievementObj
+	s.scans.Enqueue(scanJobs(achievementRef.MongoAchievementID, "", newAttachments)...)
+
+	achievementObj.Attachments = append(achievementObj.Attachments, newAttachments...)
+	achievementRef.Detail = achievementObj
+	linkDetail(achievementRef)
 
 	return c.JSON(model.WebResponse[*model.AchievementReferenceDetail]{
 		Status: "success",
@@ -713,6 +1439,8 @@ func (s *AchievementServiceImpl) Attachment(c *fiber.Ctx) error {
 // @Param        request body model.CreateRejection true "Rejection Note"
 // @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
 // @Failure      400  {object}  model.WebResponse[string]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Security     BearerAuth
 // @Router       /achievements/{id}/reject [post]
@@ -738,12 +1466,21 @@ func (s *AchievementServiceImpl) Reject(c *fiber.Ctx) error {
 		}
 		return c.Status(fiber.StatusInternalServerError).JSON(response)
 	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
 	now := time.Now()
 	AchievementRefer := &model.AchievementReference{
 		MongoAchievementID: Achievement.MongoAchievementID,
 		StudentID:          Achievement.UserDetail.StudentProfile.StudentID,
 		ID:                 Achievement.ID,
-		Status:             "rejected",
+		Status:             model.AchievementStatusRejected,
 		RejectionNote:      request.RejectionNote,
 		SubmittedAt:        Achievement.SubmittedAt,
 		VerifiedAt:         &now,
@@ -751,13 +1488,13 @@ func (s *AchievementServiceImpl) Reject(c *fiber.Ctx) error {
 		Detail:             Achievement.Detail,
 	}
 
-	AchievementRefer, err = s.repoAchivementReference.Update(ctx, *AchievementRefer)
+	AchievementRefer, err = s.transition(ctx, Achievement, *AchievementRefer, val.(*model.Claims).UserID, request.RejectionNote)
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
 			Errors: err.Error(),
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(response)
+		return c.Status(transitionStatusCode(err)).JSON(response)
 	}
 	Achievement.Status = AchievementRefer.Status
 
@@ -768,3 +1505,912 @@ func (s *AchievementServiceImpl) Reject(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(response)
 
 }
+
+// RequestRevision godoc
+// @Summary      Request revision of an achievement
+// @Description  Send a submitted achievement back to the student for changes. The student can edit and resubmit it, which starts a new review round.
+// @Tags         Achievement
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Param        request body model.CreateRevisionRequest true "Revision Note"
+// @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/request-revision [post]
+func (s *AchievementServiceImpl) RequestRevision(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+	val := ctx.Value("user")
+
+	var request model.CreateRevisionRequest
+	if err := c.BodyParser(&request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	if err := s.validate.Struct(request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	AchievementRefer := &model.AchievementReference{
+		MongoAchievementID: Achievement.MongoAchievementID,
+		StudentID:          Achievement.Owner.StudentID,
+		ID:                 Achievement.ID,
+		Status:             model.AchievementStatusNeedsRevision,
+		RevisionNote:       request.RevisionNote,
+		RevisionRound:      Achievement.RevisionRound + 1,
+	}
+
+	AchievementRefer, err = s.transition(ctx, Achievement, *AchievementRefer, val.(*model.Claims).UserID, request.RevisionNote)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(transitionStatusCode(err)).JSON(response)
+	}
+	Achievement.Status = AchievementRefer.Status
+	Achievement.RevisionNote = &request.RevisionNote
+	Achievement.RevisionRound = AchievementRefer.RevisionRound
+
+	response := model.WebResponse[*model.AchievementReferenceDetail]{
+		Status: "success",
+		Data:   Achievement,
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// Comments godoc
+// @Summary      List achievement comments
+// @Description  Get the discussion thread between the student and the advisor on an achievement.
+// @Tags         Achievement
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Success      200  {object}  model.WebResponse[[]model.AchievementComment]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/comments [get]
+func (s *AchievementServiceImpl) Comments(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+	val := ctx.Value("user")
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	Comments, err := s.repoComment.FindByAchievementID(ctx, Achievement.ID)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	for i := range Comments {
+		linkAttachments(Achievement.ID, Comments[i].Attachments)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[[]model.AchievementComment]{
+		Status: "success",
+		Data:   Comments,
+	})
+}
+
+// CreateComment godoc
+// @Summary      Post a comment
+// @Description  Post a message on an achievement thread, optionally with file attachments. Attachments follow the same rules and share the quota of the achievement's own uploads.
+// @Tags         Achievement
+// @Accept       json,mpfd
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Param        body formData string true "Comment body"
+// @Param        attachments formData file false "Files to attach"
+// @Success      201  {object}  model.WebResponse[model.AchievementComment]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/comments [post]
+func (s *AchievementServiceImpl) CreateComment(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+	val := ctx.Value("user")
+
+	var request model.CreateCommentRequest
+	if err := c.BodyParser(&request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	if err := s.validate.Struct(request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	var files []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		files = form.File["attachments"]
+	}
+
+	var uploads []CheckedUpload
+	if len(files) > 0 {
+		AchievementObj, err := s.repoAchievement.FindById(ctx, Achievement.MongoAchievementID)
+		if err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(response)
+		}
+
+		used, err := s.attachmentUsage(ctx, Achievement.ID, AchievementObj)
+		if err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(response)
+		}
+
+		uploads, err = s.uploads.Validate(files, used)
+		var uploadErr *UploadError
+		if errors.As(err, &uploadErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[[]model.AttachmentRejection]{
+				Status: "error",
+				Data:   uploadErr.Rejections,
+				Errors: err.Error(),
+			})
+		}
+		if err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(response)
+		}
+	}
+
+	attachments, err := s.saveUploads(ctx, Achievement.ID, uploads)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	comment := model.AchievementComment{
+		AchievementID: Achievement.ID,
+		AuthorID:      &val.(*model.Claims).UserID,
+		AuthorName:    &val.(*model.Claims).FullName,
+		AuthorRole:    &val.(*model.Claims).Role,
+		Body:          request.Body,
+		Attachments:   attachments,
+	}
+
+	Comment, err := s.repoComment.Save(ctx, comment)
+	if err != nil {
+		if cleanupErr := removeAttachments(context.WithoutCancel(ctx), s.storage, attachments); cleanupErr != nil {
+			s.Log.Warnf("remove uploads of comment on achievement %s after failed save: %v", id, cleanupErr)
+		}
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	s.scans.Enqueue(scanJobs("", Comment.ID, attachments)...)
+	linkAttachments(Achievement.ID, Comment.Attachments)
+
+	return c.Status(fiber.StatusCreated).JSON(model.WebResponse[*model.AchievementComment]{
+		Status: "success",
+		Data:   Comment,
+	})
+}
+
+// BulkVerify godoc
+// @Summary      Verify achievements in bulk
+// @Description  Verify several submitted achievements in one transaction. Each ID gets its own result: success, not_found, illegal_state, not_your_advisee or conflict when another reviewer changed it first.
+// @Tags         Achievement
+// @Accept       json
+// @Produce      json
+// @Param        request body model.BulkReviewRequest true "Achievement IDs and optional shared note"
+// @Success      200  {object}  model.WebResponse[[]model.BulkReviewResult]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/bulk-verify [post]
+func (s *AchievementServiceImpl) BulkVerify(c *fiber.Ctx) error {
+	return s.bulkReview(c, model.AchievementStatusVerified)
+}
+
+// BulkReject godoc
+// @Summary      Reject achievements in bulk
+// @Description  Reject several submitted achievements in one transaction with a shared note. Each ID gets its own result: success, not_found, illegal_state, not_your_advisee or conflict when another reviewer changed it first.
+// @Tags         Achievement
+// @Accept       json
+// @Produce      json
+// @Param        request body model.BulkReviewRequest true "Achievement IDs and optional shared note"
+// @Success      200  {object}  model.WebResponse[[]model.BulkReviewResult]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/bulk-reject [post]
+func (s *AchievementServiceImpl) BulkReject(c *fiber.Ctx) error {
+	return s.bulkReview(c, model.AchievementStatusRejected)
+}
+
+// bulkReview moves every reviewable achievement in the request to status.
+// Items that are missing, in the wrong state or outside the caller's scope are
+// reported and skipped; a database failure rolls back the whole batch.
+func (s *AchievementServiceImpl) bulkReview(c *fiber.Ctx, status string) error {
+	ctx := c.UserContext()
+	claims := ctx.Value("user").(*model.Claims)
+
+	var request model.BulkReviewRequest
+	if err := c.BodyParser(&request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	if err := s.validate.Struct(request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	seen := make(map[string]bool, len(request.IDs))
+	results := make([]model.BulkReviewResult, 0, len(request.IDs))
+	verified := []string{}
+	for _, id := range request.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result := model.BulkReviewResult{ID: id}
+
+		Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			result.Result = model.BulkResultNotFound
+			result.Error = "achievement not found"
+			results = append(results, result)
+			continue
+		}
+		if err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(response)
+		}
+
+		if err := s.policy.Authorize(claims, Achievement.Owner); err != nil {
+			result.Result = model.BulkResultNotAdvisee
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		if err := s.workflow.Transition(Achievement.Status, status); err != nil {
+			result.Result = model.BulkResultIllegalState
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		next := model.AchievementReference{
+			MongoAchievementID: Achievement.MongoAchievementID,
+			StudentID:          Achievement.Owner.StudentID,
+			ID:                 Achievement.ID,
+			Status:             status,
+			SubmittedAt:        Achievement.SubmittedAt,
+			VerifiedAt:         &now,
+			VerifiedBy:         claims.UserID,
+		}
+		if status == model.AchievementStatusRejected {
+			next.RejectionNote = request.Note
+		}
+
+		_, err = s.applyTransition(ctx, tx, Achievement, next, claims.UserID, request.Note)
+		if errors.Is(err, repository.ErrStatusChanged) {
+			result.Result = model.BulkResultConflict
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(response)
+		}
+
+		if status == model.AchievementStatusVerified {
+			verified = append(verified, Achievement.MongoAchievementID)
+		}
+		result.Result = model.BulkResultSuccess
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	for _, mongoID := range verified {
+		s.awardPoints(ctx, mongoID)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[[]model.BulkReviewResult]{
+		Status: "success",
+		Data:   results,
+	})
+}
+
+// DownloadAttachment godoc
+// @Summary      Download an attachment
+// @Description  Download a file attached to an achievement or one of its comments. Only users allowed to see the achievement can download it. Files are held back with 409 while the malware scan is pending and with 403 when it found malware.
+// @Tags         Achievement
+// @Produce      octet-stream
+// @Param        id   path      string  true  "Achievement ID"
+// @Param        attachmentId   path      string  true  "Attachment ID"
+// @Success      200  {file}    file
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/attachments/{attachmentId} [get]
+func (s *AchievementServiceImpl) DownloadAttachment(c *fiber.Ctx) error {
+	return s.serveAttachment(c, false)
+}
+
+// PreviewAttachment godoc
+// @Summary      Preview an attachment
+// @Description  Get the JPEG preview of an image attachment or of the first page of a PDF, under the same access rules as downloads. Attachments without a preview have no preview_url.
+// @Tags         Achievement
+// @Produce      jpeg
+// @Param        id   path      string  true  "Achievement ID"
+// @Param        attachmentId   path      string  true  "Attachment ID"
+// @Success      200  {file}    file
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/attachments/{attachmentId}/preview [get]
+func (s *AchievementServiceImpl) PreviewAttachment(c *fiber.Ctx) error {
+	return s.serveAttachment(c, true)
+}
+
+// CreateAttachmentLink godoc
+// @Summary      Share an attachment
+// @Description  Create a signed link to an attachment that works without logging in until it expires, for verifiers outside the system. Defaults to 15 minutes.
+// @Tags         Achievement
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Param        attachmentId   path      string  true  "Attachment ID"
+// @Param        request body model.CreateAttachmentLinkRequest false "Link lifetime"
+// @Success      201  {object}  model.WebResponse[model.AttachmentLink]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/attachments/{attachmentId}/link [post]
+func (s *AchievementServiceImpl) CreateAttachmentLink(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+	val := ctx.Value("user")
+
+	AttachmentID, err := url.PathUnescape(c.Params("attachmentId"))
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	var request model.CreateAttachmentLinkRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&request); err != nil {
+			response := model.WebResponse[string]{
+				Status: "error",
+				Errors: err.Error(),
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(response)
+		}
+	}
+
+	if err := s.validate.Struct(request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+	if request.ExpiresInMinutes == 0 {
+		request.ExpiresInMinutes = defaultLinkMinutes
+	}
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s not found", id),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	Attachment, err := s.findAttachment(ctx, Achievement, AttachmentID)
+	if errors.Is(err, ErrAttachmentNotFound) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	expiresAt := time.Now().Add(time.Duration(request.ExpiresInMinutes) * time.Minute)
+	token := s.signer.Sign(model.AttachmentGrant{
+		AchievementID: Achievement.ID,
+		AttachmentID:  attachmentID(*Attachment),
+		ExpiresAt:     expiresAt,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(model.WebResponse[model.AttachmentLink]{
+		Status: "success",
+		Data: model.AttachmentLink{
+			URL:       sharedAttachmentURL + "/" + token,
+			ExpiresAt: expiresAt,
+		},
+	})
+}
+
+// SharedAttachment godoc
+// @Summary      Download a shared attachment
+// @Description  Download the attachment a signed link was created for. Needs no login; the link stops working when it expires or when the achievement is deleted. Like authenticated downloads, files that have not passed the malware scan are held back.
+// @Tags         Achievement
+// @Produce      octet-stream
+// @Param        token   path      string  true  "Signed link token"
+// @Success      200  {file}    file
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
+// @Failure      410  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Router       /shared/attachments/{token} [get]
+func (s *AchievementServiceImpl) SharedAttachment(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	grant, err := s.signer.Verify(c.Params("token"))
+	if errors.Is(err, ErrLinkExpired) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusGone).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, grant.AchievementID)
+	if errors.Is(err, sql.ErrNoRows) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s not found", grant.AchievementID),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	Attachment, err := s.findAttachment(ctx, Achievement, grant.AttachmentID)
+	if errors.Is(err, ErrAttachmentNotFound) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	return s.sendAttachment(c, Attachment, false)
+}
+
+// DeleteAttachment godoc
+// @Summary      Delete an attachment
+// @Description  Remove one attachment from an achievement and delete its file. Only possible while the achievement is a draft or needs revision.
+// @Tags         Achievement
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Param        attachmentId   path      string  true  "Attachment ID"
+// @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/attachments/{attachmentId} [delete]
+func (s *AchievementServiceImpl) DeleteAttachment(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+	val := ctx.Value("user")
+
+	AttachmentID, err := url.PathUnescape(c.Params("attachmentId"))
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s not found", id),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	// Verified and submitted files are what reviewers signed off on, so no
+	// role may change them
+	if !s.workflow.CanEdit(Achievement.Status) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s cannot be edited while %s", id, Achievement.Status),
+		}
+		return c.Status(fiber.StatusConflict).JSON(response)
+	}
+
+	AchievementObj, err := s.repoAchievement.FindById(ctx, Achievement.MongoAchievementID)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	index := indexAttachment(AchievementObj.Attachments, AttachmentID)
+	if index < 0 {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: ErrAttachmentNotFound.Error(),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	removed := AchievementObj.Attachments[index]
+
+	err = s.repoAchievement.RemoveAttachment(ctx, Achievement.MongoAchievementID, AttachmentID)
+	if errors.Is(err, ErrAttachmentNotFound) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	AchievementObj.Attachments = slices.Delete(AchievementObj.Attachments, index, index+1)
+
+	// The document no longer points at the file, so failing to delete it
+	// only leaves an unreferenced file behind
+	if err := removeAttachments(context.WithoutCancel(ctx), s.storage, []model.Attachment{removed}); err != nil {
+		s.Log.Warnf("remove deleted attachment %s of achievement %s: %v", AttachmentID, id, err)
+	}
+
+	Achievement.Detail = AchievementObj
+	linkDetail(Achievement)
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[*model.AchievementReferenceDetail]{
+		Status: "success",
+		Data:   Achievement,
+	})
+}
+
+// ReplaceAttachment godoc
+// @Summary      Replace an attachment
+// @Description  Swap the file of one attachment for a new upload, keeping its place in the list. The new file gets a new attachment ID and follows the same rules as uploads. Only possible while the achievement is a draft or needs revision.
+// @Tags         Achievement
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id   path      string  true  "Achievement ID"
+// @Param        attachmentId   path      string  true  "Attachment ID"
+// @Param        attachment formData file true "Replacement file"
+// @Success      200  {object}  model.WebResponse[model.AchievementReferenceDetail]
+// @Failure      400  {object}  model.WebResponse[[]model.AttachmentRejection]
+// @Failure      403  {object}  model.WebResponse[string]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      409  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /achievements/{id}/attachments/{attachmentId} [put]
+func (s *AchievementServiceImpl) ReplaceAttachment(c *fiber.Ctx) error {
+	id := c.Params("id")
+	ctx := c.UserContext()
+	val := ctx.Value("user")
+
+	AttachmentID, err := url.PathUnescape(c.Params("attachmentId"))
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	file, err := c.FormFile("attachment")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: "Tidak ada file yang diupload",
+		})
+	}
+
+	Achievement, err := s.repoAchivementReference.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s not found", id),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	if err := s.policy.Authorize(val.(*model.Claims), Achievement.Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	// Same rule as DeleteAttachment, for every role
+	if !s.workflow.CanEdit(Achievement.Status) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: fmt.Sprintf("Achievement %s cannot be edited while %s", id, Achievement.Status),
+		}
+		return c.Status(fiber.StatusConflict).JSON(response)
+	}
+
+	AchievementObj, err := s.repoAchievement.FindById(ctx, Achievement.MongoAchievementID)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	index := indexAttachment(AchievementObj.Attachments, AttachmentID)
+	if index < 0 {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: ErrAttachmentNotFound.Error(),
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	replaced := AchievementObj.Attachments[index]
+
+	used, err := s.attachmentUsage(ctx, Achievement.ID, AchievementObj)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	// The replaced file stops counting against the quota
+	uploads, err := s.uploads.Validate([]*multipart.FileHeader{file}, used-replaced.Size)
+	var uploadErr *UploadError
+	if errors.As(err, &uploadErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[[]model.AttachmentRejection]{
+			Status: "error",
+			Data:   uploadErr.Rejections,
+			Errors: err.Error(),
+		})
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	attachments, err := s.saveUploads(ctx, Achievement.ID, uploads)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	err = s.repoAchievement.ReplaceAttachment(ctx, Achievement.MongoAchievementID, AttachmentID, attachments[0])
+	if err != nil {
+		if cleanupErr := removeAttachments(context.WithoutCancel(ctx), s.storage, attachments); cleanupErr != nil {
+			s.Log.Warnf("remove upload of achievement %s after failed update: %v", id, cleanupErr)
+		}
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, ErrAttachmentNotFound) {
+			status = fiber.StatusNotFound
+		}
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(status).JSON(response)
+	}
+	AchievementObj.Attachments[index] = attachments[0]
+
+	if err := removeAttachments(context.WithoutCancel(ctx), s.storage, []model.Attachment{replaced}); err != nil {
+		s.Log.Warnf("remove replaced attachment %s of achievement %s: %v", AttachmentID, id, err)
+	}
+	s.scans.Enqueue(scanJobs(Achievement.MongoAchievementID, "", attachments)...)
+
+	Achievement.Detail = AchievementObj
+	linkDetail(Achievement)
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[*model.AchievementReferenceDetail]{
+		Status: "success",
+		Data:   Achievement,
+	})
+}
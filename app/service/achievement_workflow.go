@@ -0,0 +1,69 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"prisma/app/model"
+	"prisma/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AchievementWorkflow holds the status rules of an achievement reference.
+// Every status change made by AchievementService goes through Transition.
+type AchievementWorkflow interface {
+	Transition(from string, to string) error
+	CanEdit(status string) bool
+}
+
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot change achievement status from '%s' to '%s'", e.From, e.To)
+}
+
+type AchievementWorkflowImpl struct {
+	transitions map[string][]string
+	editable    map[string]bool
+}
+
+func NewAchievementWorkflow() AchievementWorkflow {
+	return &AchievementWorkflowImpl{
+		transitions: map[string][]string{
+			model.AchievementStatusDraft:     {model.AchievementStatusSubmitted},
+			model.AchievementStatusSubmitted: {model.AchievementStatusVerified, model.AchievementStatusRejected, model.AchievementStatusNeedsRevision},
+			model.AchievementStatusRejected:  {model.AchievementStatusDraft},
+
+			model.AchievementStatusNeedsRevision: {model.AchievementStatusSubmitted},
+		},
+		editable: map[string]bool{
+			model.AchievementStatusDraft:         true,
+			model.AchievementStatusNeedsRevision: true,
+		},
+	}
+}
+
+func (w *AchievementWorkflowImpl) Transition(from string, to string) error {
+	for _, next := range w.transitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+func (w *AchievementWorkflowImpl) CanEdit(status string) bool {
+	return w.editable[status]
+}
+
+// transitionStatusCode reports an illegal move as 409 and anything else as 500.
+func transitionStatusCode(err error) int {
+	var transitionErr *TransitionError
+	if errors.As(err, &transitionErr) || errors.Is(err, repository.ErrStatusChanged) {
+		return fiber.StatusConflict
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"prisma/app/model"
+	"prisma/app/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// AttachmentScanner checks uploaded files for malware in the background.
+// Attachments are saved as pending and only become downloadable once a scan
+// marks them clean; achievement attachments get their preview then.
+type AttachmentScanner interface {
+	// Enqueue schedules jobs without waiting for them.
+	Enqueue(jobs ...model.ScanJob)
+	Scan(ctx context.Context, job model.ScanJob) error
+	Start(ctx context.Context, workers int, retryInterval time.Duration)
+}
+
+type AttachmentScannerImpl struct {
+	scanner         repository.MalwareScanner
+	storage         repository.FileStorage
+	previews        PreviewGenerator
+	repoAchievement repository.AchievementRepository
+	repoComment     repository.AchievementCommentRepository
+	jobs            chan model.ScanJob
+	Log             *logrus.Logger
+}
+
+func NewAttachmentScanner(scanner repository.MalwareScanner, storage repository.FileStorage, previews PreviewGenerator, repoAchievement repository.AchievementRepository, repoComment repository.AchievementCommentRepository, queueSize int, Log *logrus.Logger) AttachmentScanner {
+	return &AttachmentScannerImpl{
+		scanner:         scanner,
+		storage:         storage,
+		previews:        previews,
+		repoAchievement: repoAchievement,
+		repoComment:     repoComment,
+		jobs:            make(chan model.ScanJob, queueSize),
+		Log:             Log,
+	}
+}
+
+// Enqueue drops jobs when the queue is full. They stay pending in the stores
+// and the next retry sweep queues them again.
+func (s *AttachmentScannerImpl) Enqueue(jobs ...model.ScanJob) {
+	for _, job := range jobs {
+		select {
+		case s.jobs <- job:
+		default:
+			s.Log.Warnf("scan: queue full, attachment %s waits for the next sweep", job.AttachmentID)
+		}
+	}
+}
+
+// Start runs workers until ctx is done. Every retryInterval it queues the
+// attachments that are still pending, which covers scans lost to a restart,
+// a full queue or an unreachable scanner.
+func (s *AttachmentScannerImpl) Start(ctx context.Context, workers int, retryInterval time.Duration) {
+	for i := 0; i < workers; i++ {
+		go s.work(ctx)
+	}
+
+	ticker := time.NewTicker(retryInterval)
+	defer ticker.Stop()
+
+	for {
+		s.requeue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *AttachmentScannerImpl) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-s.jobs:
+			if err := s.Scan(ctx, job); err != nil {
+				s.Log.Errorf("scan: attachment %s: %v", job.AttachmentID, err)
+			}
+		}
+	}
+}
+
+func (s *AttachmentScannerImpl) requeue(ctx context.Context) {
+	achievementJobs, err := s.repoAchievement.FindPendingScans(ctx)
+	if err != nil {
+		s.Log.Errorf("scan: find pending achievement attachments: %v", err)
+	}
+	commentJobs, err := s.repoComment.FindPendingScans(ctx)
+	if err != nil {
+		s.Log.Errorf("scan: find pending comment attachments: %v", err)
+	}
+	s.Enqueue(append(achievementJobs, commentJobs...)...)
+}
+
+// Scan scans the stored file of job and records the verdict. A file that is
+// gone belongs to an attachment that was deleted or replaced in the meantime.
+func (s *AttachmentScannerImpl) Scan(ctx context.Context, job model.ScanJob) error {
+	file, err := s.storage.Open(ctx, job.StorageKey)
+	if errors.Is(err, repository.ErrFileNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	result, err := s.scanner.Scan(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	status := model.ScanClean
+	if result.Infected {
+		status = model.ScanInfected
+		s.Log.Warnf("scan: attachment %s is infected with %s", job.AttachmentID, result.Signature)
+	}
+
+	if job.CommentID != "" {
+		return s.repoComment.SetAttachmentScanStatus(ctx, job.CommentID, job.AttachmentID, status)
+	}
+	// Previews are rendered only from files known to be clean
+	previewKey := ""
+	if status == model.ScanClean {
+		previewKey = s.savePreview(ctx, job)
+	}
+	return s.repoAchievement.SetAttachmentScanStatus(ctx, job.MongoAchievementID, job.AttachmentID, status, previewKey)
+}
+
+// savePreview stores a preview of the file of job next to it and returns the
+// preview's key. Attachments simply go without one when the type has no
+// preview or rendering fails.
+func (s *AttachmentScannerImpl) savePreview(ctx context.Context, job model.ScanJob) string {
+	file, err := s.storage.Open(ctx, job.StorageKey)
+	if err != nil {
+		s.Log.Warnf("preview of %s: %v", job.StorageKey, err)
+		return ""
+	}
+	defer file.Close()
+
+	preview, err := s.previews.Generate(ctx, file, job.ContentType)
+	if errors.Is(err, ErrNoPreview) {
+		return ""
+	}
+	if err != nil {
+		s.Log.Warnf("preview of %s: %v", job.StorageKey, err)
+		return ""
+	}
+
+	previewKey := job.StorageKey + previewSuffix
+	if err := s.storage.Save(ctx, previewKey, bytes.NewReader(preview), int64(len(preview)), "image/jpeg"); err != nil {
+		s.Log.Warnf("store preview of %s: %v", job.StorageKey, err)
+		return ""
+	}
+	return previewKey
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"prisma/app/model"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// maxFileNameLength caps sanitized file names, leaving room in storage keys
+// for the attachment id prefix.
+const maxFileNameLength = 100
+
+// UploadError lists every file of an upload that was rejected. The upload is
+// refused as a whole when it has any.
+type UploadError struct {
+	Rejections []model.AttachmentRejection
+}
+
+func (e *UploadError) Error() string {
+	return fmt.Sprintf("%d file(s) rejected", len(e.Rejections))
+}
+
+// CheckedUpload is a file that passed validation, with its sanitized name and
+// the content type detected from its bytes.
+type CheckedUpload struct {
+	File        *multipart.FileHeader
+	FileName    string
+	ContentType string
+}
+
+// AttachmentValidator checks uploads before anything is stored: the content
+// type is sniffed from the file itself rather than trusted from the client,
+// and sizes are held to a per-file and a per-achievement quota.
+type AttachmentValidator interface {
+	// Validate checks files against the rules. used is the size already
+	// taken by the achievement's attachments.
+	Validate(files []*multipart.FileHeader, used int64) ([]CheckedUpload, error)
+}
+
+type AttachmentValidatorImpl struct {
+	allowedTypes []string
+	maxFileSize  int64
+	maxTotalSize int64
+}
+
+func NewAttachmentValidator(allowedTypes []string, maxFileSize int64, maxTotalSize int64) AttachmentValidator {
+	return &AttachmentValidatorImpl{
+		allowedTypes: allowedTypes,
+		maxFileSize:  maxFileSize,
+		maxTotalSize: maxTotalSize,
+	}
+}
+
+func (v *AttachmentValidatorImpl) Validate(files []*multipart.FileHeader, used int64) ([]CheckedUpload, error) {
+	var checked []CheckedUpload
+	var rejections []model.AttachmentRejection
+	reject := func(file *multipart.FileHeader, format string, args ...any) {
+		rejections = append(rejections, model.AttachmentRejection{
+			FileName: file.Filename,
+			Error:    fmt.Sprintf(format, args...),
+		})
+	}
+
+	total := used
+	for _, file := range files {
+		if file.Size > v.maxFileSize {
+			reject(file, "file is %s, larger than the %s limit", formatSize(file.Size), formatSize(v.maxFileSize))
+			continue
+		}
+
+		contentType, err := sniffContentType(file)
+		if err != nil {
+			reject(file, "cannot read file: %v", err)
+			continue
+		}
+		if !slices.Contains(v.allowedTypes, contentType) {
+			reject(file, "file type %s is not allowed, expected one of %s", contentType, strings.Join(v.allowedTypes, ", "))
+			continue
+		}
+
+		total += file.Size
+		if total > v.maxTotalSize {
+			reject(file, "achievement attachments would exceed the %s quota", formatSize(v.maxTotalSize))
+			continue
+		}
+
+		checked = append(checked, CheckedUpload{
+			File:        file,
+			FileName:    SanitizeFileName(file.Filename),
+			ContentType: contentType,
+		})
+	}
+
+	if len(rejections) > 0 {
+		return nil, &UploadError{Rejections: rejections}
+	}
+	return checked, nil
+}
+
+// sniffContentType detects the type of file from its first 512 bytes.
+func sniffContentType(file *multipart.FileHeader) (string, error) {
+	content, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	return contentType, nil
+}
+
+// SanitizeFileName reduces a client supplied name to a safe base name: no
+// directories, no leading dots and only letters, digits, dots, dashes and
+// underscores.
+func SanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)), r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		case unicode.IsSpace(r):
+			b.WriteRune('_')
+		}
+	}
+
+	name = strings.TrimLeft(b.String(), ".")
+	if len(name) > maxFileNameLength {
+		ext := path.Ext(name)
+		if len(ext) > 10 {
+			ext = ""
+		}
+		name = name[:maxFileNameLength-len(ext)] + ext
+	}
+	if name == "" {
+		return "file"
+	}
+	return name
+}
+
+func formatSize(size int64) string {
+	if size >= 1<<20 {
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	}
+	return fmt.Sprintf("%d KB", size>>10)
+}
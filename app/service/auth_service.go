@@ -1,33 +1,47 @@
 package service
 
 import (
+	"errors"
 	"prisma/app/model"
 	"prisma/app/repository"
 	"prisma/utils"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 )
 
+// maxSessionLabel caps the client-supplied device and user agent stored with
+// a session.
+const maxSessionLabel = 255
+
 type AuthService interface {
 	Logout(c *fiber.Ctx) error
 	Login(c *fiber.Ctx) error
 	RefreshToken(c *fiber.Ctx) error
+	Sessions(c *fiber.Ctx) error
+	RevokeSession(c *fiber.Ctx) error
+	RevokeAllSessions(c *fiber.Ctx) error
+	RevokeUserSessions(c *fiber.Ctx) error
 }
 
-func NewAuthService(repo repository.UserRepository, logout repository.AuthRepository, Log *logrus.Logger, secret []byte) AuthService {
+func NewAuthService(repo repository.UserRepository, logout repository.AuthRepository, sessions repository.SessionRepository, Log *logrus.Logger, secret []byte) AuthService {
 	return &AuthServiceImpl{
-		repo:   repo,
-		Log:    Log,
-		Auth:   logout,
-		secret: secret,
+		repo:    repo,
+		Log:     Log,
+		Auth:    logout,
+		Session: sessions,
+		secret:  secret,
 	}
 }
 
 type AuthServiceImpl struct {
 	repo     repository.UserRepository
 	Auth     repository.AuthRepository
+	Session  repository.SessionRepository
 	validate *validator.Validate
 	Log      *logrus.Logger
 	secret   []byte
@@ -46,10 +60,15 @@ type AuthServiceImpl struct {
 func (s *AuthServiceImpl) Logout(c *fiber.Ctx) error {
 	refreshToken := c.Cookies("refresh_token")
 	ctx := c.UserContext()
-	err := s.Auth.Logout(ctx, refreshToken)
+	err := s.Auth.Logout(ctx, refreshToken, s.secret)
 	if err != nil {
 		return fiber.ErrInternalServerError
 	}
+	if claims, ok := ctx.Value("user").(*model.Claims); ok && claims.SessionID != "" {
+		if _, err := s.Session.Revoke(ctx, claims.UserID, claims.SessionID); err != nil {
+			return fiber.ErrInternalServerError
+		}
+	}
 
 	response := model.LogoutResponse{
 		Message: "Logged out",
@@ -72,6 +91,7 @@ func (s *AuthServiceImpl) Logout(c *fiber.Ctx) error {
 // @Success      200  {object}  model.WebResponse[model.LoginResponse]
 // @Failure      400  {object}  model.WebResponse[string]
 // @Failure      401  {object}  model.WebResponse[string]
+// @Failure      403  {object}  model.WebResponse[string]
 // @Failure      500  {object}  model.WebResponse[string]
 // @Router       /auth/login [post]
 func (s *AuthServiceImpl) Login(c *fiber.Ctx) error {
@@ -89,11 +109,42 @@ func (s *AuthServiceImpl) Login(c *fiber.Ctx) error {
 	if !utils.CheckPasswordHash(request.Password, User.PasswordHash) {
 		return fiber.ErrUnauthorized
 	}
-	access, refresh, err := utils.GenerateToken(User, s.secret)
+	// Checked after the password so that it does not reveal which accounts exist
+	if !User.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: "account is deactivated",
+		})
+	}
+	version, err := s.Auth.TokenVersion(ctx, User.ID)
+	if err != nil {
+		s.Log.Errorf("failed to read token version: %v", err)
+		return fiber.ErrInternalServerError
+	}
+	sessionID := uuid.NewString()
+	access, refresh, err := utils.GenerateToken(User, sessionID, version, s.secret)
 	if err != nil {
 
 		return fiber.ErrInternalServerError
 	}
+	if err := s.Auth.TrackRefreshToken(ctx, refresh); err != nil {
+		s.Log.Errorf("failed to track refresh token: %v", err)
+		return fiber.ErrInternalServerError
+	}
+	now := time.Now()
+	session := model.Session{
+		ID:         sessionID,
+		UserID:     User.ID,
+		Device:     truncate(request.Device, maxSessionLabel),
+		IP:         c.IP(),
+		UserAgent:  truncate(c.Get(fiber.HeaderUserAgent), maxSessionLabel),
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	if err := s.Session.Create(ctx, session, utils.RefreshTokenTTL); err != nil {
+		s.Log.Errorf("failed to create session: %v", err)
+		return fiber.ErrInternalServerError
+	}
 
 	AuthResponse := &model.UserAuthResponse{
 		ID:          User.ID,
@@ -117,7 +168,7 @@ func (s *AuthServiceImpl) Login(c *fiber.Ctx) error {
 
 // RefreshToken godoc
 // @Summary      Refresh Access Token
-// @Description  Get a new access token using a valid refresh token from cookies.
+// @Description  Get a new access token using a valid refresh token from cookies. The refresh token is rotated: the response carries a new one and the presented token stops working. Presenting a rotated token again revokes every token descended from the same login.
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -131,10 +182,25 @@ func (s *AuthServiceImpl) RefreshToken(c *fiber.Ctx) error {
 		return fiber.ErrUnauthorized
 	}
 	ctx := c.UserContext()
-	Access, err := s.Auth.RefreshToken(ctx, refreshToken, s.secret)
+	Access, Refresh, err := s.Auth.RefreshToken(ctx, refreshToken, s.secret)
+	if errors.Is(err, repository.ErrRefreshTokenReused) {
+		// A stolen token is in use somewhere: end the session it belongs to
+		if _, err := s.Session.Revoke(ctx, Claims.UserID, Claims.Family); err != nil {
+			s.Log.Errorf("failed to revoke session %s after token reuse: %v", Claims.Family, err)
+		}
+		return fiber.ErrUnauthorized
+	}
 	if err != nil {
 		return fiber.ErrUnauthorized
 	}
+	// A session that was revoked or has expired ends its refresh tokens too
+	active, err := s.Session.Touch(ctx, Claims.UserID, Claims.Family, utils.RefreshTokenTTL)
+	if err != nil {
+		s.Log.Errorf("failed to extend session %s: %v", Claims.Family, err)
+	}
+	if !active {
+		return fiber.ErrUnauthorized
+	}
 
 	AuthResponse := &model.UserAuthResponse{
 		ID:          Claims.UserID,
@@ -146,7 +212,7 @@ func (s *AuthServiceImpl) RefreshToken(c *fiber.Ctx) error {
 
 	response := &model.LoginResponse{
 		Token:        Access,
-		RefreshToken: refreshToken,
+		RefreshToken: Refresh,
 		User:         *AuthResponse,
 	}
 
@@ -156,3 +222,120 @@ func (s *AuthServiceImpl) RefreshToken(c *fiber.Ctx) error {
 	})
 
 }
+
+// Sessions godoc
+// @Summary      List My Sessions
+// @Description  List the active sessions of the logged in user, most recently used first. The session of the calling token is marked current.
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  model.WebResponse[[]model.Session]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /auth/sessions [get]
+func (s *AuthServiceImpl) Sessions(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := ctx.Value("user").(*model.Claims)
+
+	sessions, err := s.Session.FindByUser(ctx, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+
+	return c.JSON(model.WebResponse[[]model.Session]{
+		Status: "success",
+		Data:   sessions,
+	})
+}
+
+// RevokeSession godoc
+// @Summary      Revoke My Session
+// @Description  End one session of the logged in user. Its access and refresh tokens stop working immediately.
+// @Tags         Auth
+// @Produce      json
+// @Param        id   path      string  true  "Session ID"
+// @Success      200  {object}  model.WebResponse[model.RevokeSessionsResponse]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /auth/sessions/{id} [delete]
+func (s *AuthServiceImpl) RevokeSession(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := ctx.Value("user").(*model.Claims)
+
+	revoked, err := s.Session.Revoke(ctx, claims.UserID, c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	if !revoked {
+		return c.Status(fiber.StatusNotFound).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: "session not found",
+		})
+	}
+
+	return c.JSON(model.WebResponse[model.RevokeSessionsResponse]{
+		Status: "success",
+		Data:   model.RevokeSessionsResponse{Revoked: 1},
+	})
+}
+
+// RevokeAllSessions godoc
+// @Summary      Revoke All My Sessions
+// @Description  End every session of the logged in user, including the current one.
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  model.WebResponse[model.RevokeSessionsResponse]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /auth/sessions [delete]
+func (s *AuthServiceImpl) RevokeAllSessions(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := ctx.Value("user").(*model.Claims)
+
+	return s.revokeAll(c, claims.UserID)
+}
+
+// RevokeUserSessions godoc
+// @Summary      Revoke User Sessions
+// @Description  End every session of a user, e.g. when the account is compromised.
+// @Tags         Users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  model.WebResponse[model.RevokeSessionsResponse]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /users/{id}/sessions [delete]
+func (s *AuthServiceImpl) RevokeUserSessions(c *fiber.Ctx) error {
+	return s.revokeAll(c, c.Params("id"))
+}
+
+func (s *AuthServiceImpl) revokeAll(c *fiber.Ctx, UserID string) error {
+	revoked, err := s.Session.RevokeAll(c.UserContext(), UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+
+	return c.JSON(model.WebResponse[model.RevokeSessionsResponse]{
+		Status: "success",
+		Data:   model.RevokeSessionsResponse{Revoked: revoked},
+	})
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return strings.ToValidUTF8(value[:max], "")
+}
@@ -59,17 +59,20 @@ func (s *LecturerServiceImpl) FindByID(c *fiber.Ctx) error {
 
 // FindAll godoc
 // @Summary Get all lecturers
-// @Description Retrieve a list of all lecturers
+// @Description Retrieve a paginated list of lecturers
 // @Tags Lecturers
 // @Accept json
 // @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(10)
+// @Param cursor query string false "Cursor from paging.next_cursor; continues after that row instead of using page"
 // @Success 200 {object} model.SwaggerWebResponseUserProfiles "Successfully retrieved lecturers"
 // @Failure 400 {object} model.SwaggerWebResponseString "Bad request"
 // @Security BearerAuth
 // @Router /lecturers [get]
 func (s *LecturerServiceImpl) FindAll(c *fiber.Ctx) error {
 	ctx := c.UserContext()
-	lecturers, err := s.repoLecturer.FindAll(ctx)
+	Page, err := parsePageRequest(c)
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
@@ -77,10 +80,19 @@ func (s *LecturerServiceImpl) FindAll(c *fiber.Ctx) error {
 		}
 		return c.Status(fiber.StatusBadRequest).JSON(response)
 	}
+	lecturers, err := s.repoLecturer.FindAll(ctx, Page)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(listStatusCode(err, fiber.StatusBadRequest)).JSON(response)
+	}
 
 	response := model.WebResponse[[]model.UserProfile]{
 		Status: "success",
-		Data:   lecturers,
+		Data:   lecturers.Items,
+		Paging: model.NewPageMetaData(Page, lecturers),
 	}
 
 	return c.Status(fiber.StatusOK).JSON(response)
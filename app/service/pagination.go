@@ -0,0 +1,33 @@
+package service
+
+import (
+	"errors"
+	"prisma/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parsePageRequest reads page, limit and cursor from the query string of a
+// list endpoint.
+func parsePageRequest(c *fiber.Ctx) (model.PageRequest, error) {
+	page := model.NewPageRequest()
+	if err := c.QueryParser(&page); err != nil {
+		return page, err
+	}
+	if page.Page < 1 {
+		return page, errors.New("page must be at least 1")
+	}
+	if page.Limit < 1 || page.Limit > 100 {
+		return page, errors.New("limit must be between 1 and 100")
+	}
+	return page, nil
+}
+
+// listStatusCode reports a malformed cursor as 400 and other list errors with
+// the endpoint's usual status.
+func listStatusCode(err error, fallback int) int {
+	if errors.Is(err, model.ErrInvalidCursor) {
+		return fiber.StatusBadRequest
+	}
+	return fallback
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// maxPasswordBytes is the most bcrypt hashes; longer passwords are refused
+// rather than silently truncated.
+const maxPasswordBytes = 72
+
+// PasswordRules configures a PasswordPolicy.
+type PasswordRules struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+}
+
+// PasswordError lists every rule a password breaks.
+type PasswordError struct {
+	Problems []string
+}
+
+func (e *PasswordError) Error() string {
+	return "password " + strings.Join(e.Problems, ", ")
+}
+
+// PasswordPolicy decides whether a new password is strong enough. It is
+// applied wherever a password is set: user creation, change and reset.
+type PasswordPolicy interface {
+	Validate(password string) error
+}
+
+type PasswordPolicyImpl struct {
+	rules PasswordRules
+}
+
+func NewPasswordPolicy(rules PasswordRules) PasswordPolicy {
+	return &PasswordPolicyImpl{rules: rules}
+}
+
+func (p *PasswordPolicyImpl) Validate(password string) error {
+	var problems []string
+	if len([]rune(password)) < p.rules.MinLength {
+		problems = append(problems, fmt.Sprintf("must be at least %d characters", p.rules.MinLength))
+	}
+	if len(password) > maxPasswordBytes {
+		problems = append(problems, fmt.Sprintf("must be at most %d bytes", maxPasswordBytes))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if p.rules.RequireUpper && !upper {
+		problems = append(problems, "must contain an uppercase letter")
+	}
+	if p.rules.RequireLower && !lower {
+		problems = append(problems, "must contain a lowercase letter")
+	}
+	if p.rules.RequireDigit && !digit {
+		problems = append(problems, "must contain a digit")
+	}
+	if p.rules.RequireSymbol && !symbol {
+		problems = append(problems, "must contain a symbol")
+	}
+
+	if len(problems) > 0 {
+		return &PasswordError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"prisma/app/model"
+	"prisma/app/repository"
+	"prisma/utils"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+// forgotPasswordMessage is the answer to every forgot-password request, so
+// that it does not reveal which emails have an account.
+const forgotPasswordMessage = "if the email belongs to an active account, a reset link has been sent to it"
+
+type PasswordService interface {
+	ChangePassword(c *fiber.Ctx) error
+	ForgotPassword(c *fiber.Ctx) error
+	ResetPassword(c *fiber.Ctx) error
+}
+
+// NewPasswordService builds the password endpoints. resetURL is the page of
+// the frontend that receives the reset token as its token query parameter.
+func NewPasswordService(repoUser repository.UserRepository, repoAuth repository.AuthRepository, repoSession repository.SessionRepository, policy PasswordPolicy, mailer repository.MailSender, resetURL string, resetTTL time.Duration, validate *validator.Validate, Log *logrus.Logger) PasswordService {
+	return &PasswordServiceImpl{
+		repoUser:    repoUser,
+		repoAuth:    repoAuth,
+		repoSession: repoSession,
+		policy:      policy,
+		mailer:      mailer,
+		resetURL:    resetURL,
+		resetTTL:    resetTTL,
+		validate:    validate,
+		Log:         Log,
+	}
+}
+
+type PasswordServiceImpl struct {
+	repoUser    repository.UserRepository
+	repoAuth    repository.AuthRepository
+	repoSession repository.SessionRepository
+	policy      PasswordPolicy
+	mailer      repository.MailSender
+	resetURL    string
+	resetTTL    time.Duration
+	validate    *validator.Validate
+	Log         *logrus.Logger
+}
+
+// ChangePassword godoc
+// @Summary      Change Password
+// @Description  Change the password of the logged in user. The current password is required. Every session of the user ends, so log in again with the new password.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body model.ChangePasswordRequest true "Current and new password"
+// @Success      200  {object}  model.WebResponse[string]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /auth/password [put]
+func (s *PasswordServiceImpl) ChangePassword(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := ctx.Value("user").(*model.Claims)
+
+	request := new(model.ChangePasswordRequest)
+	if err := c.BodyParser(request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	if err := s.validate.Struct(request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+
+	hash, err := s.repoUser.FindPasswordHash(ctx, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	if !utils.CheckPasswordHash(request.CurrentPassword, hash) {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: "current password is incorrect",
+		})
+	}
+	if request.NewPassword == request.CurrentPassword {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: "new password must differ from the current one",
+		})
+	}
+	if err := s.policy.Validate(request.NewPassword); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+
+	if err := s.setPassword(ctx, claims.UserID, request.NewPassword); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+
+	return c.JSON(model.WebResponse[string]{
+		Status: "success",
+		Data:   "password changed, log in again with the new password",
+	})
+}
+
+// ForgotPassword godoc
+// @Summary      Forgot Password
+// @Description  Email a single-use password reset link to an active account. The answer is the same, and as fast, whether or not the email has an account: the link is sent in the background.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body model.ForgotPasswordRequest true "Account email"
+// @Success      200  {object}  model.WebResponse[string]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Router       /auth/forgot-password [post]
+func (s *PasswordServiceImpl) ForgotPassword(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	request := new(model.ForgotPasswordRequest)
+	if err := c.BodyParser(request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	if err := s.validate.Struct(request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+
+	// Sent in the background, so that the time to answer does not tell
+	// whether the email has an account. Failures are logged only.
+	go func(ctx context.Context, email string) {
+		if err := s.sendResetLink(ctx, email); err != nil {
+			s.Log.Errorf("password reset for %s: %v", email, err)
+		}
+	}(context.WithoutCancel(ctx), request.Email)
+
+	return c.JSON(model.WebResponse[string]{
+		Status: "success",
+		Data:   forgotPasswordMessage,
+	})
+}
+
+// ResetPassword godoc
+// @Summary      Reset Password
+// @Description  Set a new password with a token from a reset link. The token works once, and every session of the user ends.
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request body model.ResetPasswordRequest true "Reset token and new password"
+// @Success      200  {object}  model.WebResponse[string]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Router       /auth/reset-password [post]
+func (s *PasswordServiceImpl) ResetPassword(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	request := new(model.ResetPasswordRequest)
+	if err := c.BodyParser(request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	if err := s.validate.Struct(request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	// Checked before the token is consumed, so a weak password does not
+	// use up the link
+	if err := s.policy.Validate(request.NewPassword); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+
+	UserID, err := s.repoAuth.ConsumePasswordReset(ctx, request.Token)
+	if errors.Is(err, repository.ErrResetTokenInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+
+	if err := s.setPassword(ctx, UserID, request.NewPassword); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+
+	return c.JSON(model.WebResponse[string]{
+		Status: "success",
+		Data:   "password reset, log in with the new password",
+	})
+}
+
+func (s *PasswordServiceImpl) sendResetLink(ctx context.Context, email string) error {
+	user, err := s.repoUser.FindByEmail(ctx, email)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		// Anyone can ask for any address; this is no failure of ours
+		s.Log.Debugf("password reset for %s: no account", email)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !user.IsActive {
+		s.Log.Debugf("password reset for %s: account is deactivated", email)
+		return nil
+	}
+
+	token, err := newResetToken()
+	if err != nil {
+		return err
+	}
+	if err := s.repoAuth.SavePasswordReset(ctx, token, user.ID, s.resetTTL); err != nil {
+		return err
+	}
+
+	link, err := url.Parse(s.resetURL)
+	if err != nil {
+		return err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	body := fmt.Sprintf("Halo %s,\n\nKami menerima permintaan untuk mengatur ulang password akun PRISMA kamu (%s). Buka tautan berikut dalam %d menit untuk membuat password baru:\n\n%s\n\nTautan hanya bisa dipakai sekali. Abaikan email ini jika kamu tidak memintanya.\n",
+		user.FullName, user.Username, int(s.resetTTL.Minutes()), link.String())
+	return s.mailer.Send(ctx, user.Email, "Reset password PRISMA", body)
+}
+
+// setPassword stores a new password and ends every session of the user. The
+// token version is bumped first so that no token outlives a saved change.
+func (s *PasswordServiceImpl) setPassword(ctx context.Context, UserID string, password string) error {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := s.repoAuth.BumpTokenVersion(ctx, UserID); err != nil {
+		return err
+	}
+	if err := s.repoUser.UpdatePassword(ctx, UserID, hash); err != nil {
+		return err
+	}
+	if _, err := s.repoSession.RevokeAll(ctx, UserID); err != nil {
+		s.Log.Errorf("failed to revoke sessions of user %s: %v", UserID, err)
+	}
+	return nil
+}
+
+func newResetToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"prisma/app/model"
+	"prisma/app/repository"
+	"strings"
+)
+
+// PointsEngine scores achievements against the versioned point rule table.
+// Award is called when an achievement is verified; Recalculate re-scores
+// already verified achievements with an older or newer rule version.
+type PointsEngine interface {
+	Score(rules []model.PointRule, achievement model.AchievementMongo) int
+	Award(ctx context.Context, mongoAchievementID string) error
+	Recalculate(ctx context.Context, ruleSet model.PointRuleSet, mongoAchievementIDs []string) (int, error)
+}
+
+type PointsEngineImpl struct {
+	repoRule        repository.PointRuleRepository
+	repoAchievement repository.AchievementRepository
+}
+
+func NewPointsEngine(repoRule repository.PointRuleRepository, repoAchievement repository.AchievementRepository) PointsEngine {
+	return &PointsEngineImpl{
+		repoRule:        repoRule,
+		repoAchievement: repoAchievement,
+	}
+}
+
+// Score returns the points of the most specific matching rule. A rule is more
+// specific the more of competition level, rank and medal type it pins down;
+// on a tie the rule listed first wins. No match scores zero.
+func (e *PointsEngineImpl) Score(rules []model.PointRule, achievement model.AchievementMongo) int {
+	best, bestSpecificity := 0, -1
+	for _, rule := range rules {
+		specificity, ok := matchRule(rule, achievement)
+		if ok && specificity > bestSpecificity {
+			best, bestSpecificity = rule.Points, specificity
+		}
+	}
+	return best
+}
+
+func matchRule(rule model.PointRule, achievement model.AchievementMongo) (int, bool) {
+	if !strings.EqualFold(rule.AchievementType, achievement.AchievementType) {
+		return 0, false
+	}
+
+	specificity := 0
+	if rule.CompetitionLevel != "" {
+		if !strings.EqualFold(rule.CompetitionLevel, achievement.Details.CompetitionLevel) {
+			return 0, false
+		}
+		specificity++
+	}
+	if rule.Rank != 0 {
+		if rule.Rank != achievement.Details.Rank {
+			return 0, false
+		}
+		specificity++
+	}
+	if rule.MedalType != "" {
+		if !strings.EqualFold(rule.MedalType, achievement.Details.MedalType) {
+			return 0, false
+		}
+		specificity++
+	}
+	return specificity, true
+}
+
+func (e *PointsEngineImpl) Award(ctx context.Context, mongoAchievementID string) error {
+	ruleSet, err := e.repoRule.FindLatest(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	achievement, err := e.repoAchievement.FindById(ctx, mongoAchievementID)
+	if err != nil {
+		return err
+	}
+
+	return e.repoAchievement.UpdatePoints(ctx, mongoAchievementID, e.Score(ruleSet.Rules, *achievement), ruleSet.Version)
+}
+
+func (e *PointsEngineImpl) Recalculate(ctx context.Context, ruleSet model.PointRuleSet, mongoAchievementIDs []string) (int, error) {
+	if len(mongoAchievementIDs) == 0 {
+		return 0, nil
+	}
+
+	achievements, err := e.repoAchievement.FindAll(ctx, mongoAchievementIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, achievement := range achievements {
+		points := e.Score(ruleSet.Rules, achievement)
+		if err := e.repoAchievement.UpdatePoints(ctx, achievement.ID.Hex(), points, ruleSet.Version); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
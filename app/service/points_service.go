@@ -0,0 +1,180 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"prisma/app/model"
+	"prisma/app/repository"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type PointsService interface {
+	FindRuleSets(c *fiber.Ctx) error
+	CreateRuleSet(c *fiber.Ctx) error
+	Recalculate(c *fiber.Ctx) error
+}
+
+type PointsServiceImpl struct {
+	repoRule repository.PointRuleRepository
+	repoRef  repository.AchievementReferenceRepository
+	engine   PointsEngine
+	validate *validator.Validate
+	Log      *logrus.Logger
+}
+
+func NewPointsService(repoRule repository.PointRuleRepository, repoRef repository.AchievementReferenceRepository, engine PointsEngine, validate *validator.Validate, Log *logrus.Logger) PointsService {
+	return &PointsServiceImpl{
+		repoRule: repoRule,
+		repoRef:  repoRef,
+		engine:   engine,
+		validate: validate,
+		Log:      Log,
+	}
+}
+
+// FindRuleSets godoc
+// @Summary      List point rule versions
+// @Description  Retrieve every version of the point rule table, newest first. The first entry is the one applied to newly verified achievements.
+// @Tags         Points
+// @Produce      json
+// @Success      200  {object}  model.WebResponse[[]model.PointRuleSet]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /points/rules [get]
+func (s *PointsServiceImpl) FindRuleSets(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	RuleSets, err := s.repoRule.FindAll(ctx)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[[]model.PointRuleSet]{
+		Status: "success",
+		Data:   RuleSets,
+	})
+}
+
+// CreateRuleSet godoc
+// @Summary      Publish a new point rule version
+// @Description  Save the full rule table as a new version. Achievements verified from now on are scored with it; existing scores only change through recalculation.
+// @Tags         Points
+// @Accept       json
+// @Produce      json
+// @Param        request body model.CreatePointRuleSetRequest true "Point rules"
+// @Success      201  {object}  model.WebResponse[model.PointRuleSet]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /points/rules [post]
+func (s *PointsServiceImpl) CreateRuleSet(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	claims := ctx.Value("user").(*model.Claims)
+
+	var request model.CreatePointRuleSetRequest
+	if err := c.BodyParser(&request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	if err := s.validate.Struct(request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	RuleSet, err := s.repoRule.Create(ctx, model.PointRuleSet{
+		Rules:     request.Rules,
+		CreatedBy: &claims.UserID,
+	})
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(model.WebResponse[*model.PointRuleSet]{
+		Status: "success",
+		Data:   RuleSet,
+	})
+}
+
+// Recalculate godoc
+// @Summary      Recalculate points with a rule version
+// @Description  Re-score every verified achievement with the given rule version.
+// @Tags         Points
+// @Produce      json
+// @Param        version path int true "Rule version"
+// @Success      200  {object}  model.WebResponse[model.RecalculatePointsResult]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      404  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /points/rules/{version}/recalculate [post]
+func (s *PointsServiceImpl) Recalculate(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	version, err := c.ParamsInt("version")
+	if err != nil || version < 1 {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: "version must be a positive number",
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	RuleSet, err := s.repoRule.FindByVersion(ctx, version)
+	if errors.Is(err, sql.ErrNoRows) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: "point rule version not found",
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	ids, err := s.repoRef.FindMongoIDsByStatus(ctx, model.AchievementStatusVerified)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	updated, err := s.engine.Recalculate(ctx, *RuleSet, ids)
+	if err != nil {
+		s.Log.Errorf("recalculate points with version %d stopped after %d achievements: %v", version, updated, err)
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[model.RecalculatePointsResult]{
+		Status: "success",
+		Data: model.RecalculatePointsResult{
+			Version: RuleSet.Version,
+			Updated: updated,
+		},
+	})
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/image/draw"
+)
+
+// maxPreviewPixels refuses to decode images large enough to exhaust memory,
+// whatever their file size.
+const maxPreviewPixels = 50_000_000
+
+var ErrNoPreview = errors.New("no preview for this file type")
+
+// PreviewGenerator renders a small JPEG of an attachment so reviewers can see
+// it without downloading: the image itself scaled down, or the first page of
+// a PDF.
+type PreviewGenerator interface {
+	// Generate returns ErrNoPreview for content types it cannot render.
+	Generate(ctx context.Context, content io.Reader, contentType string) ([]byte, error)
+}
+
+type PreviewGeneratorImpl struct {
+	maxSize     int
+	pdfRenderer string
+	timeout     time.Duration
+	Log         *logrus.Logger
+}
+
+// NewPreviewGenerator fits previews within maxSize pixels on either side.
+// pdfRenderer is the path of poppler's pdftoppm; PDFs get no preview when it
+// is empty or not installed.
+func NewPreviewGenerator(maxSize int, pdfRenderer string, timeout time.Duration, Log *logrus.Logger) PreviewGenerator {
+	if pdfRenderer != "" {
+		path, err := exec.LookPath(pdfRenderer)
+		if err != nil {
+			Log.Warnf("PDF previews disabled: %v", err)
+		}
+		pdfRenderer = path
+	}
+	return &PreviewGeneratorImpl{
+		maxSize:     maxSize,
+		pdfRenderer: pdfRenderer,
+		timeout:     timeout,
+		Log:         Log,
+	}
+}
+
+func (g *PreviewGeneratorImpl) Generate(ctx context.Context, content io.Reader, contentType string) ([]byte, error) {
+	switch contentType {
+	case "image/png", "image/jpeg":
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return nil, err
+		}
+		return g.thumbnail(data)
+	case "application/pdf":
+		if g.pdfRenderer == "" {
+			return nil, ErrNoPreview
+		}
+		page, err := g.renderFirstPage(ctx, content)
+		if err != nil {
+			return nil, err
+		}
+		return g.thumbnail(page)
+	default:
+		return nil, ErrNoPreview
+	}
+}
+
+// thumbnail scales an encoded image down to fit maxSize and re-encodes it as
+// JPEG on a white background, so transparent PNGs stay readable.
+func (g *PreviewGeneratorImpl) thumbnail(data []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > maxPreviewPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large to preview", config.Width, config.Height)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := fitWithin(src.Bounds().Dx(), src.Bounds().Dy(), g.maxSize)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Over, nil)
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// renderFirstPage runs pdftoppm on content and returns page one as PNG.
+func (g *PreviewGeneratorImpl) renderFirstPage(ctx context.Context, content io.Reader) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "preview-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "input.pdf")
+	file, err := os.Create(input)
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(file, content)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	output := filepath.Join(dir, "page")
+	cmd := exec.CommandContext(ctx, g.pdfRenderer, "-f", "1", "-l", "1", "-singlefile", "-png",
+		"-scale-to", fmt.Sprint(g.maxSize), input, output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdftoppm: %v: %s", err, bytes.TrimSpace(out))
+	}
+	return os.ReadFile(output + ".png")
+}
+
+// fitWithin scales width and height down to at most maxSize on the longer
+// side. Smaller images keep their size.
+func fitWithin(width int, height int, maxSize int) (int, int) {
+	if width <= maxSize && height <= maxSize {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, max(1, height*maxSize/width)
+	}
+	return max(1, width*maxSize/height), maxSize
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"prisma/app/model"
+	"prisma/app/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const purgeBatchSize = 100
+
+// Purger permanently removes achievements that have been in the trash longer
+// than the retention period: the Postgres row with its history and comments,
+// the Mongo document and the uploaded files.
+type Purger interface {
+	Purge(ctx context.Context, deletedBefore time.Time) (int, error)
+	Start(ctx context.Context, retention time.Duration, interval time.Duration)
+}
+
+type PurgerImpl struct {
+	repoAchievement repository.AchievementRepository
+	repoRef         repository.AchievementReferenceRepository
+	repoComment     repository.AchievementCommentRepository
+	storage         repository.FileStorage
+	Log             *logrus.Logger
+}
+
+func NewPurger(repoAchievement repository.AchievementRepository, repoRef repository.AchievementReferenceRepository, repoComment repository.AchievementCommentRepository, storage repository.FileStorage, Log *logrus.Logger) Purger {
+	return &PurgerImpl{
+		repoAchievement: repoAchievement,
+		repoRef:         repoRef,
+		repoComment:     repoComment,
+		storage:         storage,
+		Log:             Log,
+	}
+}
+
+// Start purges once and then every interval until ctx is done.
+func (p *PurgerImpl) Start(ctx context.Context, retention time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.Purge(ctx, time.Now().Add(-retention))
+		if err != nil {
+			p.Log.Errorf("purge: stopped after %d achievements: %v", purged, err)
+		} else if purged > 0 {
+			p.Log.Infof("purge: removed %d achievements", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge removes every achievement deleted before deletedBefore and returns
+// how many were removed. It stops at the first failure so the next run picks
+// the same achievement up again.
+func (p *PurgerImpl) Purge(ctx context.Context, deletedBefore time.Time) (int, error) {
+	purged := 0
+	for {
+		links, err := p.repoRef.FindDeletedBefore(ctx, deletedBefore, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		if len(links) == 0 {
+			return purged, nil
+		}
+		for _, link := range links {
+			if err := p.purgeOne(ctx, link); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+	}
+}
+
+func (p *PurgerImpl) purgeOne(ctx context.Context, link model.ReferenceLink) error {
+	doc, err := p.repoAchievement.FindById(ctx, link.MongoAchievementID)
+	if err != nil && !errors.Is(err, repository.ErrAchievementNotFound) {
+		return err
+	}
+	// Comments go with the reference row, so their files are collected first.
+	comments, err := p.repoComment.FindByAchievementID(ctx, link.ID)
+	if err != nil {
+		return err
+	}
+	var attachments []model.Attachment
+	if doc != nil {
+		attachments = append(attachments, doc.Attachments...)
+	}
+	for _, comment := range comments {
+		attachments = append(attachments, comment.Attachments...)
+	}
+
+	saga := NewSaga(p.Log)
+	if doc != nil {
+		saga.Step(SagaStep{
+			Name: "delete mongo achievement",
+			Action: func(ctx context.Context) error {
+				return p.repoAchievement.Delete(ctx, link.MongoAchievementID)
+			},
+			Compensate: func(ctx context.Context) error {
+				return p.repoAchievement.Replace(ctx, *doc)
+			},
+		})
+	}
+	saga.Step(SagaStep{
+		Name: "purge achievement reference",
+		Action: func(ctx context.Context) error {
+			return p.repoRef.Purge(ctx, link.ID)
+		},
+	})
+	if err := saga.Run(ctx); err != nil {
+		return err
+	}
+
+	// Files are removed last: a leftover file is harmless, a missing one
+	// behind a live record is not.
+	if err := removeAttachments(ctx, p.storage, attachments); err != nil {
+		p.Log.Warnf("purge: achievement %s removed but some uploads were not: %v", link.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"prisma/app/model"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type ReconcileService interface {
+	Reconcile(c *fiber.Ctx) error
+}
+
+type ReconcileServiceImpl struct {
+	reconciler Reconciler
+	validate   *validator.Validate
+	Log        *logrus.Logger
+}
+
+func NewReconcileService(reconciler Reconciler, validate *validator.Validate, Log *logrus.Logger) ReconcileService {
+	return &ReconcileServiceImpl{
+		reconciler: reconciler,
+		validate:   validate,
+		Log:        Log,
+	}
+}
+
+// Reconcile godoc
+// @Summary      Reconcile achievement stores
+// @Description  Scan Postgres references and Mongo documents for records without a counterpart. With repair=true, orphan Mongo documents are deleted and dangling references are marked broken.
+// @Tags         Achievement
+// @Produce      json
+// @Param        repair query bool false "Repair the mismatches found" default(false)
+// @Param        batch_size query int false "Records per batch (max 1000)" default(500)
+// @Success      200  {object}  model.WebResponse[model.ReconcileReport]
+// @Failure      400  {object}  model.WebResponse[string]
+// @Failure      500  {object}  model.WebResponse[string]
+// @Security     BearerAuth
+// @Router       /admin/reconcile [post]
+func (s *ReconcileServiceImpl) Reconcile(c *fiber.Ctx) error {
+	request := model.ReconcileRequest{BatchSize: 500}
+	if err := c.QueryParser(&request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+	if err := s.validate.Struct(request); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(response)
+	}
+
+	report, err := s.reconciler.Run(c.UserContext(), request)
+	if err != nil {
+		s.Log.Errorf("reconcile stopped after %d documents and %d references: %v", report.ScannedDocuments, report.ScannedReferences, err)
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[*model.ReconcileReport]{
+		Status: "success",
+		Data:   report,
+	})
+}
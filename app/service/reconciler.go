@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"prisma/app/model"
+	"prisma/app/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// reconcileGracePeriod keeps a scan away from documents whose create saga
+// may still be writing the reference row.
+const reconcileGracePeriod = 10 * time.Minute
+
+// Reconciler compares achievement_references with the student_achievements
+// collection in batches and optionally repairs what does not line up.
+type Reconciler interface {
+	Run(ctx context.Context, request model.ReconcileRequest) (*model.ReconcileReport, error)
+}
+
+type ReconcilerImpl struct {
+	repoAchievement repository.AchievementRepository
+	repoRef         repository.AchievementReferenceRepository
+	storage         repository.FileStorage
+	Log             *logrus.Logger
+}
+
+func NewReconciler(repoAchievement repository.AchievementRepository, repoRef repository.AchievementReferenceRepository, storage repository.FileStorage, Log *logrus.Logger) Reconciler {
+	return &ReconcilerImpl{
+		repoAchievement: repoAchievement,
+		repoRef:         repoRef,
+		storage:         storage,
+		Log:             Log,
+	}
+}
+
+func (r *ReconcilerImpl) Run(ctx context.Context, request model.ReconcileRequest) (*model.ReconcileReport, error) {
+	report := &model.ReconcileReport{
+		OrphanDocuments:    []string{},
+		DanglingReferences: []string{},
+		Repaired:           request.Repair,
+		StartedAt:          time.Now(),
+	}
+
+	if err := r.scanDocuments(ctx, request, report); err != nil {
+		return report, err
+	}
+	if err := r.scanReferences(ctx, request, report); err != nil {
+		return report, err
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// scanDocuments walks the collection by _id and looks up each batch in
+// Postgres to find documents no reference points to.
+func (r *ReconcilerImpl) scanDocuments(ctx context.Context, request model.ReconcileRequest, report *model.ReconcileReport) error {
+	createdBefore := report.StartedAt.Add(-reconcileGracePeriod)
+	after := ""
+	for {
+		ids, err := r.repoAchievement.FindIDsAfter(ctx, after, createdBefore, request.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		report.ScannedDocuments += len(ids)
+		after = ids[len(ids)-1]
+
+		referenced, err := r.repoRef.FindExistingMongoIDs(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, id := range missing(ids, referenced) {
+			report.OrphanDocuments = append(report.OrphanDocuments, id)
+			if !request.Repair {
+				continue
+			}
+			if err := r.deleteOrphan(ctx, id); err != nil {
+				return err
+			}
+			r.Log.Infof("reconcile: deleted orphan achievement document %s", id)
+		}
+	}
+}
+
+// deleteOrphan removes a document no reference points to together with its
+// uploads. An orphan has no reference row, so it has no comments either.
+func (r *ReconcilerImpl) deleteOrphan(ctx context.Context, id string) error {
+	doc, err := r.repoAchievement.FindById(ctx, id)
+	if errors.Is(err, repository.ErrAchievementNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.repoAchievement.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	// Files are removed last, like the purger does
+	if err := removeAttachments(ctx, r.storage, doc.Attachments); err != nil {
+		r.Log.Warnf("reconcile: orphan document %s removed but some uploads were not: %v", id, err)
+	}
+	return nil
+}
+
+// scanReferences walks achievement_references by id and looks up each batch
+// in Mongo to find references whose document is gone.
+func (r *ReconcilerImpl) scanReferences(ctx context.Context, request model.ReconcileRequest, report *model.ReconcileReport) error {
+	after := ""
+	for {
+		links, err := r.repoRef.FindLinks(ctx, after, request.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(links) == 0 {
+			return nil
+		}
+		report.ScannedReferences += len(links)
+		after = links[len(links)-1].ID
+
+		mongoIDs := make([]string, len(links))
+		for i, link := range links {
+			mongoIDs[i] = link.MongoAchievementID
+		}
+		existing, err := r.repoAchievement.FindExistingIDs(ctx, mongoIDs)
+		if err != nil {
+			return err
+		}
+		found := make(map[string]bool, len(existing))
+		for _, id := range existing {
+			found[id] = true
+		}
+
+		var dangling []string
+		for _, link := range links {
+			if !found[link.MongoAchievementID] {
+				dangling = append(dangling, link.ID)
+			}
+		}
+		report.DanglingReferences = append(report.DanglingReferences, dangling...)
+		if request.Repair && len(dangling) > 0 {
+			if err := r.repoRef.MarkBroken(ctx, dangling); err != nil {
+				return err
+			}
+			r.Log.Infof("reconcile: marked %d achievement references broken", len(dangling))
+		}
+	}
+}
+
+// missing returns the ids not present in found, keeping their order.
+func missing(ids []string, found []string) []string {
+	seen := make(map[string]bool, len(found))
+	for _, id := range found {
+		seen[id] = true
+	}
+	var result []string
+	for _, id := range ids {
+		if !seen[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SagaStep is one write of an operation spanning Postgres and Mongo, paired
+// with the write that undoes it. The last step of a saga needs no
+// Compensate: once it succeeds the whole operation has succeeded.
+type SagaStep struct {
+	Name       string
+	Action     func(ctx context.Context) error
+	Compensate func(ctx context.Context) error
+}
+
+// SagaError is returned when a step fails. Compensations lists the undo
+// actions that failed as well, which leave the stores out of sync until
+// reconciliation picks them up.
+type SagaError struct {
+	Step          string
+	Err           error
+	Compensations []error
+}
+
+func (e *SagaError) Error() string {
+	if len(e.Compensations) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (compensation failed: %v)", e.Err.Error(), errors.Join(e.Compensations...))
+}
+
+func (e *SagaError) Unwrap() error {
+	return e.Err
+}
+
+// Saga runs steps in order and, when one fails, compensates the steps that
+// already completed in reverse order. Put steps that cannot be undone, such
+// as a Postgres commit, last.
+type Saga struct {
+	Log   *logrus.Logger
+	steps []SagaStep
+}
+
+func NewSaga(Log *logrus.Logger) *Saga {
+	return &Saga{Log: Log}
+}
+
+func (s *Saga) Step(step SagaStep) *Saga {
+	s.steps = append(s.steps, step)
+	return s
+}
+
+func (s *Saga) Run(ctx context.Context) error {
+	for i, step := range s.steps {
+		if err := step.Action(ctx); err != nil {
+			return s.compensate(ctx, i, &SagaError{Step: step.Name, Err: err})
+		}
+	}
+	return nil
+}
+
+// compensate undoes the steps before failed. It runs detached from the
+// request context so that a client disconnect does not leave the first
+// store written and the second one not.
+func (s *Saga) compensate(ctx context.Context, failed int, sagaErr *SagaError) error {
+	ctx = context.WithoutCancel(ctx)
+	for i := failed - 1; i >= 0; i-- {
+		step := s.steps[i]
+		if step.Compensate == nil {
+			continue
+		}
+		if err := step.Compensate(ctx); err != nil {
+			s.Log.Errorf("saga: compensating %s after %s failed: %v", step.Name, sagaErr.Step, err)
+			sagaErr.Compensations = append(sagaErr.Compensations, fmt.Errorf("%s: %w", step.Name, err))
+		}
+	}
+	return sagaErr
+}
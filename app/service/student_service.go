@@ -2,6 +2,7 @@ package service
 
 import (
 	"database/sql"
+	"errors"
 	"prisma/app/model"
 	"prisma/app/repository"
 
@@ -13,33 +14,41 @@ type StudentService interface {
 	FindById(c *fiber.Ctx) error
 	FindAchievements(c *fiber.Ctx) error
 	ChangeAdvisor(c *fiber.Ctx) error
+	Points(c *fiber.Ctx) error
 }
 
 type StudentServiceImpl struct {
 	repoStudent     repository.StudentRepository
 	repoAchievement repository.AchievementReferenceRepository
+	repoAnalytics   repository.AnalyticsRepository
+	policy          AccessPolicy
 }
 
-func NewStudentService(repoStudent repository.StudentRepository, repoAchievement repository.AchievementReferenceRepository) StudentService {
+func NewStudentService(repoStudent repository.StudentRepository, repoAchievement repository.AchievementReferenceRepository, repoAnalytics repository.AnalyticsRepository, policy AccessPolicy) StudentService {
 	return &StudentServiceImpl{
 		repoStudent:     repoStudent,
 		repoAchievement: repoAchievement,
+		repoAnalytics:   repoAnalytics,
+		policy:          policy,
 	}
 }
 
 // FindAll godoc
 // @Summary Get all students
-// @Description Retrieve a list of all students profiles
+// @Description Retrieve a paginated list of students profiles
 // @Tags Students
 // @Accept json
 // @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(10)
+// @Param cursor query string false "Cursor from paging.next_cursor; continues after that row instead of using page"
 // @Success 200 {object} model.SwaggerWebResponseUserProfiles "Successfully retrieved students"
 // @Failure 400 {object} model.SwaggerWebResponseString "Bad request"
 // @Security BearerAuth
 // @Router /students [get]
 func (s *StudentServiceImpl) FindAll(c *fiber.Ctx) error {
 	ctx := c.UserContext()
-	Students, err := s.repoStudent.FindAll(ctx)
+	Page, err := parsePageRequest(c)
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
@@ -47,10 +56,19 @@ func (s *StudentServiceImpl) FindAll(c *fiber.Ctx) error {
 		}
 		return c.Status(fiber.StatusBadRequest).JSON(response)
 	}
+	Students, err := s.repoStudent.FindAll(ctx, Page)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(listStatusCode(err, fiber.StatusBadRequest)).JSON(response)
+	}
 
 	response := model.WebResponse[[]model.UserProfile]{
 		Status: "success",
-		Data:   Students,
+		Data:   Students.Items,
+		Paging: model.NewPageMetaData(Page, Students),
 	}
 	return c.Status(fiber.StatusOK).JSON(response)
 }
@@ -93,7 +111,8 @@ func (s *StudentServiceImpl) FindById(c *fiber.Ctx) error {
 // @Produce json
 // @Param id path string true "Student ID"
 // @Param page query int false "Page number" default(1)
-// @Param limit query int false "Page size" default(10)
+// @Param limit query int false "Page size (max 100)" default(10)
+// @Param cursor query string false "Cursor from paging.next_cursor; continues after that row instead of using page"
 // @Success 200 {object} model.SwaggerWebResponseAchievementReferenceAdmin "Successfully retrieved achievements"
 // @Failure 400 {object} model.SwaggerWebResponseString "Bad request"
 // @Security BearerAuth
@@ -101,9 +120,7 @@ func (s *StudentServiceImpl) FindById(c *fiber.Ctx) error {
 func (s *StudentServiceImpl) FindAchievements(c *fiber.Ctx) error {
 	ctx := c.UserContext()
 	id := c.Params("id")
-	Page := c.QueryInt("page", 1)
-	Limit := c.QueryInt("limit", 10)
-	Achievements, err := s.repoAchievement.FindByStudentId(ctx, id, Page, Limit)
+	Page, err := parsePageRequest(c)
 	if err != nil {
 		response := model.WebResponse[string]{
 			Status: "error",
@@ -111,9 +128,18 @@ func (s *StudentServiceImpl) FindAchievements(c *fiber.Ctx) error {
 		}
 		return c.Status(fiber.StatusBadRequest).JSON(response)
 	}
+	Achievements, err := s.repoAchievement.FindByStudentId(ctx, id, Page)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(listStatusCode(err, fiber.StatusBadRequest)).JSON(response)
+	}
 	response := model.WebResponse[[]model.AchievementReferenceAdmin]{
 		Status: "success",
-		Data:   Achievements,
+		Data:   Achievements.Items,
+		Paging: model.NewPageMetaData(Page, Achievements),
 	}
 	return c.Status(fiber.StatusOK).JSON(response)
 }
@@ -181,3 +207,68 @@ func (s *StudentServiceImpl) ChangeAdvisor(c *fiber.Ctx) error {
 	}
 	return c.Status(fiber.StatusOK).JSON(response)
 }
+
+// Points godoc
+// @Summary Get student points
+// @Description Retrieve the total points of a student's verified achievements, overall and per year
+// @Tags Students
+// @Accept json
+// @Produce json
+// @Param id path string true "Student ID"
+// @Success 200 {object} model.WebResponse[model.StudentPoints] "Successfully retrieved points"
+// @Failure 403 {object} model.SwaggerWebResponseString "Not the student or their advisor"
+// @Failure 404 {object} model.SwaggerWebResponseString "Student not found"
+// @Failure 500 {object} model.SwaggerWebResponseString "Internal server error"
+// @Security BearerAuth
+// @Router /students/{id}/points [get]
+func (s *StudentServiceImpl) Points(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	id := c.Params("id")
+
+	Owner, err := s.repoStudent.FindOwner(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: "student not found",
+		}
+		return c.Status(fiber.StatusNotFound).JSON(response)
+	}
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+	claims, _ := ctx.Value("user").(*model.Claims)
+	if err := s.policy.Authorize(claims, *Owner); err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusForbidden).JSON(response)
+	}
+
+	Periods, err := s.repoAnalytics.Points(ctx, id)
+	if err != nil {
+		response := model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(response)
+	}
+
+	Points := model.StudentPoints{
+		StudentID: id,
+		Periods:   Periods,
+	}
+	for _, period := range Periods {
+		Points.Total += period.Points
+	}
+
+	response := model.WebResponse[model.StudentPoints]{
+		Status: "success",
+		Data:   Points,
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
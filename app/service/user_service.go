@@ -1,7 +1,9 @@
 package service
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"prisma/app/model"
 	"prisma/app/repository"
 	"prisma/utils"
@@ -16,22 +18,29 @@ type UserService interface {
 	Update(c *fiber.Ctx) error
 	UpdateRole(c *fiber.Ctx) error
 	Delete(c *fiber.Ctx) error
+	Deactivate(c *fiber.Ctx) error
+	Reactivate(c *fiber.Ctx) error
 	FindById(c *fiber.Ctx) error
 	FindAll(c *fiber.Ctx) error
 	Profile(c *fiber.Ctx) error
 }
 
-func NewUserService(repoUser repository.UserRepository, repoStudent repository.StudentRepository, repoLecturer repository.LecturerRepository, DB *sql.DB, validate *validator.Validate, log *logrus.Logger) UserService {
-	return &UserServiceImpl{repoUser, repoStudent, repoLecturer, DB, validate, log}
+func NewUserService(repoUser repository.UserRepository, repoStudent repository.StudentRepository, repoLecturer repository.LecturerRepository, repoAuth repository.AuthRepository, repoSession repository.SessionRepository, passwords PasswordPolicy, DB *sql.DB, validate *validator.Validate, log *logrus.Logger) UserService {
+	return &UserServiceImpl{repoUser, repoStudent, repoLecturer, repoAuth, repoSession, passwords, DB, validate, log}
 }
 
 type UserServiceImpl struct {
 	repoUser     repository.UserRepository
 	repoStudent  repository.StudentRepository
 	repoLecturer repository.LecturerRepository
-	DB           *sql.DB
-	validate     *validator.Validate
-	Log          *logrus.Logger
+	// repoAuth bumps the token version so that changes of access take
+	// effect on tokens already issued
+	repoAuth    repository.AuthRepository
+	repoSession repository.SessionRepository
+	passwords   PasswordPolicy
+	DB          *sql.DB
+	validate    *validator.Validate
+	Log         *logrus.Logger
 }
 
 // UpdateRole godoc
@@ -185,6 +194,16 @@ func (s *UserServiceImpl) UpdateRole(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "data": "Role ID mismatch in processing"})
 	}
 
+	// Bumped before commit so that tokens already issued stop working, and
+	// again after it because a login or refresh in between still reads the
+	// old role and would stamp it with the first new version
+	if err := s.repoAuth.BumpTokenVersion(ctx, users.ID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"data":   err.Error(),
+		})
+	}
+
 	if err := tx.Commit(); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status": "error",
@@ -192,6 +211,13 @@ func (s *UserServiceImpl) UpdateRole(c *fiber.Ctx) error {
 		})
 	}
 
+	// The role is saved by now, so failures are logged rather than returned;
+	// revoking the sessions alone already stops the old tokens
+	if err := s.repoAuth.BumpTokenVersion(ctx, users.ID); err != nil {
+		s.Log.Errorf("failed to bump token version of user %s: %v", users.ID, err)
+	}
+	s.revokeSessions(ctx, users.ID)
+
 	response := model.WebResponse[interface{}]{
 		Status: "success",
 		Data:   UserData,
@@ -226,6 +252,11 @@ func (s *UserServiceImpl) Create(c *fiber.Ctx) error {
 			"message": err,
 		})
 	}
+	if err := s.passwords.Validate(request.Password); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	ctx := c.UserContext()
 	tx, err := s.DB.Begin()
 	if err != nil {
@@ -400,8 +431,8 @@ func (s *UserServiceImpl) Update(c *fiber.Ctx) error {
 }
 
 // Delete godoc
-// @Summary Delete user
-// @Description Delete a user by ID
+// @Summary Permanently delete user
+// @Description Erase a user for good. Prefer deactivating the account; this needs its own permission. The user's tokens and sessions stop working immediately.
 // @Tags Users
 // @Accept json
 // @Produce json
@@ -414,6 +445,18 @@ func (s *UserServiceImpl) Delete(c *fiber.Ctx) error {
 	UserId := c.Params("id")
 	ctx := c.UserContext()
 
+	if isSelf(c, UserId) {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: "cannot deactivate or delete your own account",
+		})
+	}
+	if err := s.repoAuth.BumpTokenVersion(ctx, UserId); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status": "error",
+			"error":  err.Error(),
+		})
+	}
 	err := s.repoUser.Delete(ctx, UserId)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -421,6 +464,7 @@ func (s *UserServiceImpl) Delete(c *fiber.Ctx) error {
 			"error":  err.Error(),
 		})
 	}
+	s.revokeSessions(ctx, UserId)
 
 	response := model.WebResponse[string]{
 		Status: "success",
@@ -429,6 +473,92 @@ func (s *UserServiceImpl) Delete(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(response)
 }
 
+// Deactivate godoc
+// @Summary Deactivate user
+// @Description Deactivate a user account. The user can no longer log in, and every session and token of the user is revoked.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} model.SwaggerWebResponseString "Successfully deactivated user"
+// @Failure 400 {object} model.SwaggerWebResponseString "Bad request - own account"
+// @Failure 404 {object} model.SwaggerWebResponseString "User not found"
+// @Failure 500 {object} model.SwaggerWebResponseString "Internal server error"
+// @Security BearerAuth
+// @Router /users/{id}/deactivate [post]
+func (s *UserServiceImpl) Deactivate(c *fiber.Ctx) error {
+	UserId := c.Params("id")
+	if isSelf(c, UserId) {
+		return c.Status(fiber.StatusBadRequest).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: "cannot deactivate or delete your own account",
+		})
+	}
+	return s.setActive(c, UserId, false, "user deactivated")
+}
+
+// Reactivate godoc
+// @Summary Reactivate user
+// @Description Allow a deactivated user to log in again.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} model.SwaggerWebResponseString "Successfully reactivated user"
+// @Failure 404 {object} model.SwaggerWebResponseString "User not found"
+// @Failure 500 {object} model.SwaggerWebResponseString "Internal server error"
+// @Security BearerAuth
+// @Router /users/{id}/reactivate [post]
+func (s *UserServiceImpl) Reactivate(c *fiber.Ctx) error {
+	return s.setActive(c, c.Params("id"), true, "user reactivated")
+}
+
+func (s *UserServiceImpl) setActive(c *fiber.Ctx, UserId string, active bool, message string) error {
+	ctx := c.UserContext()
+
+	// Bumped first so that no token outlives a deactivation that was saved.
+	// The flip side is that a call failing below still logs the user out.
+	if err := s.repoAuth.BumpTokenVersion(ctx, UserId); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	if err := s.repoUser.SetActive(ctx, UserId, active); err != nil {
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, repository.ErrUserNotFound) {
+			status = fiber.StatusNotFound
+		}
+		return c.Status(status).JSON(model.WebResponse[string]{
+			Status: "error",
+			Errors: err.Error(),
+		})
+	}
+	if !active {
+		s.revokeSessions(ctx, UserId)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(model.WebResponse[string]{
+		Status: "success",
+		Data:   message,
+	})
+}
+
+// isSelf reports whether UserId is the caller, who must not lock themselves
+// out.
+func isSelf(c *fiber.Ctx, UserId string) bool {
+	claims, ok := c.UserContext().Value("user").(*model.Claims)
+	return ok && claims.UserID == UserId
+}
+
+// revokeSessions cleans up the sessions of a user whose token version was
+// already bumped, so a failure here is logged rather than returned.
+func (s *UserServiceImpl) revokeSessions(ctx context.Context, UserId string) {
+	if _, err := s.repoSession.RevokeAll(ctx, UserId); err != nil {
+		s.Log.Errorf("failed to revoke sessions of user %s: %v", UserId, err)
+	}
+}
+
 // FindById godoc
 // @Summary Get user by ID
 // @Description Get user details by ID including role-specific profile
@@ -457,6 +587,7 @@ func (s *UserServiceImpl) FindById(c *fiber.Ctx) error {
 		Email:    Users.User.Email,
 		FullName: Users.User.FullName,
 		Role:     Users.User.RoleName,
+		IsActive: Users.User.IsActive,
 	}
 
 	if Users.StudentID.Valid {
@@ -484,10 +615,14 @@ func (s *UserServiceImpl) FindById(c *fiber.Ctx) error {
 
 // FindAll godoc
 // @Summary Get all users
-// @Description Get list of all users
+// @Description Get a paginated list of users
 // @Tags Users
 // @Accept json
 // @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Page size (max 100)" default(10)
+// @Param cursor query string false "Cursor from paging.next_cursor; continues after that row instead of using page"
+// @Param status query string false "Only active or inactive users; all users when omitted" Enums(active, inactive)
 // @Success 200 {object} model.SwaggerWebResponseUserResponses "Successfully retrieved users"
 // @Failure 500 {object} map[string]interface{} "Internal server error"
 // @Security BearerAuth
@@ -495,26 +630,40 @@ func (s *UserServiceImpl) FindById(c *fiber.Ctx) error {
 func (s *UserServiceImpl) FindAll(c *fiber.Ctx) error {
 
 	ctx := c.UserContext()
-	Users, err := s.repoUser.FindAll(ctx)
+	Page, err := parsePageRequest(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	filter := model.UserFilter{Status: c.Query("status"), PageRequest: Page}
+	if err := s.validate.Struct(filter); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	Users, err := s.repoUser.FindAll(ctx, filter)
+	if err != nil {
+		return c.Status(listStatusCode(err, fiber.StatusInternalServerError)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	var userResponses []model.UserResponse
-	for _, u := range *Users {
+	userResponses := []model.UserResponse{}
+	for _, u := range Users.Items {
 		userResponses = append(userResponses, model.UserResponse{
 			ID:       u.ID,
 			Email:    u.Email,
 			Username: u.Username,
 			FullName: u.FullName,
 			Role:     u.RoleName,
+			IsActive: u.IsActive,
 		})
 	}
 	response := model.WebResponse[[]model.UserResponse]{
 		Status: "success",
 		Data:   userResponses,
+		Paging: model.NewPageMetaData(Page, Users),
 	}
 	return c.Status(fiber.StatusOK).JSON(response)
 
@@ -550,6 +699,7 @@ func (s *UserServiceImpl) Profile(c *fiber.Ctx) error {
 		Email:    Users.User.Email,
 		FullName: Users.User.FullName,
 		Role:     Users.User.RoleName,
+		IsActive: Users.User.IsActive,
 	}
 
 	if Users.StudentID.Valid {
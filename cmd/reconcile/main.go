@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"prisma/app/model"
+	"prisma/app/repository"
+	"prisma/app/service"
+	"prisma/config"
+)
+
+// reconcile scans achievement_references and the student_achievements
+// collection for records without a counterpart and prints the report as JSON.
+//
+//	go run ./cmd/reconcile -repair -batch-size 500
+func main() {
+	repair := flag.Bool("repair", false, "delete orphan Mongo documents and mark dangling references broken")
+	batchSize := flag.Int("batch-size", 500, "records per batch (1-1000)")
+	flag.Parse()
+
+	viperConfig := config.NewViper()
+	log := config.NewLog(viperConfig)
+	postgres := config.PostgresConnect(viperConfig, log)
+	mongo := config.MongoConnect(viperConfig, log)
+	validate := config.NewValidator()
+
+	request := model.ReconcileRequest{Repair: *repair, BatchSize: *batchSize}
+	if err := validate.Struct(request); err != nil {
+		log.Fatalf("Invalid options: %v", err)
+	}
+
+	reconciler := service.NewReconciler(
+		repository.NewAchievementRepository(mongo, log),
+		repository.NewAchievementReferenceRepository(log, postgres),
+		config.NewFileStorage(viperConfig, log),
+		log,
+	)
+	report, err := reconciler.Run(context.Background(), request)
+	if err != nil {
+		log.Fatalf("Reconcile stopped after %d documents and %d references: %v", report.ScannedDocuments, report.ScannedReferences, err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+}
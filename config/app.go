@@ -1,11 +1,13 @@
 package config
 
 import (
+	"context"
 	"database/sql"
 	"prisma/app/repository"
 	"prisma/app/service"
 	"prisma/middleware"
 	"prisma/routes"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
@@ -33,29 +35,71 @@ func Bootstrap(config *BootstrapConfig) {
 	LecturerRepository := repository.NewLecturerRepositoryImpl(config.Log, config.Postgres)
 	AnalyticsRepository := repository.NewAnalyticsRepository(config.Log, config.MongoDB)
 	LogoutRepository := repository.NewLogoutRepository(config.Redis, config.Log)
+	SessionRepository := repository.NewSessionRepository(config.Redis, config.Log)
 	AchievementRepository := repository.NewAchievementRepository(config.MongoDB, config.Log)
 	AchievementRepositoryReference := repository.NewAchievementReferenceRepository(config.Log, config.Postgres)
+	AchievementHistoryRepository := repository.NewAchievementHistoryRepository(config.Log, config.Postgres)
+	AchievementCommentRepository := repository.NewAchievementCommentRepository(config.Log, config.Postgres)
+	PointRuleRepository := repository.NewPointRuleRepository(config.Log, config.Postgres)
+	FileStorage := NewFileStorage(config.Config, config.Log)
+	AttachmentValidator := NewAttachmentValidator(config.Config)
+	PreviewGenerator := NewPreviewGenerator(config.Config, config.Log)
+	AttachmentScanner := service.NewAttachmentScanner(NewMalwareScanner(config.Config, config.Log), FileStorage, PreviewGenerator, AchievementRepository, AchievementCommentRepository, 1000, config.Log)
 
 	secret := []byte(config.Config.GetString("app.jwt-secret"))
+	// Signed attachment links fall back to the JWT secret
+	signingSecret := []byte(config.Config.GetString("storage.signing-secret"))
+	if len(signingSecret) == 0 {
+		signingSecret = secret
+	}
+	URLSigner := service.NewURLSigner(signingSecret)
+	AccessPolicy := service.NewAccessPolicy()
+	PointsEngine := service.NewPointsEngine(PointRuleRepository, AchievementRepository)
 	//Setup Service
-	AchievementService := service.NewAchievementService(AchievementRepository, StudentRepository, AchievementRepositoryReference, config.Validate, config.Log)
-	AuthService := service.NewAuthService(UserRepository, LogoutRepository, config.Log, secret)
-	UserService := service.NewUserService(UserRepository, StudentRepository, LecturerRepository, config.Postgres, config.Validate, config.Log)
-	StudentService := service.NewStudentService(StudentRepository, AchievementRepositoryReference)
+	AchievementService := service.NewAchievementService(AchievementRepository, StudentRepository, AchievementRepositoryReference, AchievementHistoryRepository, AchievementCommentRepository, AccessPolicy, PointsEngine, FileStorage, AttachmentValidator, AttachmentScanner, URLSigner, config.Postgres, config.Validate, config.Log)
+	PasswordPolicy := NewPasswordPolicy(config.Config)
+	AuthService := service.NewAuthService(UserRepository, LogoutRepository, SessionRepository, config.Log, secret)
+	UserService := service.NewUserService(UserRepository, StudentRepository, LecturerRepository, LogoutRepository, SessionRepository, PasswordPolicy, config.Postgres, config.Validate, config.Log)
+	// Reset links point at the frontend page that asks for the new password
+	config.Config.SetDefault("password.reset-url", "http://localhost:3000/reset-password")
+	config.Config.SetDefault("password.reset-ttl-minutes", 30)
+	resetTTL := time.Duration(config.Config.GetInt("password.reset-ttl-minutes")) * time.Minute
+	PasswordService := service.NewPasswordService(UserRepository, LogoutRepository, SessionRepository, PasswordPolicy, NewMailSender(config.Config, config.Log), config.Config.GetString("password.reset-url"), resetTTL, config.Validate, config.Log)
+	StudentService := service.NewStudentService(StudentRepository, AchievementRepositoryReference, AnalyticsRepository, AccessPolicy)
 	LecturerService := service.NewLecturerService(LecturerRepository, StudentRepository)
 	AnalyticsService := service.NewAnalyticsService(AnalyticsRepository)
+	PointsService := service.NewPointsService(PointRuleRepository, AchievementRepositoryReference, PointsEngine, config.Validate, config.Log)
+	Reconciler := service.NewReconciler(AchievementRepository, AchievementRepositoryReference, FileStorage, config.Log)
+	ReconcileService := service.NewReconcileService(Reconciler, config.Validate, config.Log)
+	Purger := service.NewPurger(AchievementRepository, AchievementRepositoryReference, AchievementCommentRepository, FileStorage, config.Log)
 
 	RouteConfig := routes.RouteConfig{
 		App:                config.App,
 		UserService:        UserService,
 		AuthService:        AuthService,
+		PasswordService:    PasswordService,
 		AchievementService: AchievementService,
 		LecturerService:    LecturerService,
 		AnalyticsService:   AnalyticsService,
 		StudentService:     StudentService,
-		AuthMiddleware:     middleware.AuthRequired(secret),
+		PointsService:      PointsService,
+		ReconcileService:   ReconcileService,
+		AuthMiddleware:     middleware.AuthRequired(secret, SessionRepository, LogoutRepository),
 	}
 
 	RouteConfig.Setup()
 
+	// Deleted achievements stay in the trash for the retention period
+	config.Config.SetDefault("achievement.trash-retention-days", 30)
+	config.Config.SetDefault("achievement.purge-interval-minutes", 60)
+	retention := time.Duration(config.Config.GetInt("achievement.trash-retention-days")) * 24 * time.Hour
+	interval := time.Duration(config.Config.GetInt("achievement.purge-interval-minutes")) * time.Minute
+	go Purger.Start(context.Background(), retention, interval)
+
+	// Uploads stay pending until scanned; pending ones are retried
+	config.Config.SetDefault("scanner.workers", 2)
+	config.Config.SetDefault("scanner.retry-interval-minutes", 5)
+	retryInterval := time.Duration(config.Config.GetInt("scanner.retry-interval-minutes")) * time.Minute
+	go AttachmentScanner.Start(context.Background(), config.Config.GetInt("scanner.workers"), retryInterval)
+
 }
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"prisma/app/repository"
+	"prisma/app/service"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewPasswordPolicy reads the rules new passwords must follow from password.*.
+func NewPasswordPolicy(config *viper.Viper) service.PasswordPolicy {
+	config.SetDefault("password.min-length", 8)
+	config.SetDefault("password.require-upper", false)
+	config.SetDefault("password.require-lower", false)
+	config.SetDefault("password.require-digit", true)
+	config.SetDefault("password.require-symbol", false)
+	return service.NewPasswordPolicy(service.PasswordRules{
+		MinLength:     config.GetInt("password.min-length"),
+		RequireUpper:  config.GetBool("password.require-upper"),
+		RequireLower:  config.GetBool("password.require-lower"),
+		RequireDigit:  config.GetBool("password.require-digit"),
+		RequireSymbol: config.GetBool("password.require-symbol"),
+	})
+}
+
+// NewMailSender picks how mail is delivered from mail.driver: "smtp" or
+// "log" (default), which only writes it to the log.
+func NewMailSender(config *viper.Viper, logs *logrus.Logger) repository.MailSender {
+	switch driver := config.GetString("mail.driver"); driver {
+	case "smtp":
+		config.SetDefault("mail.smtp.port", 587)
+		logs.Infof("Sending mail through %s", config.GetString("mail.smtp.host"))
+		return repository.NewSMTPMailSender(
+			config.GetString("mail.smtp.host"),
+			config.GetInt("mail.smtp.port"),
+			config.GetString("mail.smtp.username"),
+			config.GetString("mail.smtp.password"),
+			config.GetString("mail.from"),
+		)
+	case "", "log":
+		logs.Warn("Mail is only logged, not sent; set mail.driver to smtp")
+		return repository.NewLogMailSender(logs)
+	default:
+		logs.Fatalf("Unknown mail driver %q", driver)
+		return nil
+	}
+}
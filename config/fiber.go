@@ -6,9 +6,12 @@ import (
 )
 
 func NewFiber(config *viper.Viper) *fiber.App {
+	// The body limit has to fit a full attachment upload
+	config.SetDefault("app.body-limit-mb", 32)
 	app := fiber.New(fiber.Config{AppName: config.GetString("app.name"),
 		ErrorHandler: ErrorHandler(),
-		Prefork:      config.GetBool("app.prefork")})
+		Prefork:      config.GetBool("app.prefork"),
+		BodyLimit:    config.GetInt("app.body-limit-mb") << 20})
 
 	return app
 }
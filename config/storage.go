@@ -0,0 +1,93 @@
+package config
+
+import (
+	"prisma/app/repository"
+	"prisma/app/service"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+// NewFileStorage picks the attachment storage backend from storage.driver:
+// "local" (default) or "s3".
+func NewFileStorage(config *viper.Viper, logs *logrus.Logger) repository.FileStorage {
+	switch driver := config.GetString("storage.driver"); driver {
+	case "s3":
+		storage, err := repository.NewS3FileStorage(
+			config.GetString("storage.s3.endpoint"),
+			config.GetString("storage.s3.access-key"),
+			config.GetString("storage.s3.secret-key"),
+			config.GetString("storage.s3.bucket"),
+			config.GetString("storage.s3.region"),
+			config.GetBool("storage.s3.use-ssl"),
+		)
+		if err != nil {
+			panic(err)
+		}
+		logs.Infof("Storing attachments in S3 bucket %s", config.GetString("storage.s3.bucket"))
+		return storage
+	case "", "local":
+		root := config.GetString("storage.local.root")
+		if root == "" {
+			root = "./public/uploads"
+		}
+		logs.Infof("Storing attachments in %s", root)
+		return repository.NewLocalFileStorage(root)
+	default:
+		logs.Fatalf("Unknown storage driver %q", driver)
+		return nil
+	}
+}
+
+// NewAttachmentValidator reads the upload rules from the attachment block.
+// Sizes are configured in megabytes.
+func NewAttachmentValidator(config *viper.Viper) service.AttachmentValidator {
+	config.SetDefault("attachment.allowed-types", []string{"application/pdf", "image/png", "image/jpeg"})
+	config.SetDefault("attachment.max-file-size-mb", 10)
+	config.SetDefault("attachment.max-total-size-mb", 25)
+
+	return service.NewAttachmentValidator(
+		config.GetStringSlice("attachment.allowed-types"),
+		config.GetInt64("attachment.max-file-size-mb")<<20,
+		config.GetInt64("attachment.max-total-size-mb")<<20,
+	)
+}
+
+// NewMalwareScanner picks the attachment scanner from scanner.driver:
+// "clamav" or "none" (default), which reports every file clean.
+func NewMalwareScanner(config *viper.Viper, logs *logrus.Logger) repository.MalwareScanner {
+	switch driver := config.GetString("scanner.driver"); driver {
+	case "clamav":
+		config.SetDefault("scanner.clamav.network", "tcp")
+		config.SetDefault("scanner.clamav.address", "localhost:3310")
+		config.SetDefault("scanner.timeout-seconds", 60)
+		logs.Infof("Scanning attachments with clamd at %s", config.GetString("scanner.clamav.address"))
+		return repository.NewClamAVScanner(
+			config.GetString("scanner.clamav.network"),
+			config.GetString("scanner.clamav.address"),
+			time.Duration(config.GetInt("scanner.timeout-seconds"))*time.Second,
+		)
+	case "", "none":
+		logs.Warn("Attachments are not scanned for malware; set scanner.driver to clamav")
+		return repository.NewNoopMalwareScanner()
+	default:
+		logs.Fatalf("Unknown scanner driver %q", driver)
+		return nil
+	}
+}
+
+// NewPreviewGenerator reads the preview settings. PDF previews need poppler's
+// pdftoppm at preview.pdftoppm.
+func NewPreviewGenerator(config *viper.Viper, logs *logrus.Logger) service.PreviewGenerator {
+	config.SetDefault("preview.max-size", 480)
+	config.SetDefault("preview.pdftoppm", "pdftoppm")
+	config.SetDefault("preview.timeout-seconds", 20)
+
+	return service.NewPreviewGenerator(
+		config.GetInt("preview.max-size"),
+		config.GetString("preview.pdftoppm"),
+		time.Duration(config.GetInt("preview.timeout-seconds"))*time.Second,
+		logs,
+	)
+}
@@ -0,0 +1,35 @@
+CREATE TABLE achievement_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_reference_id UUID NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    actor_id UUID,
+    note TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    -- Foreign keys
+    CONSTRAINT fk_achievement_reference
+        FOREIGN KEY (achievement_reference_id) REFERENCES achievement_references(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_actor
+        FOREIGN KEY (actor_id) REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_achievement_status_history_reference
+    ON achievement_status_history (achievement_reference_id, created_at);
+
+-- Backfill the log for existing achievements from the timestamps we already have
+INSERT INTO achievement_status_history (achievement_reference_id, from_status, to_status, created_at)
+SELECT id, NULL, 'draft', created_at
+FROM achievement_references;
+
+INSERT INTO achievement_status_history (achievement_reference_id, from_status, to_status, created_at)
+SELECT id, 'draft', 'submitted', submitted_at
+FROM achievement_references
+WHERE submitted_at IS NOT NULL;
+
+INSERT INTO achievement_status_history (achievement_reference_id, from_status, to_status, actor_id, note, created_at)
+SELECT id, 'submitted', status, verified_by, rejection_note, verified_at
+FROM achievement_references
+WHERE verified_at IS NOT NULL AND status IN ('verified', 'rejected');
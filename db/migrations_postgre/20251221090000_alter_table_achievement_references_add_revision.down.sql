@@ -0,0 +1,13 @@
+DELETE FROM permissions WHERE name = 'achievements:requestRevision';
+
+UPDATE achievement_references SET status = 'draft' WHERE status = 'needs_revision';
+
+ALTER TABLE achievement_references
+    DROP CONSTRAINT IF EXISTS achievement_references_status_check;
+
+ALTER TABLE achievement_references
+    DROP COLUMN IF EXISTS revision_round,
+    DROP COLUMN IF EXISTS revision_note,
+    ALTER COLUMN status TYPE VARCHAR(10),
+    ADD CONSTRAINT achievement_references_status_check
+        CHECK (status IN ('draft', 'submitted', 'verified', 'rejected'));
@@ -0,0 +1,17 @@
+ALTER TABLE achievement_references
+    DROP CONSTRAINT IF EXISTS achievement_references_status_check;
+
+ALTER TABLE achievement_references
+    ALTER COLUMN status TYPE VARCHAR(20),
+    ADD CONSTRAINT achievement_references_status_check
+        CHECK (status IN ('draft', 'submitted', 'verified', 'rejected', 'needs_revision')),
+    ADD COLUMN revision_note TEXT,
+    ADD COLUMN revision_round INT NOT NULL DEFAULT 1;
+
+INSERT INTO permissions (name, resource, action, description) VALUES
+('achievements:requestRevision', 'achievements', 'requestRevision', 'Dosen Wali meminta revisi achievement');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('lecturer', 'admin') AND p.name = 'achievements:requestRevision';
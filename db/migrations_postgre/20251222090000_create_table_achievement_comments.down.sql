@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'achievements:comment';
+
+DROP TABLE IF EXISTS achievement_comments;
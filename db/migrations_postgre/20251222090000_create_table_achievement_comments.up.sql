@@ -0,0 +1,27 @@
+CREATE TABLE achievement_comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    achievement_reference_id UUID NOT NULL,
+    author_id UUID,
+    body TEXT NOT NULL,
+    attachments JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    -- Foreign keys
+    CONSTRAINT fk_achievement_reference
+        FOREIGN KEY (achievement_reference_id) REFERENCES achievement_references(id)
+        ON DELETE CASCADE,
+    CONSTRAINT fk_author
+        FOREIGN KEY (author_id) REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+CREATE INDEX idx_achievement_comments_reference
+    ON achievement_comments (achievement_reference_id, created_at);
+
+INSERT INTO permissions (name, resource, action, description) VALUES
+('achievements:comment', 'achievements', 'comment', 'Diskusi achievement antara mahasiswa dan dosen wali');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name IN ('mahasiswa', 'lecturer', 'admin') AND p.name = 'achievements:comment';
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'points:manage';
+
+DROP TABLE IF EXISTS point_rule_sets;
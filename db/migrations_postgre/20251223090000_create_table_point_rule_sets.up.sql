@@ -0,0 +1,42 @@
+CREATE TABLE point_rule_sets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    version INT NOT NULL UNIQUE,
+    rules JSONB NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_created_by
+        FOREIGN KEY (created_by) REFERENCES users(id)
+        ON DELETE SET NULL
+);
+
+-- Versi awal aturan poin, versi berikutnya dibuat admin lewat API
+INSERT INTO point_rule_sets (version, rules) VALUES
+(1, '[
+    {"achievement_type": "competition", "competition_level": "international", "rank": 1, "points": 100},
+    {"achievement_type": "competition", "competition_level": "international", "rank": 2, "points": 90},
+    {"achievement_type": "competition", "competition_level": "international", "rank": 3, "points": 80},
+    {"achievement_type": "competition", "competition_level": "international", "points": 50},
+    {"achievement_type": "competition", "competition_level": "national", "rank": 1, "points": 75},
+    {"achievement_type": "competition", "competition_level": "national", "rank": 2, "points": 65},
+    {"achievement_type": "competition", "competition_level": "national", "rank": 3, "points": 55},
+    {"achievement_type": "competition", "competition_level": "national", "points": 30},
+    {"achievement_type": "competition", "competition_level": "regional", "rank": 1, "points": 40},
+    {"achievement_type": "competition", "competition_level": "regional", "points": 20},
+    {"achievement_type": "competition", "competition_level": "local", "rank": 1, "points": 20},
+    {"achievement_type": "competition", "competition_level": "local", "points": 10},
+    {"achievement_type": "competition", "points": 5},
+    {"achievement_type": "publication", "points": 40},
+    {"achievement_type": "certification", "points": 20},
+    {"achievement_type": "organization", "points": 15},
+    {"achievement_type": "academic", "points": 15},
+    {"achievement_type": "other", "points": 5}
+]');
+
+INSERT INTO permissions (name, resource, action, description) VALUES
+('points:manage', 'points', 'manage', 'Kelola aturan poin dan hitung ulang poin achievement');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'points:manage';
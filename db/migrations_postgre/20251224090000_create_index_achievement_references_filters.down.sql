@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_achievement_references_mongo_id;
+
+DROP INDEX IF EXISTS idx_achievement_references_status_created;
@@ -0,0 +1,5 @@
+CREATE INDEX idx_achievement_references_status_created
+    ON achievement_references (status, created_at DESC);
+
+CREATE INDEX idx_achievement_references_mongo_id
+    ON achievement_references (mongo_achievement_id);
@@ -0,0 +1,3 @@
+DELETE FROM permissions WHERE name = 'achievements:reconcile';
+
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS broken_at;
@@ -0,0 +1,10 @@
+-- Diisi job reconciliation saat dokumen Mongo yang dirujuk sudah tidak ada
+ALTER TABLE achievement_references ADD COLUMN broken_at TIMESTAMP;
+
+INSERT INTO permissions (name, resource, action, description) VALUES
+('achievements:reconcile', 'achievements', 'reconcile', 'Cek dan perbaiki selisih data achievement antara PostgreSQL dan MongoDB');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'achievements:reconcile';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_achievement_references_deleted_at;
+
+ALTER TABLE achievement_references DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,5 @@
+-- Soft delete: baris tetap ada sampai dipurge setelah masa retensi
+ALTER TABLE achievement_references ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX idx_achievement_references_deleted_at ON achievement_references (deleted_at)
+    WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,7 @@
+INSERT INTO permissions (name, resource, action, description) VALUES
+('users:revokeSessions', 'users', 'revokeSessions', 'Mengakhiri semua sesi login pengguna');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'users:revokeSessions';
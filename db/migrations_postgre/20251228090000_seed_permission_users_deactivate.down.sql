@@ -0,0 +1,5 @@
+DELETE FROM permissions WHERE name = 'users:deactivate';
+
+UPDATE permissions
+SET name = 'users:delete', action = 'delete', description = 'Menghapus pengguna'
+WHERE name = 'users:hardDelete';
@@ -0,0 +1,12 @@
+-- Menghapus permanen sekarang izin tersendiri; role yang punya users:delete tetap memilikinya
+UPDATE permissions
+SET name = 'users:hardDelete', action = 'hardDelete', description = 'Menghapus pengguna secara permanen'
+WHERE name = 'users:delete';
+
+INSERT INTO permissions (name, resource, action, description) VALUES
+('users:deactivate', 'users', 'deactivate', 'Menonaktifkan dan mengaktifkan kembali pengguna');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM roles r, permissions p
+WHERE r.name = 'admin' AND p.name = 'users:deactivate';
@@ -0,0 +1,2 @@
+-- Hasil scan tidak dibuang lagi; lampiran lama tetap memakai status yang sudah didapat
+SELECT 1;
@@ -0,0 +1,15 @@
+-- Lampiran komentar dari sebelum ada scan malware belum punya scan_status (dan
+-- sebagian belum punya id serta storage_key); tandai pending agar ikut di-scan
+UPDATE achievement_comments SET attachments = (
+    SELECT jsonb_agg(CASE
+        WHEN COALESCE(a->>'scan_status', '') <> '' THEN a
+        ELSE a || jsonb_build_object(
+            'id', COALESCE(NULLIF(a->>'id', ''), regexp_replace(COALESCE(NULLIF(a->>'storage_key', ''), regexp_replace(a->>'file_url', '^/uploads/', '')), '^.*/', '')),
+            'storage_key', COALESCE(NULLIF(a->>'storage_key', ''), regexp_replace(a->>'file_url', '^/uploads/', '')),
+            'scan_status', 'pending')
+        END)
+    FROM jsonb_array_elements(attachments) AS a)
+WHERE EXISTS (
+    SELECT 1 FROM jsonb_array_elements(attachments) AS a
+    WHERE COALESCE(a->>'scan_status', '') = ''
+);
@@ -3,13 +3,14 @@ package middleware
 import (
 	"context"
 	"prisma/app/model"
+	"prisma/app/repository"
 	"prisma/utils"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func AuthRequired(JWTsecret []byte) fiber.Handler {
+func AuthRequired(JWTsecret []byte, sessions repository.SessionRepository, tokens repository.AuthRepository) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
@@ -26,12 +27,43 @@ func AuthRequired(JWTsecret []byte) fiber.Handler {
 		}
 
 		claims, err := utils.ValidateToken(tokenParts[1], JWTsecret)
-		if err != nil {
+		// Refresh tokens carry a family and must not be usable as access tokens
+		if err != nil || claims.Family != "" {
 			return c.Status(401).JSON(fiber.Map{
 				"error": "Token akses tidak valid",
 			})
 		}
 
+		// Tokens without a session cannot be revoked and are not accepted
+		if claims.SessionID == "" {
+			return c.Status(401).JSON(fiber.Map{
+				"error": "Token akses tidak valid",
+			})
+		}
+		active, err := sessions.Touch(c.UserContext(), claims.UserID, claims.SessionID, 0)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Sesi tidak dapat diperiksa",
+			})
+		}
+		if !active {
+			return c.Status(401).JSON(fiber.Map{
+				"error": "Sesi sudah berakhir",
+			})
+		}
+		// Role, permission, activity or password changes bump the version
+		version, err := tokens.TokenVersion(c.UserContext(), claims.UserID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "Sesi tidak dapat diperiksa",
+			})
+		}
+		if claims.TokenVersion != version {
+			return c.Status(401).JSON(fiber.Map{
+				"error": "Hak akses berubah, silakan login ulang",
+			})
+		}
+
 		ctx := context.WithValue(c.UserContext(), "user", claims)
 		c.SetUserContext(ctx)
 
@@ -11,11 +11,14 @@ import (
 type RouteConfig struct {
 	App                *fiber.App
 	AuthService        service.AuthService
+	PasswordService    service.PasswordService
 	UserService        service.UserService
 	AchievementService service.AchievementService
 	StudentService     service.StudentService
 	LecturerService    service.LecturerService
 	AnalyticsService   service.AnalyticsService
+	PointsService      service.PointsService
+	ReconcileService   service.ReconcileService
 	AuthMiddleware     fiber.Handler
 }
 
@@ -27,12 +30,19 @@ func (c *RouteConfig) Setup() {
 func (c *RouteConfig) SetupGuestRoute() {
 	c.App.Post("/api/v1/auth/login", c.AuthService.Login)
 	c.App.Post("/api/v1/auth/refresh", c.AuthService.RefreshToken)
+	c.App.Post("/api/v1/auth/forgot-password", c.PasswordService.ForgotPassword)
+	c.App.Post("/api/v1/auth/reset-password", c.PasswordService.ResetPassword)
+	c.App.Get("/api/v1/shared/attachments/:token", c.AchievementService.SharedAttachment)
 	c.App.Get("/swagger/*", swagger.HandlerDefault)
 }
 
 func (c *RouteConfig) SetupAuthRoute() {
 	c.App.Use(c.AuthMiddleware)
 	c.App.Post("/api/v1/auth/logout", c.AuthService.Logout)
+	c.App.Put("/api/v1/auth/password", c.PasswordService.ChangePassword)
+	c.App.Get("/api/v1/auth/sessions", c.AuthService.Sessions)
+	c.App.Delete("/api/v1/auth/sessions", c.AuthService.RevokeAllSessions)
+	c.App.Delete("/api/v1/auth/sessions/:id", c.AuthService.RevokeSession)
 	c.App.Get("/api/v1/auth/profile", c.UserService.Profile)
 
 	//users
@@ -40,30 +50,56 @@ func (c *RouteConfig) SetupAuthRoute() {
 	c.App.Get("/api/v1/users", middleware.RequirePermission("users:list"), c.UserService.FindAll)
 	c.App.Get("/api/v1/users/:id", middleware.RequirePermission("users:detail"), c.UserService.FindById)
 	c.App.Put("/api/v1/users/:id", middleware.RequirePermission("users:update"), c.UserService.Update)
-	c.App.Delete("/api/v1/users/:id", middleware.RequirePermission("users:delete"), c.UserService.Delete)
+	c.App.Delete("/api/v1/users/:id", middleware.RequirePermission("users:hardDelete"), c.UserService.Delete)
+	c.App.Post("/api/v1/users/:id/deactivate", middleware.RequirePermission("users:deactivate"), c.UserService.Deactivate)
+	c.App.Post("/api/v1/users/:id/reactivate", middleware.RequirePermission("users:deactivate"), c.UserService.Reactivate)
 	c.App.Put("/api/v1/users/:id/role", middleware.RequirePermission("users:updateRole"), c.UserService.UpdateRole)
+	c.App.Delete("/api/v1/users/:id/sessions", middleware.RequirePermission("users:revokeSessions"), c.AuthService.RevokeUserSessions)
 
 	//achievement
 	c.App.Post("/api/v1/achievements", middleware.RequirePermission("achievements:create"), c.AchievementService.Create)
+	c.App.Post("/api/v1/achievements/bulk-verify", middleware.RequirePermission("achievements:verify"), c.AchievementService.BulkVerify)
+	c.App.Post("/api/v1/achievements/bulk-reject", middleware.RequirePermission("achievements:reject"), c.AchievementService.BulkReject)
 	c.App.Get("/api/v1/achievements", middleware.RequirePermission("achievements:list"), c.AchievementService.FindAll)
+	c.App.Get("/api/v1/achievements/search", middleware.RequirePermission("achievements:list"), c.AchievementService.Search)
+	c.App.Get("/api/v1/achievements/trash", middleware.RequirePermission("achievements:delete"), c.AchievementService.Trash)
 	c.App.Get("/api/v1/achievements/:id", middleware.RequirePermission("achievements:detail"), c.AchievementService.FindByID)
 	c.App.Put("/api/v1/achievements/:id", middleware.RequirePermission("achievements:update"), c.AchievementService.Update)
 	c.App.Delete("/api/v1/achievements/:id", middleware.RequirePermission("achievements:delete"), c.AchievementService.Delete)
-	c.App.Post("/api/v1/achievements:id/submit", middleware.RequirePermission("achievements:submit"), c.AchievementService.Submit)
-	c.App.Post("/api/achievements/:id/verify", middleware.RequirePermission("achievements:verify"), c.AchievementService.Verify)
+	c.App.Post("/api/v1/achievements/:id/restore", middleware.RequirePermission("achievements:delete"), c.AchievementService.Restore)
+	c.App.Post("/api/v1/achievements/:id/submit", middleware.RequirePermission("achievements:submit"), c.AchievementService.Submit)
+	c.App.Post("/api/v1/achievements/:id/reopen", middleware.RequirePermission("achievements:update"), c.AchievementService.Reopen)
+	c.App.Post("/api/v1/achievements/:id/verify", middleware.RequirePermission("achievements:verify"), c.AchievementService.Verify)
 	c.App.Post("/api/v1/achievements/:id/reject", middleware.RequirePermission("achievements:reject"), c.AchievementService.Reject)
+	c.App.Post("/api/v1/achievements/:id/request-revision", middleware.RequirePermission("achievements:requestRevision"), c.AchievementService.RequestRevision)
 	c.App.Get("/api/v1/achievements/:id/history", middleware.RequirePermission("achievements:history"), c.AchievementService.History)
-	c.App.Post("/api/v1/achievements/:id/attachment", middleware.RequirePermission("achievements:upload"), c.AchievementService.Attachment)
+	c.App.Post("/api/v1/achievements/:id/attachment", middleware.RequirePermission("achievements:uploadAttachment"), c.AchievementService.Attachment)
+	c.App.Get("/api/v1/achievements/:id/attachments/:attachmentId", middleware.RequirePermission("achievements:detail"), c.AchievementService.DownloadAttachment)
+	c.App.Get("/api/v1/achievements/:id/attachments/:attachmentId/preview", middleware.RequirePermission("achievements:detail"), c.AchievementService.PreviewAttachment)
+	c.App.Put("/api/v1/achievements/:id/attachments/:attachmentId", middleware.RequirePermission("achievements:uploadAttachment"), c.AchievementService.ReplaceAttachment)
+	c.App.Delete("/api/v1/achievements/:id/attachments/:attachmentId", middleware.RequirePermission("achievements:uploadAttachment"), c.AchievementService.DeleteAttachment)
+	c.App.Post("/api/v1/achievements/:id/attachments/:attachmentId/link", middleware.RequirePermission("achievements:detail"), c.AchievementService.CreateAttachmentLink)
+	c.App.Get("/api/v1/achievements/:id/comments", middleware.RequirePermission("achievements:detail"), c.AchievementService.Comments)
+	c.App.Post("/api/v1/achievements/:id/comments", middleware.RequirePermission("achievements:comment"), c.AchievementService.CreateComment)
 
 	//Student And Lecturer
 	c.App.Get("/api/v1/students", middleware.RequirePermission("students:list"), c.StudentService.FindAll)
 	c.App.Get("/api/v1/students/:id", middleware.RequirePermission("students:detail"), c.StudentService.FindById)
-	c.App.Get("/api/v1/students:id/achievements", middleware.RequirePermission("students:achievements"), c.StudentService.FindAchievements)
+	c.App.Get("/api/v1/students/:id/achievements", middleware.RequirePermission("students:achievements"), c.StudentService.FindAchievements)
+	c.App.Get("/api/v1/students/:id/points", middleware.RequirePermission("students:achievements"), c.StudentService.Points)
 	c.App.Put("/api/v1/students/:id/advisor", middleware.RequirePermission("students:updateAdvisor"), c.StudentService.ChangeAdvisor)
 	c.App.Get("/api/v1/lecturers", middleware.RequirePermission("lecturers:list"), c.LecturerService.FindAll)
 	c.App.Get("/api/v1/lecturer/:id", middleware.RequirePermission("lecturers:details"), c.LecturerService.FindByID)
 	c.App.Get("/api/v1/lecturers/:id/advices", middleware.RequirePermission("lecturers:advisees"), c.LecturerService.FindAdvices)
 
+	//points
+	c.App.Get("/api/v1/points/rules", middleware.RequirePermission("points:manage"), c.PointsService.FindRuleSets)
+	c.App.Post("/api/v1/points/rules", middleware.RequirePermission("points:manage"), c.PointsService.CreateRuleSet)
+	c.App.Post("/api/v1/points/rules/:version/recalculate", middleware.RequirePermission("points:manage"), c.PointsService.Recalculate)
+
+	//maintenance
+	c.App.Post("/api/v1/admin/reconcile", middleware.RequirePermission("achievements:reconcile"), c.ReconcileService.Reconcile)
+
 	//analytics And Reporting
 	c.App.Get("/api/v1/reports/statistics", middleware.RequirePermission("reports:statistics"), c.AnalyticsService.Analytics)
 	c.App.Get("/api/v1/reports/student/:id", middleware.RequirePermission("reports:studentDetail"), c.AnalyticsService.Report)
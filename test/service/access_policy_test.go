@@ -0,0 +1,54 @@
+package service_test
+
+import (
+	"testing"
+
+	"prisma/app/model"
+	"prisma/app/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessPolicy_Authorize(t *testing.T) {
+	policy := service.NewAccessPolicy()
+	owner := model.ResourceOwner{
+		StudentID:     "student-id-1",
+		UserID:        "student-user-1",
+		AdvisorUserID: "lecturer-user-1",
+	}
+
+	t.Run("Admin Can Access Everything", func(t *testing.T) {
+		claims := &model.Claims{UserID: "admin-user", Role: model.RoleAdmin}
+		assert.NoError(t, policy.Authorize(claims, owner))
+	})
+
+	t.Run("Student Can Access Own Record", func(t *testing.T) {
+		claims := &model.Claims{UserID: "student-user-1", Role: model.RoleStudent}
+		assert.NoError(t, policy.Authorize(claims, owner))
+	})
+
+	t.Run("Student Cannot Access Other Student Record", func(t *testing.T) {
+		claims := &model.Claims{UserID: "student-user-2", Role: model.RoleStudent}
+		assert.ErrorIs(t, policy.Authorize(claims, owner), service.ErrForbidden)
+	})
+
+	t.Run("Advisor Can Access Advisee Record", func(t *testing.T) {
+		claims := &model.Claims{UserID: "lecturer-user-1", Role: model.RoleLecturer}
+		assert.NoError(t, policy.Authorize(claims, owner))
+	})
+
+	t.Run("Lecturer Cannot Access Non Advisee Record", func(t *testing.T) {
+		claims := &model.Claims{UserID: "lecturer-user-2", Role: model.RoleLecturer}
+		assert.ErrorIs(t, policy.Authorize(claims, owner), service.ErrForbidden)
+	})
+
+	t.Run("Lecturer Cannot Access Student Without Advisor", func(t *testing.T) {
+		claims := &model.Claims{UserID: "", Role: model.RoleLecturer}
+		assert.ErrorIs(t, policy.Authorize(claims, model.ResourceOwner{UserID: "student-user-1"}), service.ErrForbidden)
+	})
+
+	t.Run("Unknown Role Is Denied", func(t *testing.T) {
+		claims := &model.Claims{UserID: "student-user-1", Role: "guest"}
+		assert.ErrorIs(t, policy.Authorize(claims, owner), service.ErrForbidden)
+	})
+}
@@ -3,15 +3,28 @@ package service_test
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"prisma/app/model"
+	"prisma/app/repository"
 	"prisma/app/service"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/sirupsen/logrus"
@@ -40,10 +53,26 @@ func (m *MockStudentRepo) FindByUserId(ctx context.Context, userid string) (*mod
 func (m *MockStudentRepo) Save(ctx context.Context, tx *sql.Tx, Student *model.Student) (*model.Student, error) {
 	return nil, nil
 }
-func (m *MockStudentRepo) FindAll(ctx context.Context) ([]model.UserProfile, error) { return nil, nil }
+func (m *MockStudentRepo) FindAll(ctx context.Context, page model.PageRequest) (*model.PageResult[model.UserProfile], error) {
+	return nil, nil
+}
 func (m *MockStudentRepo) FindById(ctx context.Context, id string) (*model.UserProfile, error) {
 	return nil, nil
 }
+func (m *MockStudentRepo) FindIDsByAdvisor(ctx context.Context, userid string) ([]string, error) {
+	args := m.Called(ctx, userid)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+func (m *MockStudentRepo) FindOwner(ctx context.Context, id string) (*model.ResourceOwner, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ResourceOwner), args.Error(1)
+}
 func (m *MockStudentRepo) DeleteById(ctx context.Context, tx *sql.Tx, id string) error { return nil }
 func (m *MockStudentRepo) UpdateById(ctx context.Context, Student *model.Student) (*model.Student, error) {
 	return nil, nil
@@ -62,15 +91,108 @@ func (m *MockAchievementRepo) Create(ctx context.Context, Achievement model.Achi
 	return args.Get(0).(*model.AchievementMongo), args.Error(1)
 }
 
-// Stub method lain
 func (m *MockAchievementRepo) Update(ctx context.Context, Achievement model.AchievementMongo) (*model.AchievementMongo, error) {
-	return nil, nil
+	args := m.Called(ctx, Achievement)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementMongo), args.Error(1)
 }
+
 func (m *MockAchievementRepo) FindAll(ctx context.Context, Id []string) ([]model.AchievementMongo, error) {
-	return nil, nil
+	args := m.Called(ctx, Id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AchievementMongo), args.Error(1)
 }
+
 func (m *MockAchievementRepo) FindById(ctx context.Context, id string) (*model.AchievementMongo, error) {
-	return nil, nil
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementMongo), args.Error(1)
+}
+
+func (m *MockAchievementRepo) UpdatePoints(ctx context.Context, id string, points int, version int) error {
+	args := m.Called(ctx, id, points, version)
+	return args.Error(0)
+}
+
+func (m *MockAchievementRepo) FindIDs(ctx context.Context, filter model.AchievementFilter, limit int) ([]string, error) {
+	args := m.Called(ctx, filter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAchievementRepo) Replace(ctx context.Context, Achievement model.AchievementMongo) error {
+	args := m.Called(ctx, Achievement)
+	return args.Error(0)
+}
+
+func (m *MockAchievementRepo) MarkDeleted(ctx context.Context, id string, deletedAt *time.Time) error {
+	args := m.Called(ctx, id, deletedAt)
+	return args.Error(0)
+}
+
+func (m *MockAchievementRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAchievementRepo) FindIDsAfter(ctx context.Context, afterID string, createdBefore time.Time, limit int) ([]string, error) {
+	args := m.Called(ctx, afterID, createdBefore, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAchievementRepo) FindExistingIDs(ctx context.Context, ids []string) ([]string, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAchievementRepo) AddAttachments(ctx context.Context, id string, attachments []model.Attachment) error {
+	args := m.Called(ctx, id, attachments)
+	return args.Error(0)
+}
+
+func (m *MockAchievementRepo) RemoveAttachment(ctx context.Context, id string, attachmentID string) error {
+	args := m.Called(ctx, id, attachmentID)
+	return args.Error(0)
+}
+
+func (m *MockAchievementRepo) ReplaceAttachment(ctx context.Context, id string, attachmentID string, attachment model.Attachment) error {
+	args := m.Called(ctx, id, attachmentID, attachment)
+	return args.Error(0)
+}
+
+func (m *MockAchievementRepo) SetAttachmentScanStatus(ctx context.Context, id string, attachmentID string, status string, previewKey string) error {
+	args := m.Called(ctx, id, attachmentID, status, previewKey)
+	return args.Error(0)
+}
+
+func (m *MockAchievementRepo) FindTextMatches(ctx context.Context, text string, achievementType string, studentIDs []string, limit int) ([]string, error) {
+	args := m.Called(ctx, text, achievementType, studentIDs, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockAchievementRepo) FindPendingScans(ctx context.Context) ([]model.ScanJob, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ScanJob), args.Error(1)
 }
 
 // 3. Mock Reference Repository (Postgres)
@@ -78,42 +200,267 @@ type MockReferenceRepo struct {
 	mock.Mock
 }
 
-func (m *MockReferenceRepo) Create(ctx context.Context, achievement model.AchievementReference) (*model.AchievementReference, error) {
-	args := m.Called(ctx, achievement)
+func (m *MockReferenceRepo) Create(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference) (*model.AchievementReference, error) {
+	args := m.Called(ctx, tx, achievement)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*model.AchievementReference), args.Error(1)
 }
 
-// Stub method lain
-func (m *MockReferenceRepo) Update(ctx context.Context, achievement model.AchievementReference) (*model.AchievementReference, error) {
-	return nil, nil
+func (m *MockReferenceRepo) Update(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference) (*model.AchievementReference, error) {
+	args := m.Called(ctx, tx, achievement)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementReference), args.Error(1)
+}
+
+func (m *MockReferenceRepo) UpdateStatus(ctx context.Context, tx *sql.Tx, achievement model.AchievementReference, from string) (*model.AchievementReference, error) {
+	args := m.Called(ctx, tx, achievement, from)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementReference), args.Error(1)
 }
-func (m *MockReferenceRepo) Delete(ctx context.Context, id string) error { return nil }
+
 func (m *MockReferenceRepo) FindByID(ctx context.Context, id string) (*model.AchievementReferenceDetail, error) {
-	return nil, nil
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementReferenceDetail), args.Error(1)
 }
-func (m *MockReferenceRepo) FindByLecturer(ctx context.Context, id string, page int, limit int) ([]model.AchievementReferenceLecturer, error) {
-	return nil, nil
+
+func (m *MockReferenceRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
 }
-func (m *MockReferenceRepo) FindByStudent(ctx context.Context, id string, page int, limit int) ([]model.AchievementReferenceStudent, error) {
+
+// Stub method lain
+func (m *MockReferenceRepo) FindByLecturer(ctx context.Context, id string, filter model.AchievementFilter) (*model.PageResult[model.AchievementReferenceLecturer], error) {
 	return nil, nil
 }
-func (m *MockReferenceRepo) FindAll(ctx context.Context, page int, limit int) ([]model.AchievementReferenceAdmin, error) {
+func (m *MockReferenceRepo) FindByStudent(ctx context.Context, id string, filter model.AchievementFilter) (*model.PageResult[model.AchievementReferenceStudent], error) {
+	args := m.Called(ctx, id, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PageResult[model.AchievementReferenceStudent]), args.Error(1)
+}
+
+func (m *MockReferenceRepo) FindAll(ctx context.Context, filter model.AchievementFilter) (*model.PageResult[model.AchievementReferenceAdmin], error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PageResult[model.AchievementReferenceAdmin]), args.Error(1)
+}
+
+func (m *MockReferenceRepo) FindByStudentId(ctx context.Context, id string, page model.PageRequest) (*model.PageResult[model.AchievementReferenceAdmin], error) {
 	return nil, nil
 }
-func (m *MockReferenceRepo) FindByStudentId(ctx context.Context, id string, page int, limit int) ([]model.AchievementReferenceAdmin, error) {
+func (m *MockReferenceRepo) FindMongoIDsByStatus(ctx context.Context, status string) ([]string, error) {
 	return nil, nil
 }
 
+func (m *MockReferenceRepo) FindLinks(ctx context.Context, afterID string, limit int) ([]model.ReferenceLink, error) {
+	args := m.Called(ctx, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ReferenceLink), args.Error(1)
+}
+
+func (m *MockReferenceRepo) FindExistingMongoIDs(ctx context.Context, mongoIDs []string) ([]string, error) {
+	args := m.Called(ctx, mongoIDs)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockReferenceRepo) MarkBroken(ctx context.Context, ids []string) error {
+	args := m.Called(ctx, ids)
+	return args.Error(0)
+}
+
+func (m *MockReferenceRepo) FindDeletedByID(ctx context.Context, id string) (*model.AchievementReferenceDetail, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementReferenceDetail), args.Error(1)
+}
+
+func (m *MockReferenceRepo) FindTrash(ctx context.Context, studentID string, page model.PageRequest) (*model.PageResult[model.AchievementReferenceAdmin], error) {
+	args := m.Called(ctx, studentID, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PageResult[model.AchievementReferenceAdmin]), args.Error(1)
+}
+
+func (m *MockReferenceRepo) Restore(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockReferenceRepo) FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]model.ReferenceLink, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ReferenceLink), args.Error(1)
+}
+
+func (m *MockReferenceRepo) Purge(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// 4. Mock History Repository (Postgres)
+type MockHistoryRepo struct {
+	mock.Mock
+}
+
+func (m *MockHistoryRepo) Save(ctx context.Context, tx *sql.Tx, history model.AchievementHistory) (*model.AchievementHistory, error) {
+	args := m.Called(ctx, tx, history)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementHistory), args.Error(1)
+}
+
+func (m *MockHistoryRepo) FindByAchievementID(ctx context.Context, id string) ([]model.AchievementHistory, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AchievementHistory), args.Error(1)
+}
+
+// 5. Mock Comment Repository (Postgres)
+type MockCommentRepo struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepo) Save(ctx context.Context, comment model.AchievementComment) (*model.AchievementComment, error) {
+	args := m.Called(ctx, comment)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.AchievementComment), args.Error(1)
+}
+
+func (m *MockCommentRepo) FindByAchievementID(ctx context.Context, id string) ([]model.AchievementComment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AchievementComment), args.Error(1)
+}
+
+func (m *MockCommentRepo) SetAttachmentScanStatus(ctx context.Context, id string, attachmentID string, status string) error {
+	args := m.Called(ctx, id, attachmentID, status)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepo) FindPendingScans(ctx context.Context) ([]model.ScanJob, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.ScanJob), args.Error(1)
+}
+
+// 6. Mock Points Engine
+type MockPointsEngine struct {
+	mock.Mock
+}
+
+func (m *MockPointsEngine) Score(rules []model.PointRule, achievement model.AchievementMongo) int {
+	return 0
+}
+
+func (m *MockPointsEngine) Award(ctx context.Context, mongoAchievementID string) error {
+	args := m.Called(ctx, mongoAchievementID)
+	return args.Error(0)
+}
+
+func (m *MockPointsEngine) Recalculate(ctx context.Context, ruleSet model.PointRuleSet, mongoAchievementIDs []string) (int, error) {
+	return 0, nil
+}
+
+// 7. Mock File Storage
+type MockFileStorage struct {
+	mock.Mock
+}
+
+func (m *MockFileStorage) Save(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	args := m.Called(ctx, key, content, size, contentType)
+	return args.Error(0)
+}
+
+func (m *MockFileStorage) Open(ctx context.Context, key string) (*repository.StoredFile, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*repository.StoredFile), args.Error(1)
+}
+
+func (m *MockFileStorage) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+// 8. Fake Attachment Scanner, which only records what was queued
+type FakeAttachmentScanner struct {
+	Jobs []model.ScanJob
+}
+
+func (f *FakeAttachmentScanner) Enqueue(jobs ...model.ScanJob) {
+	f.Jobs = append(f.Jobs, jobs...)
+}
+
+func (f *FakeAttachmentScanner) Scan(ctx context.Context, job model.ScanJob) error {
+	return nil
+}
+
+func (f *FakeAttachmentScanner) Start(ctx context.Context, workers int, retryInterval time.Duration) {
+}
+
+// newPreviewGenerator renders image previews only; PDFs would need pdftoppm.
+func newPreviewGenerator() service.PreviewGenerator {
+	return service.NewPreviewGenerator(64, "", time.Second, logrus.New())
+}
+
+func newAttachmentValidator() service.AttachmentValidator {
+	return service.NewAttachmentValidator([]string{"application/pdf", "image/png", "image/jpeg"}, 1<<20, 2<<20)
+}
+
 // --- UNIT TEST FUNCTION ---
 
+var competitionDetails = model.AchievementDetails{
+	CompetitionName:  "Gemastik",
+	CompetitionLevel: "national",
+	Rank:             1,
+}
+
 func TestAchievementServiceImpl_Create(t *testing.T) {
+	// Setup SQL Mock (reference + history ditulis dalam satu transaksi)
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
 	// Setup Mocks
 	mockStudentRepo := new(MockStudentRepo)
 	mockAchievementRepo := new(MockAchievementRepo)
 	mockRefRepo := new(MockReferenceRepo)
+	mockHistoryRepo := new(MockHistoryRepo)
 	validator := validator.New()
 	logger := logrus.New()
 
@@ -122,6 +469,15 @@ func TestAchievementServiceImpl_Create(t *testing.T) {
 		mockAchievementRepo,
 		mockStudentRepo,
 		mockRefRepo,
+		mockHistoryRepo,
+		new(MockCommentRepo),
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		new(MockFileStorage),
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		db,
 		validator,
 		logger,
 	)
@@ -170,15 +526,21 @@ func TestAchievementServiceImpl_Create(t *testing.T) {
 		mockAchievementRepo.On("Create", mock.Anything, mock.MatchedBy(func(arg model.AchievementMongo) bool {
 			return arg.Title == "Lomba Coding" && arg.StudentID == "student-id-1"
 		})).Return(mongoResult, nil)
-		mockRefRepo.On("Create", mock.Anything, mock.MatchedBy(func(arg model.AchievementReference) bool {
+		sqlMock.ExpectBegin()
+		mockRefRepo.On("Create", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(arg model.AchievementReference) bool {
 			return arg.MongoAchievementID == mongoID.Hex() && arg.Status == "draft"
 		})).Return(refResult, nil)
+		mockHistoryRepo.On("Save", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(arg model.AchievementHistory) bool {
+			return arg.AchievementID == "ref-id-1" && arg.FromStatus == nil && arg.ToStatus == "draft"
+		})).Return(&model.AchievementHistory{ID: "history-id-1"}, nil)
+		sqlMock.ExpectCommit()
 
 		// 3. Request Payload
 		payload := model.CreateAchievementRequest{
 			AchievementType: "competition",
 			Title:           "Lomba Coding",
 			Description:     "Juara 1",
+			Details:         competitionDetails,
 			Tags:            []string{"coding"},
 		}
 		body, _ := json.Marshal(payload)
@@ -193,9 +555,11 @@ func TestAchievementServiceImpl_Create(t *testing.T) {
 		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
 
 		// Verifikasi Mock dipanggil
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
 		mockStudentRepo.AssertExpectations(t)
 		mockAchievementRepo.AssertExpectations(t)
 		mockRefRepo.AssertExpectations(t)
+		mockHistoryRepo.AssertExpectations(t)
 	})
 
 	t.Run("Error Validation Failed", func(t *testing.T) {
@@ -224,6 +588,7 @@ func TestAchievementServiceImpl_Create(t *testing.T) {
 			AchievementType: "competition",
 			Title:           "Lomba Invalid",
 			Description:     "Test",
+			Details:         competitionDetails,
 		}
 		body, _ := json.Marshal(payload)
 		req := httptest.NewRequest("POST", "/achievements", bytes.NewReader(body))
@@ -249,6 +614,36 @@ func TestAchievementServiceImpl_Create(t *testing.T) {
 			AchievementType: "competition",
 			Title:           "Lomba Mongo Error",
 			Description:     "Test",
+			Details:         competitionDetails,
+		}
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", "/achievements", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	})
+
+	t.Run("Error Reference Failed Removes Mongo Document", func(t *testing.T) {
+		mockStudentRepo.ExpectedCalls = nil
+		mockAchievementRepo.ExpectedCalls = nil
+		mockRefRepo.ExpectedCalls = nil
+
+		mongoID := primitive.NewObjectID()
+		mockStudentRepo.On("FindByUserId", mock.Anything, "user-123").Return(&model.Student{ID: "student-id-1"}, nil)
+		mockAchievementRepo.On("Create", mock.Anything, mock.Anything).Return(&model.AchievementMongo{ID: mongoID}, nil).Once()
+		sqlMock.ExpectBegin()
+		mockRefRepo.On("Create", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.Anything).Return(nil, errors.New("duplicate key")).Once()
+		sqlMock.ExpectRollback()
+		mockAchievementRepo.On("Delete", mock.Anything, mongoID.Hex()).Return(nil).Once()
+
+		payload := model.CreateAchievementRequest{
+			AchievementType: "competition",
+			Title:           "Lomba Orphan",
+			Description:     "Test",
+			Details:         competitionDetails,
 		}
 		body, _ := json.Marshal(payload)
 		req := httptest.NewRequest("POST", "/achievements", bytes.NewReader(body))
@@ -258,5 +653,1434 @@ func TestAchievementServiceImpl_Create(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		mockAchievementRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Type Specific Details", func(t *testing.T) {
+		start := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		tests := []struct {
+			name    string
+			payload model.CreateAchievementRequest
+		}{
+			{"unknown type", model.CreateAchievementRequest{
+				AchievementType: "hackathon", Title: "Hack", Description: "Test",
+			}},
+			{"competition without level", model.CreateAchievementRequest{
+				AchievementType: "competition", Title: "Lomba", Description: "Test",
+				Details: model.AchievementDetails{CompetitionName: "Gemastik"},
+			}},
+			{"competition level outside enum", model.CreateAchievementRequest{
+				AchievementType: "competition", Title: "Lomba", Description: "Test",
+				Details: model.AchievementDetails{CompetitionName: "Gemastik", CompetitionLevel: "galactic"},
+			}},
+			{"publication without authors", model.CreateAchievementRequest{
+				AchievementType: "publication", Title: "Paper", Description: "Test",
+				Details: model.AchievementDetails{PublicationType: "journal", PublicationTitle: "Deep Learning"},
+			}},
+			{"certification without issuer", model.CreateAchievementRequest{
+				AchievementType: "certification", Title: "Sertifikat", Description: "Test",
+				Details: model.AchievementDetails{CertificationName: "AWS Cloud Practitioner"},
+			}},
+			{"organization period ends before start", model.CreateAchievementRequest{
+				AchievementType: "organization", Title: "BEM", Description: "Test",
+				Details: model.AchievementDetails{
+					OrganizationName: "BEM", Position: "Ketua",
+					Period: &model.AchievementPeriod{Start: start, End: start.AddDate(0, -1, 0)},
+				},
+			}},
+		}
+		for _, tc := range tests {
+			t.Run(tc.name, func(t *testing.T) {
+				body, _ := json.Marshal(tc.payload)
+				req := httptest.NewRequest("POST", "/achievements", bytes.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+
+				resp, err := app.Test(req)
+
+				assert.NoError(t, err)
+				assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			})
+		}
+	})
+}
+
+func TestAchievementServiceImpl_Submit(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mockRefRepo := new(MockReferenceRepo)
+	mockHistoryRepo := new(MockHistoryRepo)
+	svc := service.NewAchievementService(
+		new(MockAchievementRepo),
+		new(MockStudentRepo),
+		mockRefRepo,
+		mockHistoryRepo,
+		new(MockCommentRepo),
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		new(MockFileStorage),
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		db,
+		validator.New(),
+		logrus.New(),
+	)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		claims := &model.Claims{
+			UserID: "user-123",
+			Role:   "mahasiswa",
+		}
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Post("/achievements/:id/submit", svc.Submit)
+
+	t.Run("Success Submit Draft Writes History", func(t *testing.T) {
+		draft := &model.AchievementReferenceDetail{
+			ID:     "ref-id-1",
+			Status: "draft",
+			UserDetail: model.UserResponse{
+				StudentProfile: &model.StudentCreate{StudentID: "student-id-1"},
+			},
+			Owner: model.ResourceOwner{StudentID: "student-id-1", UserID: "user-123"},
+		}
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(draft, nil).Once()
+
+		sqlMock.ExpectBegin()
+		mockRefRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(arg model.AchievementReference) bool {
+			return arg.ID == "ref-id-1" && arg.Status == "submitted" && arg.SubmittedAt != nil
+		}), "draft").Return(&model.AchievementReference{ID: "ref-id-1", Status: "submitted"}, nil).Once()
+		mockHistoryRepo.On("Save", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(arg model.AchievementHistory) bool {
+			return arg.AchievementID == "ref-id-1" && *arg.FromStatus == "draft" && arg.ToStatus == "submitted" && *arg.ActorID == "user-123"
+		})).Return(&model.AchievementHistory{ID: "history-id-1"}, nil).Once()
+		sqlMock.ExpectCommit()
+
+		req := httptest.NewRequest("POST", "/achievements/ref-id-1/submit", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		mockRefRepo.AssertExpectations(t)
+		mockHistoryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Submit Verified Achievement Conflict", func(t *testing.T) {
+		verified := &model.AchievementReferenceDetail{
+			ID:     "ref-id-2",
+			Status: "verified",
+			UserDetail: model.UserResponse{
+				StudentProfile: &model.StudentCreate{StudentID: "student-id-1"},
+			},
+			Owner: model.ResourceOwner{StudentID: "student-id-1", UserID: "user-123"},
+		}
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-2").Return(verified, nil).Once()
+
+		req := httptest.NewRequest("POST", "/achievements/ref-id-2/submit", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		// Tidak boleh ada transaksi untuk transisi ilegal
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Error Submit Other Student Achievement Forbidden", func(t *testing.T) {
+		other := &model.AchievementReferenceDetail{
+			ID:     "ref-id-3",
+			Status: "draft",
+			Owner:  model.ResourceOwner{StudentID: "student-id-2", UserID: "user-456"},
+		}
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-3").Return(other, nil).Once()
+
+		req := httptest.NewRequest("POST", "/achievements/ref-id-3/submit", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementServiceImpl_Delete(t *testing.T) {
+	mockAchievementRepo := new(MockAchievementRepo)
+	mockRefRepo := new(MockReferenceRepo)
+	svc := service.NewAchievementService(
+		mockAchievementRepo,
+		new(MockStudentRepo),
+		mockRefRepo,
+		new(MockHistoryRepo),
+		new(MockCommentRepo),
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		new(MockFileStorage),
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		claims := &model.Claims{
+			UserID: "user-123",
+			Role:   "mahasiswa",
+		}
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Delete("/achievements/:id", svc.Delete)
+	app.Post("/achievements/:id/restore", svc.Restore)
+
+	mongoID := primitive.NewObjectID().Hex()
+	draft := &model.AchievementReferenceDetail{
+		ID:                 "ref-id-1",
+		Status:             "draft",
+		MongoAchievementID: mongoID,
+		Owner:              model.ResourceOwner{StudentID: "student-id-1", UserID: "user-123"},
+	}
+
+	t.Run("Success Marks Both Stores", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(draft, nil).Once()
+		mockAchievementRepo.On("MarkDeleted", mock.Anything, mongoID, mock.MatchedBy(func(at *time.Time) bool {
+			return at != nil
+		})).Return(nil).Once()
+		mockRefRepo.On("Delete", mock.Anything, "ref-id-1").Return(nil).Once()
+
+		req := httptest.NewRequest("DELETE", "/achievements/ref-id-1", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockAchievementRepo.AssertExpectations(t)
+		mockRefRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Reference Failed Restores Mongo Document", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(draft, nil).Once()
+		mockAchievementRepo.On("MarkDeleted", mock.Anything, mongoID, mock.MatchedBy(func(at *time.Time) bool {
+			return at != nil
+		})).Return(nil).Once()
+		mockRefRepo.On("Delete", mock.Anything, "ref-id-1").Return(errors.New("connection reset")).Once()
+		mockAchievementRepo.On("MarkDeleted", mock.Anything, mongoID, (*time.Time)(nil)).Return(nil).Once()
+
+		req := httptest.NewRequest("DELETE", "/achievements/ref-id-1", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+		mockAchievementRepo.AssertExpectations(t)
+		mockRefRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success Restore From Trash", func(t *testing.T) {
+		deletedAt := time.Now().Add(-time.Hour)
+		trashed := &model.AchievementReferenceDetail{
+			ID:                 "ref-id-1",
+			Status:             "draft",
+			MongoAchievementID: mongoID,
+			DeletedAt:          &deletedAt,
+			Owner:              model.ResourceOwner{StudentID: "student-id-1", UserID: "user-123"},
+		}
+		mockRefRepo.On("FindDeletedByID", mock.Anything, "ref-id-1").Return(trashed, nil).Once()
+		mockAchievementRepo.On("MarkDeleted", mock.Anything, mongoID, (*time.Time)(nil)).Return(nil).Once()
+		mockRefRepo.On("Restore", mock.Anything, "ref-id-1").Return(nil).Once()
+
+		req := httptest.NewRequest("POST", "/achievements/ref-id-1/restore", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockAchievementRepo.AssertExpectations(t)
+		mockRefRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Restore Not In Trash", func(t *testing.T) {
+		mockRefRepo.On("FindDeletedByID", mock.Anything, "ref-id-9").Return(nil, sql.ErrNoRows).Once()
+
+		req := httptest.NewRequest("POST", "/achievements/ref-id-9/restore", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Error Restore Other Student Forbidden", func(t *testing.T) {
+		deletedAt := time.Now()
+		other := &model.AchievementReferenceDetail{
+			ID:        "ref-id-3",
+			DeletedAt: &deletedAt,
+			Owner:     model.ResourceOwner{StudentID: "student-id-2", UserID: "user-456"},
+		}
+		mockRefRepo.On("FindDeletedByID", mock.Anything, "ref-id-3").Return(other, nil).Once()
+
+		req := httptest.NewRequest("POST", "/achievements/ref-id-3/restore", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+		mockRefRepo.AssertNumberOfCalls(t, "Restore", 1)
+	})
+}
+
+func TestAchievementServiceImpl_Attachment(t *testing.T) {
+	mockAchievementRepo := new(MockAchievementRepo)
+	mockRefRepo := new(MockReferenceRepo)
+	mockCommentRepo := new(MockCommentRepo)
+	scanner := new(FakeAttachmentScanner)
+	root := t.TempDir()
+	svc := service.NewAchievementService(
+		mockAchievementRepo,
+		new(MockStudentRepo),
+		mockRefRepo,
+		new(MockHistoryRepo),
+		mockCommentRepo,
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		repository.NewLocalFileStorage(root),
+		newAttachmentValidator(),
+		scanner,
+		service.NewURLSigner([]byte("test-secret")),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	claims := &model.Claims{UserID: "user-123", Role: "mahasiswa"}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Post("/achievements/:id/attachments", svc.Attachment)
+
+	mongoID := primitive.NewObjectID()
+	draft := &model.AchievementReferenceDetail{
+		ID:                 "ref-id-1",
+		Status:             "draft",
+		MongoAchievementID: mongoID.Hex(),
+		Owner:              model.ResourceOwner{StudentID: "student-id-1", UserID: "user-123"},
+	}
+	newRequest := func(files ...string) *http.Request {
+		if len(files) == 0 {
+			files = []string{"sertifikat.pdf", "%PDF-1.4 sertifikat"}
+		}
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for i := 0; i < len(files); i += 2 {
+			part, _ := writer.CreateFormFile("attachments", files[i])
+			part.Write([]byte(files[i+1]))
+		}
+		writer.Close()
+		req := httptest.NewRequest("POST", "/achievements/ref-id-1/attachments", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req
+	}
+	storedFiles := func() []string {
+		entries, _ := os.ReadDir(filepath.Join(root, "achievements"))
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	t.Run("Success Stores File Through Storage", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(draft, nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(&model.AchievementMongo{ID: mongoID}, nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{}, nil).Once()
+		checksum := sha256.Sum256([]byte("%PDF-1.4 sertifikat"))
+		mockAchievementRepo.On("AddAttachments", mock.Anything, mongoID.Hex(), mock.MatchedBy(func(arg []model.Attachment) bool {
+			return len(arg) == 1 && strings.HasPrefix(arg[0].StorageKey, "achievements/") &&
+				arg[0].FileURL == "/api/v1/achievements/ref-id-1/attachments/"+arg[0].ID &&
+				arg[0].FileName == "sertifikat_lomba.pdf" &&
+				arg[0].FileType == "application/pdf" &&
+				arg[0].Checksum == hex.EncodeToString(checksum[:]) &&
+				arg[0].ScanStatus == model.ScanPending
+		})).Return(nil).Once()
+
+		resp, err := app.Test(newRequest("../../sertifikat lomba.pdf", "%PDF-1.4 sertifikat"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Len(t, storedFiles(), 1)
+		assert.Len(t, scanner.Jobs, 1)
+		assert.Equal(t, mongoID.Hex(), scanner.Jobs[0].MongoAchievementID)
+		mockAchievementRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success Image Preview Left To Scanner", func(t *testing.T) {
+		var photo bytes.Buffer
+		png.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 200, 100)))
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(draft, nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(&model.AchievementMongo{ID: mongoID}, nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{}, nil).Once()
+		mockAchievementRepo.On("AddAttachments", mock.Anything, mongoID.Hex(), mock.MatchedBy(func(arg []model.Attachment) bool {
+			return len(arg) == 1 && arg[0].PreviewKey == ""
+		})).Return(nil).Once()
+
+		resp, err := app.Test(newRequest("foto.png", photo.String()))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockAchievementRepo.AssertExpectations(t)
+
+		previews, _ := filepath.Glob(filepath.Join(root, "achievements", "*_foto.png.preview.jpg"))
+		assert.Empty(t, previews)
+		assert.Equal(t, "image/png", scanner.Jobs[len(scanner.Jobs)-1].ContentType)
+	})
+
+	t.Run("Error Update Failed Removes Stored File", func(t *testing.T) {
+		before := storedFiles()
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(draft, nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(&model.AchievementMongo{ID: mongoID}, nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{}, nil).Once()
+		mockAchievementRepo.On("AddAttachments", mock.Anything, mongoID.Hex(), mock.Anything).Return(errors.New("mongo down")).Once()
+
+		resp, err := app.Test(newRequest())
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+		assert.Equal(t, before, storedFiles())
+		assert.Len(t, scanner.Jobs, 2)
+	})
+
+	t.Run("Error Rejected Files Listed And Nothing Stored", func(t *testing.T) {
+		before := storedFiles()
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(draft, nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(&model.AchievementMongo{ID: mongoID}, nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{}, nil).Once()
+
+		resp, err := app.Test(newRequest(
+			"sertifikat.pdf", "%PDF-1.4 sertifikat",
+			"script.pdf", "#!/bin/sh\necho pwned",
+		))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		var body model.WebResponse[[]model.AttachmentRejection]
+		json.NewDecoder(resp.Body).Decode(&body)
+		assert.Len(t, body.Data, 1)
+		assert.Equal(t, "script.pdf", body.Data[0].FileName)
+		assert.Contains(t, body.Data[0].Error, "text/plain")
+		assert.Equal(t, before, storedFiles())
+		// Only the earlier subtests reached AddAttachments
+		mockAchievementRepo.AssertNumberOfCalls(t, "AddAttachments", 3)
+	})
+
+	t.Run("Error Admin Upload After Verify", func(t *testing.T) {
+		claims.UserID, claims.Role = "admin-1", "admin"
+		defer func() { claims.UserID, claims.Role = "user-123", "mahasiswa" }()
+		verified := *draft
+		verified.Status = "verified"
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(&verified, nil).Once()
+		before := storedFiles()
+
+		resp, err := app.Test(newRequest())
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		assert.Equal(t, before, storedFiles())
+		mockAchievementRepo.AssertNumberOfCalls(t, "AddAttachments", 3)
+	})
+
+	t.Run("Error Achievement Quota Exceeded", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(draft, nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(&model.AchievementMongo{
+			ID:          mongoID,
+			Attachments: []model.Attachment{{ID: "file-1", Size: 1 << 20}},
+		}, nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{
+			{Attachments: []model.Attachment{{ID: "file-2", Size: 1<<20 - 4}}},
+		}, nil).Once()
+
+		resp, err := app.Test(newRequest())
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestAchievementServiceImpl_DownloadAttachment(t *testing.T) {
+	mockAchievementRepo := new(MockAchievementRepo)
+	mockRefRepo := new(MockReferenceRepo)
+	mockCommentRepo := new(MockCommentRepo)
+	storage := repository.NewLocalFileStorage(t.TempDir())
+	svc := service.NewAchievementService(
+		mockAchievementRepo,
+		new(MockStudentRepo),
+		mockRefRepo,
+		new(MockHistoryRepo),
+		mockCommentRepo,
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		storage,
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	claims := &model.Claims{UserID: "user-123", Role: "mahasiswa"}
+	app := fiber.New()
+	app.Get("/shared/attachments/:token", svc.SharedAttachment)
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Get("/achievements/:id/attachments/:attachmentId", svc.DownloadAttachment)
+	app.Post("/achievements/:id/attachments/:attachmentId/link", svc.CreateAttachmentLink)
+	app.Get("/achievements/:id/attachments/:attachmentId/preview", svc.PreviewAttachment)
+
+	content := "%PDF-1.4 sertifikat"
+	err := storage.Save(context.Background(), "achievements/file-1_sertifikat.pdf", strings.NewReader(content), int64(len(content)), "application/pdf")
+	assert.NoError(t, err)
+
+	mongoID := primitive.NewObjectID()
+	ref := &model.AchievementReferenceDetail{
+		ID:                 "ref-id-1",
+		Status:             "verified",
+		MongoAchievementID: mongoID.Hex(),
+		Owner:              model.ResourceOwner{StudentID: "student-id-1", UserID: "user-123"},
+	}
+	doc := &model.AchievementMongo{
+		ID: mongoID,
+		Attachments: []model.Attachment{{
+			ID:         "file-1",
+			FileName:   "sertifikat.pdf",
+			StorageKey: "achievements/file-1_sertifikat.pdf",
+			FileType:   "application/pdf",
+			ScanStatus: model.ScanClean,
+		}},
+	}
+
+	t.Run("Success Owner Downloads", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(ref, nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(doc, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/achievements/ref-id-1/attachments/file-1", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/pdf", resp.Header.Get("Content-Type"))
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "sertifikat.pdf")
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, content, string(body))
+	})
+
+	t.Run("Error Held Back Until Scanned Clean", func(t *testing.T) {
+		// An empty status belongs to an upload from before scanning that the
+		// migration has not marked pending yet
+		for status, code := range map[string]int{model.ScanPending: fiber.StatusConflict, "": fiber.StatusConflict, model.ScanInfected: fiber.StatusForbidden} {
+			scanned := *doc
+			scanned.Attachments = []model.Attachment{doc.Attachments[0]}
+			scanned.Attachments[0].ScanStatus = status
+			mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(ref, nil).Once()
+			mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(&scanned, nil).Once()
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/achievements/ref-id-1/attachments/file-1", nil))
+
+			assert.NoError(t, err)
+			assert.Equal(t, code, resp.StatusCode, status)
+		}
+	})
+
+	t.Run("Preview Served Inline When Present", func(t *testing.T) {
+		err := storage.Save(context.Background(), "achievements/file-1_sertifikat.pdf.preview.jpg", strings.NewReader("jpeg"), 4, "image/jpeg")
+		assert.NoError(t, err)
+		withPreview := *doc
+		withPreview.Attachments = []model.Attachment{doc.Attachments[0]}
+		withPreview.Attachments[0].PreviewKey = "achievements/file-1_sertifikat.pdf.preview.jpg"
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(ref, nil).Twice()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(&withPreview, nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(doc, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/achievements/ref-id-1/attachments/file-1/preview", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "image/jpeg", resp.Header.Get("Content-Type"))
+		assert.Empty(t, resp.Header.Get("Content-Disposition"))
+
+		resp, err = app.Test(httptest.NewRequest("GET", "/achievements/ref-id-1/attachments/file-1/preview", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Error Other Student Forbidden", func(t *testing.T) {
+		claims.UserID = "user-456"
+		defer func() { claims.UserID = "user-123" }()
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(ref, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/achievements/ref-id-1/attachments/file-1", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Error Unknown Attachment", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(ref, nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(doc, nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/achievements/ref-id-1/attachments/file-2", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Success Signed Link Works Without Login", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(ref, nil).Twice()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(doc, nil).Twice()
+
+		req := httptest.NewRequest("POST", "/achievements/ref-id-1/attachments/file-1/link", strings.NewReader(`{"expires_in_minutes": 5}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+		var link model.WebResponse[model.AttachmentLink]
+		json.NewDecoder(resp.Body).Decode(&link)
+		assert.True(t, strings.HasPrefix(link.Data.URL, "/api/v1/shared/attachments/"))
+		assert.WithinDuration(t, time.Now().Add(5*time.Minute), link.Data.ExpiresAt, time.Minute)
+
+		token := strings.TrimPrefix(link.Data.URL, "/api/v1/shared/attachments/")
+		resp, err = app.Test(httptest.NewRequest("GET", "/shared/attachments/"+token, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, content, string(body))
+	})
+
+	t.Run("Error Link Lifetime Too Long", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/achievements/ref-id-1/attachments/file-1/link", strings.NewReader(`{"expires_in_minutes": 2000}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Error Shared Link Expired Or Tampered", func(t *testing.T) {
+		signer := service.NewURLSigner([]byte("test-secret"))
+		expired := signer.Sign(model.AttachmentGrant{AchievementID: "ref-id-1", AttachmentID: "file-1", ExpiresAt: time.Now().Add(-time.Minute)})
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/shared/attachments/"+expired, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusGone, resp.StatusCode)
+
+		forged := service.NewURLSigner([]byte("guess")).Sign(model.AttachmentGrant{AchievementID: "ref-id-1", AttachmentID: "file-1", ExpiresAt: time.Now().Add(time.Minute)})
+		resp, err = app.Test(httptest.NewRequest("GET", "/shared/attachments/"+forged, nil))
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func TestAchievementServiceImpl_DeleteAttachment(t *testing.T) {
+	mockAchievementRepo := new(MockAchievementRepo)
+	mockRefRepo := new(MockReferenceRepo)
+	mockCommentRepo := new(MockCommentRepo)
+	root := t.TempDir()
+	storage := repository.NewLocalFileStorage(root)
+	svc := service.NewAchievementService(
+		mockAchievementRepo,
+		new(MockStudentRepo),
+		mockRefRepo,
+		new(MockHistoryRepo),
+		mockCommentRepo,
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		storage,
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	claims := &model.Claims{UserID: "user-123", Role: "mahasiswa"}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Delete("/achievements/:id/attachments/:attachmentId", svc.DeleteAttachment)
+	app.Put("/achievements/:id/attachments/:attachmentId", svc.ReplaceAttachment)
+
+	mongoID := primitive.NewObjectID()
+	newRef := func(status string) *model.AchievementReferenceDetail {
+		return &model.AchievementReferenceDetail{
+			ID:                 "ref-id-1",
+			Status:             status,
+			MongoAchievementID: mongoID.Hex(),
+			Owner:              model.ResourceOwner{StudentID: "student-id-1", UserID: "user-123"},
+		}
+	}
+	// newDoc stores two attachments and returns the document pointing at them
+	newDoc := func() *model.AchievementMongo {
+		doc := &model.AchievementMongo{ID: mongoID}
+		for _, id := range []string{"file-1", "file-2"} {
+			key := "achievements/" + id + "_sertifikat.pdf"
+			storage.Save(context.Background(), key, strings.NewReader("%PDF-1.4"), 8, "application/pdf")
+			doc.Attachments = append(doc.Attachments, model.Attachment{ID: id, FileName: "sertifikat.pdf", StorageKey: key, Size: 8})
+		}
+		return doc
+	}
+	stored := func(id string) bool {
+		_, err := os.Stat(filepath.Join(root, "achievements", id+"_sertifikat.pdf"))
+		return err == nil
+	}
+	newReplaceRequest := func(name string, content string) *http.Request {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile("attachment", name)
+		part.Write([]byte(content))
+		writer.Close()
+		req := httptest.NewRequest("PUT", "/achievements/ref-id-1/attachments/file-1", body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		return req
+	}
+
+	t.Run("Success Delete Removes Entry And File", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("draft"), nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(newDoc(), nil).Once()
+		mockAchievementRepo.On("RemoveAttachment", mock.Anything, mongoID.Hex(), "file-1").Return(nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/achievements/ref-id-1/attachments/file-1", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.False(t, stored("file-1"))
+		assert.True(t, stored("file-2"))
+		mockAchievementRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Delete After Submit", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("submitted"), nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/achievements/ref-id-1/attachments/file-1", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockAchievementRepo.AssertNumberOfCalls(t, "RemoveAttachment", 1)
+	})
+
+	t.Run("Error Admin Delete After Verify", func(t *testing.T) {
+		claims.UserID, claims.Role = "admin-1", "admin"
+		defer func() { claims.UserID, claims.Role = "user-123", "mahasiswa" }()
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("verified"), nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/achievements/ref-id-1/attachments/file-1", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockAchievementRepo.AssertNumberOfCalls(t, "RemoveAttachment", 1)
+	})
+
+	t.Run("Error Delete Unknown Attachment", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("draft"), nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(newDoc(), nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/achievements/ref-id-1/attachments/file-9", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Success Replace Keeps Position", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("needs_revision"), nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(newDoc(), nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{}, nil).Once()
+		mockAchievementRepo.On("ReplaceAttachment", mock.Anything, mongoID.Hex(), "file-1", mock.MatchedBy(func(arg model.Attachment) bool {
+			return arg.FileName == "revisi.pdf" && arg.ID != "file-1"
+		})).Return(nil).Once()
+
+		resp, err := app.Test(newReplaceRequest("revisi.pdf", "%PDF-1.4 revisi"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.False(t, stored("file-1"))
+		var body model.WebResponse[model.AchievementReferenceDetail]
+		json.NewDecoder(resp.Body).Decode(&body)
+		assert.Len(t, body.Data.Detail.Attachments, 2)
+		assert.Equal(t, "revisi.pdf", body.Data.Detail.Attachments[0].FileName)
+		assert.Equal(t, "file-2", body.Data.Detail.Attachments[1].ID)
+		mockAchievementRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Replace Attachment Deleted Meanwhile", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("draft"), nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(newDoc(), nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{}, nil).Once()
+		mockAchievementRepo.On("ReplaceAttachment", mock.Anything, mongoID.Hex(), "file-1", mock.Anything).Return(repository.ErrAttachmentNotFound).Once()
+		before, _ := filepath.Glob(filepath.Join(root, "achievements", "*"))
+
+		resp, err := app.Test(newReplaceRequest("revisi.pdf", "%PDF-1.4 revisi"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		after, _ := filepath.Glob(filepath.Join(root, "achievements", "*"))
+		assert.Equal(t, before, after)
+	})
+
+	t.Run("Error Admin Replace After Verify", func(t *testing.T) {
+		claims.UserID, claims.Role = "admin-1", "admin"
+		defer func() { claims.UserID, claims.Role = "user-123", "mahasiswa" }()
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("verified"), nil).Once()
+
+		resp, err := app.Test(newReplaceRequest("revisi.pdf", "%PDF-1.4 revisi"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		mockAchievementRepo.AssertNumberOfCalls(t, "ReplaceAttachment", 2)
+	})
+
+	t.Run("Error Replace With Disallowed Type Keeps Original", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("draft"), nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, mongoID.Hex()).Return(newDoc(), nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{}, nil).Once()
+
+		resp, err := app.Test(newReplaceRequest("revisi.pdf", "just some text"))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		assert.True(t, stored("file-1"))
+		mockAchievementRepo.AssertNumberOfCalls(t, "ReplaceAttachment", 2)
+	})
+}
+
+func TestAchievementServiceImpl_History(t *testing.T) {
+	mockRefRepo := new(MockReferenceRepo)
+	mockHistoryRepo := new(MockHistoryRepo)
+	mockCommentRepo := new(MockCommentRepo)
+	svc := service.NewAchievementService(
+		new(MockAchievementRepo),
+		new(MockStudentRepo),
+		mockRefRepo,
+		mockHistoryRepo,
+		mockCommentRepo,
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		new(MockFileStorage),
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		claims := &model.Claims{
+			UserID: "lecturer-user-1",
+			Role:   "lecturer",
+		}
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Get("/achievements/:id/history", svc.History)
+
+	t.Run("Success Merge Status Log And Comments In Order", func(t *testing.T) {
+		base := time.Date(2025, 12, 1, 8, 0, 0, 0, time.UTC)
+		draft, submitted := "draft", "submitted"
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(&model.AchievementReferenceDetail{
+			ID:     "ref-id-1",
+			Status: "submitted",
+			Owner:  model.ResourceOwner{UserID: "student-user-1", AdvisorUserID: "lecturer-user-1"},
+		}, nil).Once()
+		mockHistoryRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementHistory{
+			{ID: "h-1", Type: model.HistoryTypeStatusChange, ToStatus: draft, Timestamp: base},
+			{ID: "h-2", Type: model.HistoryTypeStatusChange, FromStatus: &draft, ToStatus: submitted, Timestamp: base.Add(2 * time.Hour)},
+		}, nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-id-1").Return([]model.AchievementComment{
+			{ID: "c-1", AchievementID: "ref-id-1", Body: "Sertifikat sudah saya lampirkan", CreatedAt: base.Add(time.Hour)},
+		}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/achievements/ref-id-1/history", nil)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var body model.WebResponse[[]model.AchievementHistory]
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		if assert.Len(t, body.Data, 3) {
+			assert.Equal(t, "h-1", body.Data[0].ID)
+			assert.Equal(t, "c-1", body.Data[1].ID)
+			assert.Equal(t, model.HistoryTypeComment, body.Data[1].Type)
+			assert.Equal(t, "h-2", body.Data[2].ID)
+		}
+	})
+
+	t.Run("Error Lecturer Not Advisor Forbidden", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-2").Return(&model.AchievementReferenceDetail{
+			ID:     "ref-id-2",
+			Status: "submitted",
+			Owner:  model.ResourceOwner{UserID: "student-user-2", AdvisorUserID: "lecturer-user-2"},
+		}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/achievements/ref-id-2/history", nil)
+		resp, err := app.Test(req)
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	})
+}
+
+func TestAchievementServiceImpl_Reopen(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mockRefRepo := new(MockReferenceRepo)
+	mockHistoryRepo := new(MockHistoryRepo)
+	svc := service.NewAchievementService(
+		new(MockAchievementRepo),
+		new(MockStudentRepo),
+		mockRefRepo,
+		mockHistoryRepo,
+		new(MockCommentRepo),
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		new(MockFileStorage),
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		db,
+		validator.New(),
+		logrus.New(),
+	)
+
+	claims := &model.Claims{UserID: "user-123", Role: "mahasiswa"}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Post("/achievements/:id/reopen", svc.Reopen)
+
+	newRef := func(status string) *model.AchievementReferenceDetail {
+		return &model.AchievementReferenceDetail{
+			ID:     "ref-id-1",
+			Status: status,
+			Owner:  model.ResourceOwner{StudentID: "student-id-1", UserID: "user-123"},
+		}
+	}
+
+	t.Run("Success Rejected Back To Draft", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("rejected"), nil).Once()
+		sqlMock.ExpectBegin()
+		mockRefRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(arg model.AchievementReference) bool {
+			return arg.ID == "ref-id-1" && arg.Status == "draft"
+		}), "rejected").Return(&model.AchievementReference{ID: "ref-id-1", Status: "draft"}, nil).Once()
+		mockHistoryRepo.On("Save", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(arg model.AchievementHistory) bool {
+			return *arg.FromStatus == "rejected" && arg.ToStatus == "draft" && *arg.ActorID == "user-123"
+		})).Return(&model.AchievementHistory{ID: "history-id-1"}, nil).Once()
+		sqlMock.ExpectCommit()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/achievements/ref-id-1/reopen", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var body model.WebResponse[model.AchievementReferenceDetail]
+		json.NewDecoder(resp.Body).Decode(&body)
+		assert.Equal(t, "draft", body.Data.Status)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		mockRefRepo.AssertExpectations(t)
+		mockHistoryRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Only Rejected Can Reopen", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("verified"), nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/achievements/ref-id-1/reopen", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusConflict, resp.StatusCode)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("Error Other Student Forbidden", func(t *testing.T) {
+		claims.UserID = "user-456"
+		defer func() { claims.UserID = "user-123" }()
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-1").Return(newRef("rejected"), nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/achievements/ref-id-1/reopen", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+		mockRefRepo.AssertNumberOfCalls(t, "UpdateStatus", 1)
+	})
+
+	t.Run("Error Unknown Achievement", func(t *testing.T) {
+		mockRefRepo.On("FindByID", mock.Anything, "ref-id-9").Return(nil, sql.ErrNoRows).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/achievements/ref-id-9/reopen", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestAchievementServiceImpl_BulkVerify(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mockRefRepo := new(MockReferenceRepo)
+	mockHistoryRepo := new(MockHistoryRepo)
+	mockPoints := new(MockPointsEngine)
+	svc := service.NewAchievementService(
+		new(MockAchievementRepo),
+		new(MockStudentRepo),
+		mockRefRepo,
+		mockHistoryRepo,
+		new(MockCommentRepo),
+		service.NewAccessPolicy(),
+		mockPoints,
+		new(MockFileStorage),
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		db,
+		validator.New(),
+		logrus.New(),
+	)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		claims := &model.Claims{
+			UserID: "lecturer-user-1",
+			Role:   "lecturer",
+		}
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Post("/achievements/bulk-verify", svc.BulkVerify)
+
+	t.Run("Success Reports Result Per Item", func(t *testing.T) {
+		okID := "11111111-1111-1111-1111-111111111111"
+		draftID := "22222222-2222-2222-2222-222222222222"
+		otherID := "33333333-3333-3333-3333-333333333333"
+		missingID := "44444444-4444-4444-4444-444444444444"
+
+		mockRefRepo.On("FindByID", mock.Anything, okID).Return(&model.AchievementReferenceDetail{
+			ID:                 okID,
+			MongoAchievementID: "mongo-id-1",
+			Status:             "submitted",
+			Owner:              model.ResourceOwner{StudentID: "student-id-1", UserID: "user-1", AdvisorUserID: "lecturer-user-1"},
+		}, nil).Once()
+		mockRefRepo.On("FindByID", mock.Anything, draftID).Return(&model.AchievementReferenceDetail{
+			ID:     draftID,
+			Status: "draft",
+			Owner:  model.ResourceOwner{StudentID: "student-id-1", UserID: "user-1", AdvisorUserID: "lecturer-user-1"},
+		}, nil).Once()
+		mockRefRepo.On("FindByID", mock.Anything, otherID).Return(&model.AchievementReferenceDetail{
+			ID:     otherID,
+			Status: "submitted",
+			Owner:  model.ResourceOwner{StudentID: "student-id-2", UserID: "user-2", AdvisorUserID: "lecturer-user-2"},
+		}, nil).Once()
+		mockRefRepo.On("FindByID", mock.Anything, missingID).Return(nil, sql.ErrNoRows).Once()
+
+		sqlMock.ExpectBegin()
+		mockRefRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(arg model.AchievementReference) bool {
+			return arg.ID == okID && arg.Status == "verified" && arg.VerifiedBy == "lecturer-user-1"
+		}), "submitted").Return(&model.AchievementReference{ID: okID, Status: "verified"}, nil).Once()
+		mockHistoryRepo.On("Save", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(arg model.AchievementHistory) bool {
+			return arg.AchievementID == okID && arg.ToStatus == "verified" && *arg.Note == "Bukti lengkap"
+		})).Return(&model.AchievementHistory{ID: "history-id-1"}, nil).Once()
+		sqlMock.ExpectCommit()
+		mockPoints.On("Award", mock.Anything, "mongo-id-1").Return(nil).Once()
+
+		payload := model.BulkReviewRequest{
+			IDs:  []string{okID, draftID, otherID, missingID, okID},
+			Note: "Bukti lengkap",
+		}
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", "/achievements/bulk-verify", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var respBody model.WebResponse[[]model.BulkReviewResult]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.Len(t, respBody.Data, 4)
+		assert.Equal(t, model.BulkResultSuccess, respBody.Data[0].Result)
+		assert.Equal(t, model.BulkResultIllegalState, respBody.Data[1].Result)
+		assert.Equal(t, model.BulkResultNotAdvisee, respBody.Data[2].Result)
+		assert.Equal(t, model.BulkResultNotFound, respBody.Data[3].Result)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		mockRefRepo.AssertExpectations(t)
+		mockHistoryRepo.AssertExpectations(t)
+		mockPoints.AssertExpectations(t)
+	})
+
+	t.Run("Conflict When Reviewed Meanwhile", func(t *testing.T) {
+		raceID := "55555555-5555-5555-5555-555555555555"
+		mockRefRepo.On("FindByID", mock.Anything, raceID).Return(&model.AchievementReferenceDetail{
+			ID:                 raceID,
+			MongoAchievementID: "mongo-id-5",
+			Status:             "submitted",
+			Owner:              model.ResourceOwner{StudentID: "student-id-1", UserID: "user-1", AdvisorUserID: "lecturer-user-1"},
+		}, nil).Once()
+
+		// Another reviewer rejected it between the read and the update
+		sqlMock.ExpectBegin()
+		mockRefRepo.On("UpdateStatus", mock.Anything, mock.AnythingOfType("*sql.Tx"), mock.MatchedBy(func(arg model.AchievementReference) bool {
+			return arg.ID == raceID
+		}), "submitted").Return(nil, repository.ErrStatusChanged).Once()
+		sqlMock.ExpectCommit()
+
+		body, _ := json.Marshal(model.BulkReviewRequest{IDs: []string{raceID}})
+		req := httptest.NewRequest("POST", "/achievements/bulk-verify", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var respBody model.WebResponse[[]model.BulkReviewResult]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.Len(t, respBody.Data, 1)
+		assert.Equal(t, model.BulkResultConflict, respBody.Data[0].Result)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		// No history entry and no points for the lost race
+		mockHistoryRepo.AssertNumberOfCalls(t, "Save", 1)
+		mockPoints.AssertNumberOfCalls(t, "Award", 1)
+	})
+
+	t.Run("Error Validation Empty IDs", func(t *testing.T) {
+		body, _ := json.Marshal(model.BulkReviewRequest{})
+		req := httptest.NewRequest("POST", "/achievements/bulk-verify", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func TestAchievementServiceImpl_Search(t *testing.T) {
+	mockAchievementRepo := new(MockAchievementRepo)
+	mockStudentRepo := new(MockStudentRepo)
+	mockRefRepo := new(MockReferenceRepo)
+	svc := service.NewAchievementService(
+		mockAchievementRepo,
+		mockStudentRepo,
+		mockRefRepo,
+		new(MockHistoryRepo),
+		new(MockCommentRepo),
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		new(MockFileStorage),
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	claims := &model.Claims{UserID: "admin-1", Role: "admin"}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Get("/achievements/search", svc.Search)
+
+	best, second := primitive.NewObjectID(), primitive.NewObjectID()
+
+	t.Run("Success Ranked Results Keep Mongo Order", func(t *testing.T) {
+		mockAchievementRepo.On("FindTextMatches", mock.Anything, "gemastik", "competition", []string(nil), 1001).
+			Return([]string{best.Hex(), second.Hex()}, nil).Once()
+		mockRefRepo.On("FindAll", mock.Anything, mock.MatchedBy(func(f model.AchievementFilter) bool {
+			return f.Sort == model.SortRelevance && f.Status == "verified" &&
+				len(f.MongoIDs) == 2 && f.MongoIDs[0] == best.Hex()
+		})).Return(&model.PageResult[model.AchievementReferenceAdmin]{
+			Items: []model.AchievementReferenceAdmin{
+				{ID: "ref-id-1", MongoAchievementID: best.Hex(), Status: "verified"},
+				{ID: "ref-id-2", MongoAchievementID: second.Hex(), Status: "verified"},
+			},
+			TotalItems: 2,
+		}, nil).Once()
+		mockAchievementRepo.On("FindAll", mock.Anything, []string{best.Hex(), second.Hex()}).Return([]model.AchievementMongo{
+			{ID: second, Title: "Finalis Gemastik"},
+			{ID: best, Title: "Juara Gemastik"},
+		}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/achievements/search?q=gemastik&type=competition&status=verified", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var respBody model.WebResponse[[]model.AchievementReferenceAdmin]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.Len(t, respBody.Data, 2)
+		assert.Equal(t, "Juara Gemastik", respBody.Data[0].Title)
+		assert.Equal(t, "verified", respBody.Data[0].Status)
+		assert.Equal(t, 2, respBody.Paging.TotalItems)
+		mockRefRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success Student Scoped To Own Achievements", func(t *testing.T) {
+		claims.UserID, claims.Role = "user-123", "mahasiswa"
+		defer func() { claims.UserID, claims.Role = "admin-1", "admin" }()
+		mockStudentRepo.On("FindByUserId", mock.Anything, "user-123").Return(&model.Student{ID: "student-1"}, nil).Once()
+		mockAchievementRepo.On("FindTextMatches", mock.Anything, "robot", "", []string{"student-1"}, 1001).Return([]string{best.Hex()}, nil).Once()
+		mockRefRepo.On("FindByStudent", mock.Anything, "user-123", mock.MatchedBy(func(f model.AchievementFilter) bool {
+			return f.Sort == model.SortRelevance && len(f.MongoIDs) == 1
+		})).Return(&model.PageResult[model.AchievementReferenceStudent]{}, nil).Once()
+		mockAchievementRepo.On("FindAll", mock.Anything, []string(nil)).Return([]model.AchievementMongo{}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/achievements/search?q=robot", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockRefRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success Lecturer Without Advisees Skips Mongo", func(t *testing.T) {
+		claims.UserID, claims.Role = "lecturer-user-1", "lecturer"
+		defer func() { claims.UserID, claims.Role = "admin-1", "admin" }()
+		mockStudentRepo.On("FindIDsByAdvisor", mock.Anything, "lecturer-user-1").Return([]string{}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/achievements/search?q=robot", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockAchievementRepo.AssertNumberOfCalls(t, "FindTextMatches", 2)
+	})
+
+	t.Run("Success No Match Skips Postgres", func(t *testing.T) {
+		mockAchievementRepo.On("FindTextMatches", mock.Anything, "tidak ada", "", []string(nil), 1001).Return([]string{}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/achievements/search?q=tidak+ada", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockRefRepo.AssertNumberOfCalls(t, "FindAll", 1)
+	})
+
+	t.Run("Error Missing Query", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/achievements/search", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestAchievementServiceImpl_FindAll(t *testing.T) {
+	mockAchievementRepo := new(MockAchievementRepo)
+	mockStudentRepo := new(MockStudentRepo)
+	mockRefRepo := new(MockReferenceRepo)
+	svc := service.NewAchievementService(
+		mockAchievementRepo,
+		mockStudentRepo,
+		mockRefRepo,
+		new(MockHistoryRepo),
+		new(MockCommentRepo),
+		service.NewAccessPolicy(),
+		new(MockPointsEngine),
+		new(MockFileStorage),
+		newAttachmentValidator(),
+		new(FakeAttachmentScanner),
+		service.NewURLSigner([]byte("test-secret")),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	claims := &model.Claims{UserID: "admin-1", Role: "admin"}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Get("/achievements", svc.FindAll)
+
+	t.Run("Success Combine Mongo And Postgres Filters", func(t *testing.T) {
+		mongoID := primitive.NewObjectID()
+		mockAchievementRepo.On("FindIDs", mock.Anything, mock.MatchedBy(func(f model.AchievementFilter) bool {
+			return f.AchievementType == "competition" && f.Tag == "coding" && f.Sort == "title" && f.StudentIDs == nil
+		}), 1001).Return([]string{mongoID.Hex()}, nil).Once()
+		mockRefRepo.On("FindAll", mock.Anything, mock.MatchedBy(func(f model.AchievementFilter) bool {
+			return f.Status == "verified" && f.AcademicYear == "2023" && f.Page == 2 && f.Limit == 5 &&
+				len(f.MongoIDs) == 1 && f.MongoIDs[0] == mongoID.Hex()
+		})).Return(&model.PageResult[model.AchievementReferenceAdmin]{
+			Items:      []model.AchievementReferenceAdmin{{ID: "ref-id-1", MongoAchievementID: mongoID.Hex()}},
+			TotalItems: 6,
+		}, nil).Once()
+		mockAchievementRepo.On("FindAll", mock.Anything, []string{mongoID.Hex()}).
+			Return([]model.AchievementMongo{{ID: mongoID, Title: "Gemastik", AchievementType: "competition"}}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/achievements?type=competition&tag=coding&status=verified&academic_year=2023&sort=title&page=2&limit=5", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+		var respBody model.WebResponse[[]model.AchievementReferenceAdmin]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.Len(t, respBody.Data, 1)
+		assert.Equal(t, "Gemastik", respBody.Data[0].Title)
+		assert.Equal(t, 2, respBody.Paging.Page)
+		assert.Equal(t, 6, respBody.Paging.TotalItems)
+		assert.Equal(t, 2, respBody.Paging.TotalPages)
+		assert.Empty(t, respBody.Paging.NextCursor)
+		mockAchievementRepo.AssertExpectations(t)
+		mockRefRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success No Mongo Match Skips Postgres", func(t *testing.T) {
+		mockAchievementRepo.On("FindIDs", mock.Anything, mock.Anything, 1001).Return([]string{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/achievements?q=tidak-ada", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockRefRepo.AssertNumberOfCalls(t, "FindAll", 1)
+	})
+
+	t.Run("Success Lecturer Mongo Filter Scoped To Advisees", func(t *testing.T) {
+		claims.UserID, claims.Role = "lecturer-user-1", "lecturer"
+		defer func() { claims.UserID, claims.Role = "admin-1", "admin" }()
+		mockStudentRepo.On("FindIDsByAdvisor", mock.Anything, "lecturer-user-1").Return([]string{"student-1", "student-2"}, nil).Once()
+		mockAchievementRepo.On("FindIDs", mock.Anything, mock.MatchedBy(func(f model.AchievementFilter) bool {
+			return len(f.StudentIDs) == 2 && f.StudentIDs[0] == "student-1"
+		}), 1001).Return([]string{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/achievements?tag=coding", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockStudentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success Lecturer Without Advisees Skips Mongo", func(t *testing.T) {
+		claims.UserID, claims.Role = "lecturer-user-2", "lecturer"
+		defer func() { claims.UserID, claims.Role = "admin-1", "admin" }()
+		mockStudentRepo.On("FindIDsByAdvisor", mock.Anything, "lecturer-user-2").Return([]string{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/achievements?tag=coding", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockAchievementRepo.AssertNumberOfCalls(t, "FindIDs", 3)
+	})
+
+	t.Run("Success Candidates Over Limit Marked Truncated", func(t *testing.T) {
+		ids := make([]string, 1001)
+		for i := range ids {
+			ids[i] = primitive.NewObjectID().Hex()
+		}
+		mockAchievementRepo.On("FindIDs", mock.Anything, mock.Anything, 1001).Return(ids, nil).Once()
+		mockRefRepo.On("FindAll", mock.Anything, mock.MatchedBy(func(f model.AchievementFilter) bool {
+			return len(f.MongoIDs) == 1000
+		})).Return(&model.PageResult[model.AchievementReferenceAdmin]{TotalItems: 1000}, nil).Once()
+		mockAchievementRepo.On("FindAll", mock.Anything, []string(nil)).Return([]model.AchievementMongo{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/achievements?sort=title", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var respBody model.WebResponse[[]model.AchievementReferenceAdmin]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.True(t, respBody.Paging.Truncated)
+		assert.Equal(t, 1000, respBody.Paging.TotalItems)
+	})
+
+	t.Run("Success Descending Mongo Sort Keeps Top Candidates", func(t *testing.T) {
+		ids := make([]string, 1001)
+		for i := range ids {
+			ids[i] = primitive.NewObjectID().Hex()
+		}
+		// Mongo ranks Z to A; the list gets the kept 1000 ranked A to Z and
+		// applies the direction itself
+		first, last := ids[999], ids[0]
+		mockAchievementRepo.On("FindIDs", mock.Anything, mock.MatchedBy(func(f model.AchievementFilter) bool {
+			return f.Sort == "title" && f.Descending()
+		}), 1001).Return(ids, nil).Once()
+		mockRefRepo.On("FindAll", mock.Anything, mock.MatchedBy(func(f model.AchievementFilter) bool {
+			return len(f.MongoIDs) == 1000 && f.MongoIDs[0] == first && f.MongoIDs[999] == last && f.Order == "desc"
+		})).Return(&model.PageResult[model.AchievementReferenceAdmin]{TotalItems: 1000}, nil).Once()
+		mockAchievementRepo.On("FindAll", mock.Anything, []string(nil)).Return([]model.AchievementMongo{}, nil).Once()
+
+		req := httptest.NewRequest("GET", "/achievements?sort=title&order=desc", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var respBody model.WebResponse[[]model.AchievementReferenceAdmin]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.True(t, respBody.Paging.Truncated)
+		mockRefRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Invalid Sort Column", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/achievements?sort=password", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Error Cursor With Non Keyset Sort", func(t *testing.T) {
+		cursor := model.PageCursor{CreatedAt: time.Now(), ID: "ref-id-1"}.Encode()
+		req := httptest.NewRequest("GET", "/achievements?sort=title&cursor="+cursor, nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Error Cursor With Mongo Filter", func(t *testing.T) {
+		cursor := model.PageCursor{CreatedAt: time.Now(), ID: "ref-id-1"}.Encode()
+		req := httptest.NewRequest("GET", "/achievements?tag=coding&cursor="+cursor, nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockAchievementRepo.AssertNumberOfCalls(t, "FindIDs", 5)
+	})
+
+	t.Run("Error Malformed Cursor", func(t *testing.T) {
+		mockRefRepo.On("FindAll", mock.Anything, mock.Anything).Return(nil, model.ErrInvalidCursor).Once()
+
+		req := httptest.NewRequest("GET", "/achievements?cursor=bukan-cursor", nil)
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
 	})
 }
@@ -0,0 +1,58 @@
+package service_test
+
+import (
+	"testing"
+
+	"prisma/app/model"
+	"prisma/app/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAchievementWorkflow_Transition(t *testing.T) {
+	workflow := service.NewAchievementWorkflow()
+
+	allowed := [][2]string{
+		{model.AchievementStatusDraft, model.AchievementStatusSubmitted},
+		{model.AchievementStatusSubmitted, model.AchievementStatusVerified},
+		{model.AchievementStatusSubmitted, model.AchievementStatusRejected},
+		{model.AchievementStatusRejected, model.AchievementStatusDraft},
+		{model.AchievementStatusSubmitted, model.AchievementStatusNeedsRevision},
+		{model.AchievementStatusNeedsRevision, model.AchievementStatusSubmitted},
+	}
+	for _, tc := range allowed {
+		t.Run(tc[0]+" to "+tc[1], func(t *testing.T) {
+			assert.NoError(t, workflow.Transition(tc[0], tc[1]))
+		})
+	}
+
+	illegal := [][2]string{
+		{model.AchievementStatusDraft, model.AchievementStatusVerified},
+		{model.AchievementStatusDraft, model.AchievementStatusRejected},
+		{model.AchievementStatusVerified, model.AchievementStatusRejected},
+		{model.AchievementStatusVerified, model.AchievementStatusSubmitted},
+		{model.AchievementStatusRejected, model.AchievementStatusVerified},
+		{model.AchievementStatusSubmitted, model.AchievementStatusSubmitted},
+		{model.AchievementStatusDraft, model.AchievementStatusNeedsRevision},
+		{model.AchievementStatusNeedsRevision, model.AchievementStatusVerified},
+	}
+	for _, tc := range illegal {
+		t.Run(tc[0]+" to "+tc[1]+" is rejected", func(t *testing.T) {
+			err := workflow.Transition(tc[0], tc[1])
+			var transitionErr *service.TransitionError
+			assert.ErrorAs(t, err, &transitionErr)
+			assert.Equal(t, tc[0], transitionErr.From)
+			assert.Equal(t, tc[1], transitionErr.To)
+		})
+	}
+}
+
+func TestAchievementWorkflow_CanEdit(t *testing.T) {
+	workflow := service.NewAchievementWorkflow()
+
+	assert.True(t, workflow.CanEdit(model.AchievementStatusDraft))
+	assert.True(t, workflow.CanEdit(model.AchievementStatusNeedsRevision))
+	assert.False(t, workflow.CanEdit(model.AchievementStatusSubmitted))
+	assert.False(t, workflow.CanEdit(model.AchievementStatusVerified))
+	assert.False(t, workflow.CanEdit(model.AchievementStatusRejected))
+}
@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"prisma/app/model"
+	"prisma/app/repository"
+	"prisma/app/service"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// FakeMalwareScanner flags content containing the EICAR test string and fails
+// when Err is set.
+type FakeMalwareScanner struct {
+	Err error
+}
+
+func (f *FakeMalwareScanner) Scan(ctx context.Context, content io.Reader) (*repository.ScanResult, error) {
+	if f.Err != nil {
+		return nil, f.Err
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(data), "EICAR-STANDARD-ANTIVIRUS-TEST-FILE") {
+		return &repository.ScanResult{Infected: true, Signature: "Eicar-Test-Signature"}, nil
+	}
+	return &repository.ScanResult{}, nil
+}
+
+func TestAttachmentScanner_Scan(t *testing.T) {
+	storage := repository.NewLocalFileStorage(t.TempDir())
+	save := func(key string, content string) {
+		err := storage.Save(context.Background(), key, strings.NewReader(content), int64(len(content)), "application/pdf")
+		assert.NoError(t, err)
+	}
+	save("achievements/clean.pdf", "%PDF-1.4 sertifikat")
+	save("achievements/eicar.pdf", `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`)
+	var photo bytes.Buffer
+	png.Encode(&photo, image.NewRGBA(image.Rect(0, 0, 200, 100)))
+	save("achievements/foto.png", photo.String())
+
+	newScanner := func(fake *FakeMalwareScanner) (service.AttachmentScanner, *MockAchievementRepo, *MockCommentRepo) {
+		mockAchievementRepo := new(MockAchievementRepo)
+		mockCommentRepo := new(MockCommentRepo)
+		return service.NewAttachmentScanner(fake, storage, newPreviewGenerator(), mockAchievementRepo, mockCommentRepo, 10, logrus.New()), mockAchievementRepo, mockCommentRepo
+	}
+
+	t.Run("Success Clean Achievement Attachment", func(t *testing.T) {
+		scanner, mockAchievementRepo, _ := newScanner(&FakeMalwareScanner{})
+		mockAchievementRepo.On("SetAttachmentScanStatus", mock.Anything, "mongo-1", "file-1", model.ScanClean, "").Return(nil).Once()
+
+		err := scanner.Scan(context.Background(), model.ScanJob{MongoAchievementID: "mongo-1", AttachmentID: "file-1", StorageKey: "achievements/clean.pdf", ContentType: "application/pdf"})
+
+		assert.NoError(t, err)
+		mockAchievementRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success Clean Image Gets Preview", func(t *testing.T) {
+		scanner, mockAchievementRepo, _ := newScanner(&FakeMalwareScanner{})
+		mockAchievementRepo.On("SetAttachmentScanStatus", mock.Anything, "mongo-1", "file-4", model.ScanClean, "achievements/foto.png.preview.jpg").Return(nil).Once()
+
+		err := scanner.Scan(context.Background(), model.ScanJob{MongoAchievementID: "mongo-1", AttachmentID: "file-4", StorageKey: "achievements/foto.png", ContentType: "image/png"})
+
+		assert.NoError(t, err)
+		mockAchievementRepo.AssertExpectations(t)
+		file, err := storage.Open(context.Background(), "achievements/foto.png.preview.jpg")
+		assert.NoError(t, err)
+		defer file.Close()
+		config, format, err := image.DecodeConfig(file)
+		assert.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, 64, config.Width)
+		assert.Equal(t, 32, config.Height)
+	})
+
+	t.Run("Success Infected Comment Attachment", func(t *testing.T) {
+		scanner, _, mockCommentRepo := newScanner(&FakeMalwareScanner{})
+		mockCommentRepo.On("SetAttachmentScanStatus", mock.Anything, "comment-1", "file-2", model.ScanInfected).Return(nil).Once()
+
+		err := scanner.Scan(context.Background(), model.ScanJob{CommentID: "comment-1", AttachmentID: "file-2", StorageKey: "achievements/eicar.pdf"})
+
+		assert.NoError(t, err)
+		mockCommentRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success Removed File Skipped", func(t *testing.T) {
+		scanner, mockAchievementRepo, _ := newScanner(&FakeMalwareScanner{})
+
+		err := scanner.Scan(context.Background(), model.ScanJob{MongoAchievementID: "mongo-1", AttachmentID: "file-3", StorageKey: "achievements/gone.pdf"})
+
+		assert.NoError(t, err)
+		mockAchievementRepo.AssertNumberOfCalls(t, "SetAttachmentScanStatus", 0)
+	})
+
+	t.Run("Error Scanner Down Leaves Pending", func(t *testing.T) {
+		scanner, mockAchievementRepo, _ := newScanner(&FakeMalwareScanner{Err: errors.New("connect to clamd: refused")})
+
+		err := scanner.Scan(context.Background(), model.ScanJob{MongoAchievementID: "mongo-1", AttachmentID: "file-1", StorageKey: "achievements/clean.pdf"})
+
+		assert.Error(t, err)
+		mockAchievementRepo.AssertNumberOfCalls(t, "SetAttachmentScanStatus", 0)
+	})
+}
@@ -0,0 +1,88 @@
+package service_test
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"prisma/app/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// formFiles parses name/content pairs into the file headers a handler gets
+// from a multipart upload.
+func formFiles(t *testing.T, files ...string) []*multipart.FileHeader {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for i := 0; i < len(files); i += 2 {
+		part, _ := writer.CreateFormFile("attachments", files[i])
+		part.Write([]byte(files[i+1]))
+	}
+	writer.Close()
+
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	return req.MultipartForm.File["attachments"]
+}
+
+func TestAttachmentValidator_Validate(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 100)
+	validator := service.NewAttachmentValidator([]string{"application/pdf", "image/png", "image/jpeg"}, 200, 300)
+
+	t.Run("Success Detects Type From Content", func(t *testing.T) {
+		uploads, err := validator.Validate(formFiles(t, "foto.pdf", png), 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, uploads, 1)
+		assert.Equal(t, "image/png", uploads[0].ContentType)
+		assert.Equal(t, "foto.pdf", uploads[0].FileName)
+	})
+
+	t.Run("Error Every Rejected File Reported", func(t *testing.T) {
+		_, err := validator.Validate(formFiles(t,
+			"ok.png", png,
+			"page.pdf", "<html><body>hi</body></html>",
+			"big.pdf", "%PDF-1.4 "+strings.Repeat("x", 300),
+		), 0)
+
+		var uploadErr *service.UploadError
+		assert.ErrorAs(t, err, &uploadErr)
+		assert.Len(t, uploadErr.Rejections, 2)
+		assert.Equal(t, "page.pdf", uploadErr.Rejections[0].FileName)
+		assert.Contains(t, uploadErr.Rejections[0].Error, "text/html")
+		assert.Equal(t, "big.pdf", uploadErr.Rejections[1].FileName)
+		assert.Contains(t, uploadErr.Rejections[1].Error, "larger than")
+	})
+
+	t.Run("Error Quota Counts Existing Attachments", func(t *testing.T) {
+		_, err := validator.Validate(formFiles(t, "a.png", png, "b.png", png), 150)
+
+		var uploadErr *service.UploadError
+		assert.ErrorAs(t, err, &uploadErr)
+		assert.Len(t, uploadErr.Rejections, 1)
+		assert.Equal(t, "b.png", uploadErr.Rejections[0].FileName)
+		assert.Contains(t, uploadErr.Rejections[0].Error, "quota")
+	})
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	tests := map[string]string{
+		"sertifikat.pdf":                  "sertifikat.pdf",
+		"../../etc/passwd":                "passwd",
+		`..\..\windows\system.ini`:        "system.ini",
+		"piagam juara 1.pdf":              "piagam_juara_1.pdf",
+		".hidden":                         "hidden",
+		"sértifikat;rm -rf.pdf":           "srtifikatrm_-rf.pdf",
+		"..":                              "file",
+		strings.Repeat("a", 150) + ".pdf": strings.Repeat("a", 96) + ".pdf",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, service.SanitizeFileName(name), name)
+	}
+}
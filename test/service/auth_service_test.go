@@ -6,13 +6,16 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"prisma/app/model"
+	"prisma/app/repository"
 	"prisma/app/service"
+	"prisma/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
@@ -51,23 +54,95 @@ func (m *MockUserRepoAuth) Delete(ctx context.Context, UserId string) error { re
 func (m *MockUserRepoAuth) FindById(ctx context.Context, UserId string) (*model.UserProfile, error) {
 	return nil, nil
 }
-func (m *MockUserRepoAuth) FindAll(ctx context.Context) (*[]model.User, error) { return nil, nil }
+func (m *MockUserRepoAuth) FindAll(ctx context.Context, filter model.UserFilter) (*model.PageResult[model.User], error) {
+	return nil, nil
+}
+func (m *MockUserRepoAuth) SetActive(ctx context.Context, UserId string, active bool) error {
+	return nil
+}
+func (m *MockUserRepoAuth) FindByEmail(ctx context.Context, Email string) (*model.User, error) {
+	return nil, nil
+}
+func (m *MockUserRepoAuth) FindPasswordHash(ctx context.Context, UserId string) (string, error) {
+	return "", nil
+}
+func (m *MockUserRepoAuth) UpdatePassword(ctx context.Context, UserId string, PasswordHash string) error {
+	return nil
+}
 
 // 2. Mock Auth Repository (Redis)
 type MockAuthRepo struct {
 	mock.Mock
 }
 
-func (m *MockAuthRepo) Logout(ctx context.Context, RefreshToken string) error {
+func (m *MockAuthRepo) Logout(ctx context.Context, RefreshToken string, jwtKey []byte) error {
+	args := m.Called(ctx, RefreshToken, jwtKey)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepo) TrackRefreshToken(ctx context.Context, RefreshToken string) error {
 	args := m.Called(ctx, RefreshToken)
 	return args.Error(0)
 }
 
-func (m *MockAuthRepo) RefreshToken(ctx context.Context, RefreshToken string, jwtKey []byte) (string, error) {
+func (m *MockAuthRepo) RefreshToken(ctx context.Context, RefreshToken string, jwtKey []byte) (string, string, error) {
 	args := m.Called(ctx, RefreshToken, jwtKey)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
+func (m *MockAuthRepo) TokenVersion(ctx context.Context, UserID string) (int64, error) {
+	args := m.Called(ctx, UserID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAuthRepo) BumpTokenVersion(ctx context.Context, UserID string) error {
+	args := m.Called(ctx, UserID)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepo) SavePasswordReset(ctx context.Context, Token string, UserID string, ttl time.Duration) error {
+	args := m.Called(ctx, Token, UserID, ttl)
+	return args.Error(0)
+}
+
+func (m *MockAuthRepo) ConsumePasswordReset(ctx context.Context, Token string) (string, error) {
+	args := m.Called(ctx, Token)
 	return args.String(0), args.Error(1)
 }
 
+// 3. Mock Session Repository (Redis)
+type MockSessionRepo struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepo) Create(ctx context.Context, session model.Session, ttl time.Duration) error {
+	args := m.Called(ctx, session, ttl)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepo) FindByUser(ctx context.Context, UserID string) ([]model.Session, error) {
+	args := m.Called(ctx, UserID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Session), args.Error(1)
+}
+
+func (m *MockSessionRepo) Touch(ctx context.Context, UserID string, SessionID string, ttl time.Duration) (bool, error) {
+	args := m.Called(ctx, UserID, SessionID, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepo) Revoke(ctx context.Context, UserID string, SessionID string) (bool, error) {
+	args := m.Called(ctx, UserID, SessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepo) RevokeAll(ctx context.Context, UserID string) (int, error) {
+	args := m.Called(ctx, UserID)
+	return args.Int(0), args.Error(1)
+}
+
 // --- HELPER FOR TOKEN ---
 func generateValidRefreshToken(secret []byte) string {
 	// Membuat token dummy yang valid secara struktur JWT agar lolos utils.ValidateToken
@@ -76,6 +151,8 @@ func generateValidRefreshToken(secret []byte) string {
 		"user_id":  "user-123",
 		"username": "testuser",
 		"role":     "mahasiswa",
+		"fam":      "session-1",
+		"jti":      "token-1",
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	t, _ := token.SignedString(secret)
@@ -88,6 +165,7 @@ func TestAuthServiceImpl(t *testing.T) {
 	// Setup Dependencies
 	mockUserRepo := new(MockUserRepoAuth)
 	mockAuthRepo := new(MockAuthRepo)
+	mockSessionRepo := new(MockSessionRepo)
 	logger := logrus.New()
 	secretKey := []byte("secret-key-test") // Secret key dummy
 
@@ -95,6 +173,7 @@ func TestAuthServiceImpl(t *testing.T) {
 	svc := service.NewAuthService(
 		mockUserRepo,
 		mockAuthRepo,
+		mockSessionRepo,
 		logger,
 		secretKey,
 	)
@@ -117,17 +196,25 @@ func TestAuthServiceImpl(t *testing.T) {
 			Username:     "testuser",
 			PasswordHash: string(hashedPassword),
 			RoleName:     "mahasiswa",
+			IsActive:     true,
 		}
 
 		mockUserRepo.On("FindByUsername", mock.Anything, "testuser").Return(userMock, nil)
+		mockAuthRepo.On("TokenVersion", mock.Anything, "user-1").Return(int64(3), nil)
+		mockAuthRepo.On("TrackRefreshToken", mock.Anything, mock.AnythingOfType("string")).Return(nil).Once()
+		mockSessionRepo.On("Create", mock.Anything, mock.MatchedBy(func(session model.Session) bool {
+			return session.UserID == "user-1" && session.Device == "Laptop" && session.UserAgent == "test-agent" && session.ID != ""
+		}), 7*24*time.Hour).Return(nil).Once()
 
 		payload := model.LoginRequest{
 			Username: "testuser",
 			Password: password,
+			Device:   "Laptop",
 		}
 		body, _ := json.Marshal(payload)
 		req := httptest.NewRequest("POST", "/auth/login", bytes.NewReader(body))
 		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "test-agent")
 
 		// Act
 		resp, err := app.Test(req)
@@ -135,12 +222,39 @@ func TestAuthServiceImpl(t *testing.T) {
 		// Assert
 		assert.NoError(t, err)
 		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockSessionRepo.AssertExpectations(t)
 
 		// Cek apakah response mengandung token
 		var respBody model.WebResponse[model.LoginResponse]
 		json.NewDecoder(resp.Body).Decode(&respBody)
 		assert.NotEmpty(t, respBody.Data.Token)
 		assert.NotEmpty(t, respBody.Data.RefreshToken)
+		// Token membawa token version user saat login
+		claims, err := utils.ValidateToken(respBody.Data.Token, secretKey)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), claims.TokenVersion)
+		mockAuthRepo.AssertCalled(t, "TrackRefreshToken", mock.Anything, respBody.Data.RefreshToken)
+	})
+
+	t.Run("Login Track Refresh Token Failed", func(t *testing.T) {
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+		userMock := &model.User{
+			ID:           "user-1",
+			Username:     "redisdown",
+			PasswordHash: string(hashedPassword),
+			IsActive:     true,
+		}
+		mockUserRepo.On("FindByUsername", mock.Anything, "redisdown").Return(userMock, nil)
+		mockAuthRepo.On("TrackRefreshToken", mock.Anything, mock.AnythingOfType("string")).Return(errors.New("redis down")).Once()
+
+		body, _ := json.Marshal(model.LoginRequest{Username: "redisdown", Password: "password123"})
+		req := httptest.NewRequest("POST", "/auth/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
 	})
 
 	t.Run("Login Wrong Password", func(t *testing.T) {
@@ -171,6 +285,28 @@ func TestAuthServiceImpl(t *testing.T) {
 		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
 	})
 
+	t.Run("Login Deactivated Account", func(t *testing.T) {
+		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+		userMock := &model.User{
+			ID:           "user-9",
+			Username:     "alumni",
+			PasswordHash: string(hashedPassword),
+			IsActive:     false,
+		}
+		mockUserRepo.On("FindByUsername", mock.Anything, "alumni").Return(userMock, nil)
+
+		body, _ := json.Marshal(model.LoginRequest{Username: "alumni", Password: "password123"})
+		req := httptest.NewRequest("POST", "/auth/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+		// Tidak ada token maupun sesi yang diterbitkan
+		mockAuthRepo.AssertNotCalled(t, "TokenVersion", mock.Anything, "user-9")
+	})
+
 	t.Run("Login User Not Found", func(t *testing.T) {
 		mockUserRepo.ExpectedCalls = nil
 		mockUserRepo.On("FindByUsername", mock.Anything, "unknown").Return(nil, errors.New("user not found"))
@@ -188,7 +324,7 @@ func TestAuthServiceImpl(t *testing.T) {
 
 	t.Run("Logout Success", func(t *testing.T) {
 		// Arrange
-		mockAuthRepo.On("Logout", mock.Anything, "dummy-refresh-token").Return(nil)
+		mockAuthRepo.On("Logout", mock.Anything, "dummy-refresh-token", secretKey).Return(nil)
 
 		req := httptest.NewRequest("POST", "/auth/logout", nil)
 		// Set Cookie
@@ -208,9 +344,11 @@ func TestAuthServiceImpl(t *testing.T) {
 		// Kita butuh token valid karena service memanggil utils.ValidateToken
 		validToken := generateValidRefreshToken(secretKey)
 		newAccessToken := "new-access-token-from-redis"
+		newRefreshToken := "rotated-refresh-token"
 
 		// Mock Auth Repo harus dipanggil
-		mockAuthRepo.On("RefreshToken", mock.Anything, validToken, secretKey).Return(newAccessToken, nil)
+		mockAuthRepo.On("RefreshToken", mock.Anything, validToken, secretKey).Return(newAccessToken, newRefreshToken, nil).Once()
+		mockSessionRepo.On("Touch", mock.Anything, "user-123", "session-1", 7*24*time.Hour).Return(true, nil).Once()
 
 		req := httptest.NewRequest("POST", "/auth/refresh", nil)
 		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: validToken})
@@ -225,6 +363,41 @@ func TestAuthServiceImpl(t *testing.T) {
 		var respBody model.WebResponse[model.LoginResponse]
 		json.NewDecoder(resp.Body).Decode(&respBody)
 		assert.Equal(t, newAccessToken, respBody.Data.Token)
+		// Token lama diganti dengan token hasil rotasi
+		assert.Equal(t, newRefreshToken, respBody.Data.RefreshToken)
+	})
+
+	t.Run("RefreshToken Revoked Session", func(t *testing.T) {
+		// Sesi sudah dicabut atau kedaluwarsa: token baru tidak diberikan
+		validToken := generateValidRefreshToken(secretKey)
+		mockAuthRepo.On("RefreshToken", mock.Anything, validToken, secretKey).Return("new-access-token", "rotated-refresh-token", nil).Once()
+		mockSessionRepo.On("Touch", mock.Anything, "user-123", "session-1", 7*24*time.Hour).Return(false, nil).Once()
+
+		req := httptest.NewRequest("POST", "/auth/refresh", nil)
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: validToken})
+
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		assert.NotContains(t, string(body), "new-access-token")
+	})
+
+	t.Run("RefreshToken Reused Token", func(t *testing.T) {
+		// Token yang sudah dirotasi dipakai lagi: family dicabut oleh repository
+		validToken := generateValidRefreshToken(secretKey)
+		mockAuthRepo.On("RefreshToken", mock.Anything, validToken, secretKey).Return("", "", repository.ErrRefreshTokenReused).Once()
+		mockSessionRepo.On("Revoke", mock.Anything, "user-123", "session-1").Return(true, nil).Once()
+
+		req := httptest.NewRequest("POST", "/auth/refresh", nil)
+		req.AddCookie(&http.Cookie{Name: "refresh_token", Value: validToken})
+
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+		mockSessionRepo.AssertCalled(t, "Revoke", mock.Anything, "user-123", "session-1")
 	})
 
 	t.Run("RefreshToken Invalid Token", func(t *testing.T) {
@@ -245,3 +418,77 @@ func TestAuthServiceImpl(t *testing.T) {
 		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
 	})
 }
+
+func TestAuthServiceImpl_Sessions(t *testing.T) {
+	mockSessionRepo := new(MockSessionRepo)
+	svc := service.NewAuthService(new(MockUserRepoAuth), new(MockAuthRepo), mockSessionRepo, logrus.New(), []byte("secret-key-test"))
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		claims := &model.Claims{UserID: "user-123", SessionID: "session-2"}
+		c.SetUserContext(context.WithValue(c.UserContext(), "user", claims))
+		return c.Next()
+	})
+	app.Get("/auth/sessions", svc.Sessions)
+	app.Delete("/auth/sessions", svc.RevokeAllSessions)
+	app.Delete("/auth/sessions/:id", svc.RevokeSession)
+	app.Delete("/users/:id/sessions", svc.RevokeUserSessions)
+
+	t.Run("List Marks Current Session", func(t *testing.T) {
+		mockSessionRepo.On("FindByUser", mock.Anything, "user-123").Return([]model.Session{
+			{ID: "session-2", UserID: "user-123", Device: "Laptop"},
+			{ID: "session-1", UserID: "user-123", Device: "Phone"},
+		}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/auth/sessions", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var respBody model.WebResponse[[]model.Session]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.Len(t, respBody.Data, 2)
+		assert.True(t, respBody.Data[0].Current)
+		assert.False(t, respBody.Data[1].Current)
+	})
+
+	t.Run("Revoke One Session", func(t *testing.T) {
+		mockSessionRepo.On("Revoke", mock.Anything, "user-123", "session-1").Return(true, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/auth/sessions/session-1", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Revoke Session Of Another User", func(t *testing.T) {
+		// Repository hanya mencabut sesi milik user yang login
+		mockSessionRepo.On("Revoke", mock.Anything, "user-123", "session-other").Return(false, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/auth/sessions/session-other", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Revoke All My Sessions", func(t *testing.T) {
+		mockSessionRepo.On("RevokeAll", mock.Anything, "user-123").Return(2, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/auth/sessions", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var respBody model.WebResponse[model.RevokeSessionsResponse]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.Equal(t, 2, respBody.Data.Revoked)
+	})
+
+	t.Run("Admin Revokes User Sessions", func(t *testing.T) {
+		mockSessionRepo.On("RevokeAll", mock.Anything, "user-456").Return(3, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/users/user-456/sessions", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockSessionRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,58 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"prisma/app/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newPasswordPolicy is the default policy from config.json.
+func newPasswordPolicy() service.PasswordPolicy {
+	return service.NewPasswordPolicy(service.PasswordRules{MinLength: 8, RequireDigit: true})
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	strict := service.NewPasswordPolicy(service.PasswordRules{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+	})
+
+	t.Run("Success Default Policy", func(t *testing.T) {
+		assert.NoError(t, newPasswordPolicy().Validate("password123"))
+	})
+
+	t.Run("Success Strict Policy", func(t *testing.T) {
+		assert.NoError(t, strict.Validate("Rahasia#2024"))
+	})
+
+	t.Run("Error Lists Every Broken Rule", func(t *testing.T) {
+		err := strict.Validate("abc")
+
+		var policyErr *service.PasswordError
+		assert.ErrorAs(t, err, &policyErr)
+		assert.Equal(t, []string{
+			"must be at least 10 characters",
+			"must contain an uppercase letter",
+			"must contain a digit",
+			"must contain a symbol",
+		}, policyErr.Problems)
+	})
+
+	t.Run("Error Counts Characters Not Bytes", func(t *testing.T) {
+		// 8 karakter (14 byte) lolos, 5 karakter (9 byte) tidak
+		assert.NoError(t, newPasswordPolicy().Validate("пароль12"))
+		assert.Error(t, newPasswordPolicy().Validate("ääää1"))
+	})
+
+	t.Run("Error Longer Than Bcrypt Accepts", func(t *testing.T) {
+		err := newPasswordPolicy().Validate(strings.Repeat("a1", 40))
+
+		assert.ErrorContains(t, err, "at most 72 bytes")
+	})
+}
@@ -0,0 +1,215 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"prisma/app/model"
+	"prisma/app/repository"
+	"prisma/app/service"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// FakeMailSender keeps sent mail instead of delivering it. Mail is sent in
+// the background, so Sent is guarded.
+type FakeMailSender struct {
+	mu   sync.Mutex
+	sent []FakeMail
+}
+
+type FakeMail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+func (f *FakeMailSender) Send(ctx context.Context, to string, subject string, body string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, FakeMail{To: to, Subject: subject, Body: body})
+	return nil
+}
+
+func (f *FakeMailSender) Sent() []FakeMail {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.sent)
+}
+
+// postJSON sends payload and returns the status code. Hashing a new password
+// with bcrypt can outlast the default test timeout, so there is none.
+func postJSON(app *fiber.App, path string, payload any) int {
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest("POST", path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+func TestPasswordServiceImpl(t *testing.T) {
+	mockUserRepo := new(MockUserRepo)
+	mockAuthRepo := new(MockAuthRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	mailer := &FakeMailSender{}
+	svc := service.NewPasswordService(
+		mockUserRepo,
+		mockAuthRepo,
+		mockSessionRepo,
+		newPasswordPolicy(),
+		mailer,
+		"https://prisma.test/reset-password",
+		30*time.Minute,
+		validator.New(),
+		logrus.New(),
+	)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		claims := &model.Claims{UserID: "user-1", Username: "testuser"}
+		c.SetUserContext(context.WithValue(c.UserContext(), "user", claims))
+		return c.Next()
+	})
+	app.Put("/auth/password", svc.ChangePassword)
+	app.Post("/auth/forgot-password", svc.ForgotPassword)
+	app.Post("/auth/reset-password", svc.ResetPassword)
+
+	currentHash, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	newPassword := mock.MatchedBy(func(hash string) bool {
+		return bcrypt.CompareHashAndPassword([]byte(hash), []byte("gantiPass456")) == nil
+	})
+
+	t.Run("Change Success Ends Sessions", func(t *testing.T) {
+		mockUserRepo.On("FindPasswordHash", mock.Anything, "user-1").Return(string(currentHash), nil)
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "user-1").Return(nil).Once()
+		mockUserRepo.On("UpdatePassword", mock.Anything, "user-1", newPassword).Return(nil).Once()
+		mockSessionRepo.On("RevokeAll", mock.Anything, "user-1").Return(2, nil).Once()
+
+		body, _ := json.Marshal(model.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "gantiPass456"})
+		req := httptest.NewRequest("PUT", "/auth/password", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockAuthRepo.AssertExpectations(t)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Change Wrong Current Password", func(t *testing.T) {
+		body, _ := json.Marshal(model.ChangePasswordRequest{CurrentPassword: "salah", NewPassword: "gantiPass456"})
+		req := httptest.NewRequest("PUT", "/auth/password", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockUserRepo.AssertNumberOfCalls(t, "UpdatePassword", 1)
+	})
+
+	t.Run("Change Weak New Password", func(t *testing.T) {
+		body, _ := json.Marshal(model.ChangePasswordRequest{CurrentPassword: "password123", NewPassword: "pendek"})
+		req := httptest.NewRequest("PUT", "/auth/password", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		var respBody model.WebResponse[string]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.Contains(t, respBody.Errors, "at least 8 characters")
+		mockUserRepo.AssertNumberOfCalls(t, "UpdatePassword", 1)
+	})
+
+	var token string
+	t.Run("Forgot Sends Single Use Link", func(t *testing.T) {
+		mockUserRepo.On("FindByEmail", mock.Anything, "maba@univ.ac.id").Return(&model.User{
+			ID: "user-1", Username: "maba2024", Email: "maba@univ.ac.id", FullName: "Mahasiswa Baru", IsActive: true,
+		}, nil).Once()
+		mockAuthRepo.On("SavePasswordReset", mock.Anything, mock.AnythingOfType("string"), "user-1", 30*time.Minute).Return(nil).Once()
+
+		status := postJSON(app, "/auth/forgot-password", model.ForgotPasswordRequest{Email: "maba@univ.ac.id"})
+
+		assert.Equal(t, fiber.StatusOK, status)
+		// Email dikirim di background
+		if assert.Eventually(t, func() bool { return len(mailer.Sent()) == 1 }, time.Second, 5*time.Millisecond) {
+			sent := mailer.Sent()
+			assert.Equal(t, "maba@univ.ac.id", sent[0].To)
+			link := regexp.MustCompile(`https://prisma\.test/reset-password\?token=\S+`).FindString(sent[0].Body)
+			parsed, err := url.Parse(link)
+			assert.NoError(t, err)
+			token = parsed.Query().Get("token")
+			// Token yang dikirim sama dengan yang disimpan
+			mockAuthRepo.AssertCalled(t, "SavePasswordReset", mock.Anything, token, "user-1", 30*time.Minute)
+		}
+	})
+
+	t.Run("Forgot Unknown Email Looks The Same", func(t *testing.T) {
+		lookedUp := make(chan struct{})
+		mockUserRepo.On("FindByEmail", mock.Anything, "siapa@univ.ac.id").Return(nil, repository.ErrUserNotFound).
+			Run(func(mock.Arguments) { close(lookedUp) }).Once()
+
+		status := postJSON(app, "/auth/forgot-password", model.ForgotPasswordRequest{Email: "siapa@univ.ac.id"})
+
+		assert.Equal(t, fiber.StatusOK, status)
+		<-lookedUp
+		assert.Len(t, mailer.Sent(), 1)
+	})
+
+	t.Run("Forgot Deactivated Account Gets No Mail", func(t *testing.T) {
+		lookedUp := make(chan struct{})
+		mockUserRepo.On("FindByEmail", mock.Anything, "alumni@univ.ac.id").Return(&model.User{ID: "user-9", IsActive: false}, nil).
+			Run(func(mock.Arguments) { close(lookedUp) }).Once()
+
+		status := postJSON(app, "/auth/forgot-password", model.ForgotPasswordRequest{Email: "alumni@univ.ac.id"})
+
+		assert.Equal(t, fiber.StatusOK, status)
+		<-lookedUp
+		mockAuthRepo.AssertNotCalled(t, "SavePasswordReset", mock.Anything, mock.Anything, "user-9", mock.Anything)
+		assert.Len(t, mailer.Sent(), 1)
+	})
+
+	t.Run("Reset Success", func(t *testing.T) {
+		mockAuthRepo.On("ConsumePasswordReset", mock.Anything, token).Return("user-1", nil).Once()
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "user-1").Return(nil).Once()
+		mockUserRepo.On("UpdatePassword", mock.Anything, "user-1", newPassword).Return(nil).Once()
+		mockSessionRepo.On("RevokeAll", mock.Anything, "user-1").Return(1, nil).Once()
+
+		status := postJSON(app, "/auth/reset-password", model.ResetPasswordRequest{Token: token, NewPassword: "gantiPass456"})
+
+		assert.Equal(t, fiber.StatusOK, status)
+		mockUserRepo.AssertNumberOfCalls(t, "UpdatePassword", 2)
+	})
+
+	t.Run("Reset Used Token", func(t *testing.T) {
+		mockAuthRepo.On("ConsumePasswordReset", mock.Anything, token).Return("", repository.ErrResetTokenInvalid).Once()
+
+		status := postJSON(app, "/auth/reset-password", model.ResetPasswordRequest{Token: token, NewPassword: "gantiPass456"})
+
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		mockUserRepo.AssertNumberOfCalls(t, "UpdatePassword", 2)
+	})
+
+	t.Run("Reset Weak Password Keeps Token", func(t *testing.T) {
+		status := postJSON(app, "/auth/reset-password", model.ResetPasswordRequest{Token: "token-lain", NewPassword: "pendek"})
+
+		assert.Equal(t, fiber.StatusBadRequest, status)
+		mockAuthRepo.AssertNumberOfCalls(t, "ConsumePasswordReset", 2)
+	})
+}
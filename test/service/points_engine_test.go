@@ -0,0 +1,128 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"prisma/app/model"
+	"prisma/app/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type MockPointRuleRepo struct {
+	mock.Mock
+}
+
+func (m *MockPointRuleRepo) FindLatest(ctx context.Context) (*model.PointRuleSet, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PointRuleSet), args.Error(1)
+}
+
+// Stub method lain
+func (m *MockPointRuleRepo) Create(ctx context.Context, ruleSet model.PointRuleSet) (*model.PointRuleSet, error) {
+	return nil, nil
+}
+func (m *MockPointRuleRepo) FindByVersion(ctx context.Context, version int) (*model.PointRuleSet, error) {
+	return nil, nil
+}
+func (m *MockPointRuleRepo) FindAll(ctx context.Context) ([]model.PointRuleSet, error) {
+	return nil, nil
+}
+
+var pointRules = []model.PointRule{
+	{AchievementType: "competition", Points: 5},
+	{AchievementType: "competition", CompetitionLevel: "national", Points: 30},
+	{AchievementType: "competition", CompetitionLevel: "national", Rank: 1, Points: 75},
+	{AchievementType: "competition", CompetitionLevel: "national", Rank: 1, MedalType: "gold", Points: 80},
+	{AchievementType: "publication", Points: 40},
+}
+
+func TestPointsEngine_Score(t *testing.T) {
+	engine := service.NewPointsEngine(nil, nil)
+
+	tests := []struct {
+		name     string
+		achieved model.AchievementMongo
+		points   int
+	}{
+		{"most specific rule wins", model.AchievementMongo{
+			AchievementType: "competition",
+			Details:         model.AchievementDetails{CompetitionLevel: "national", Rank: 1, MedalType: "Gold"},
+		}, 80},
+		{"rank without medal", model.AchievementMongo{
+			AchievementType: "competition",
+			Details:         model.AchievementDetails{CompetitionLevel: "national", Rank: 1},
+		}, 75},
+		{"level only", model.AchievementMongo{
+			AchievementType: "competition",
+			Details:         model.AchievementDetails{CompetitionLevel: "national", Rank: 4},
+		}, 30},
+		{"type fallback", model.AchievementMongo{
+			AchievementType: "competition",
+			Details:         model.AchievementDetails{CompetitionLevel: "local"},
+		}, 5},
+		{"other type", model.AchievementMongo{AchievementType: "publication"}, 40},
+		{"no matching rule", model.AchievementMongo{AchievementType: "organization"}, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.points, engine.Score(pointRules, tc.achieved))
+		})
+	}
+}
+
+func TestPointsEngine_Award(t *testing.T) {
+	t.Run("Success Award With Latest Version", func(t *testing.T) {
+		mockRuleRepo := new(MockPointRuleRepo)
+		mockAchievementRepo := new(MockAchievementRepo)
+		engine := service.NewPointsEngine(mockRuleRepo, mockAchievementRepo)
+
+		mockRuleRepo.On("FindLatest", mock.Anything).Return(&model.PointRuleSet{Version: 3, Rules: pointRules}, nil)
+		mockAchievementRepo.On("FindById", mock.Anything, "mongo-id-1").Return(&model.AchievementMongo{
+			AchievementType: "competition",
+			Details:         model.AchievementDetails{CompetitionLevel: "national"},
+		}, nil)
+		mockAchievementRepo.On("UpdatePoints", mock.Anything, "mongo-id-1", 30, 3).Return(nil)
+
+		assert.NoError(t, engine.Award(context.Background(), "mongo-id-1"))
+		mockAchievementRepo.AssertExpectations(t)
+	})
+
+	t.Run("No Rule Set Leaves Points Untouched", func(t *testing.T) {
+		mockRuleRepo := new(MockPointRuleRepo)
+		mockAchievementRepo := new(MockAchievementRepo)
+		engine := service.NewPointsEngine(mockRuleRepo, mockAchievementRepo)
+
+		mockRuleRepo.On("FindLatest", mock.Anything).Return(nil, sql.ErrNoRows)
+
+		assert.NoError(t, engine.Award(context.Background(), "mongo-id-1"))
+		mockAchievementRepo.AssertNotCalled(t, "UpdatePoints", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestPointsEngine_Recalculate(t *testing.T) {
+	mockAchievementRepo := new(MockAchievementRepo)
+	engine := service.NewPointsEngine(new(MockPointRuleRepo), mockAchievementRepo)
+
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+	ids := []string{first.Hex(), second.Hex()}
+	mockAchievementRepo.On("FindAll", mock.Anything, ids).Return([]model.AchievementMongo{
+		{ID: first, AchievementType: "publication"},
+		{ID: second, AchievementType: "organization"},
+	}, nil)
+	mockAchievementRepo.On("UpdatePoints", mock.Anything, first.Hex(), 40, 1).Return(nil)
+	mockAchievementRepo.On("UpdatePoints", mock.Anything, second.Hex(), 0, 1).Return(nil)
+
+	updated, err := engine.Recalculate(context.Background(), model.PointRuleSet{Version: 1, Rules: pointRules}, ids)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated)
+	mockAchievementRepo.AssertExpectations(t)
+}
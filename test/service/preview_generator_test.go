@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"prisma/app/service"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewGenerator_Generate(t *testing.T) {
+	generator := service.NewPreviewGenerator(100, "", time.Second, logrus.New())
+
+	t.Run("Success Scales Image Down", func(t *testing.T) {
+		var src bytes.Buffer
+		png.Encode(&src, image.NewNRGBA(image.Rect(0, 0, 300, 600)))
+
+		preview, err := generator.Generate(context.Background(), &src, "image/png")
+
+		assert.NoError(t, err)
+		config, format, err := image.DecodeConfig(bytes.NewReader(preview))
+		assert.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, 50, config.Width)
+		assert.Equal(t, 100, config.Height)
+	})
+
+	t.Run("Success Small Image Keeps Size", func(t *testing.T) {
+		var src bytes.Buffer
+		jpeg.Encode(&src, image.NewRGBA(image.Rect(0, 0, 40, 30)), nil)
+
+		preview, err := generator.Generate(context.Background(), &src, "image/jpeg")
+
+		assert.NoError(t, err)
+		config, _, err := image.DecodeConfig(bytes.NewReader(preview))
+		assert.NoError(t, err)
+		assert.Equal(t, 40, config.Width)
+		assert.Equal(t, 30, config.Height)
+	})
+
+	t.Run("Error No Preview Without PDF Renderer", func(t *testing.T) {
+		_, err := generator.Generate(context.Background(), strings.NewReader("%PDF-1.4"), "application/pdf")
+
+		assert.ErrorIs(t, err, service.ErrNoPreview)
+	})
+
+	t.Run("Error Corrupt Image", func(t *testing.T) {
+		_, err := generator.Generate(context.Background(), strings.NewReader("\\x89PNG broken"), "image/png")
+
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, service.ErrNoPreview)
+	})
+}
@@ -0,0 +1,87 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"prisma/app/model"
+	"prisma/app/repository"
+	"prisma/app/service"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPurger_Purge(t *testing.T) {
+	before := time.Now().Add(-30 * 24 * time.Hour)
+
+	t.Run("Success Removes Both Stores", func(t *testing.T) {
+		mockAchievementRepo := new(MockAchievementRepo)
+		mockRefRepo := new(MockReferenceRepo)
+		mockCommentRepo := new(MockCommentRepo)
+		mockStorage := new(MockFileStorage)
+		purger := service.NewPurger(mockAchievementRepo, mockRefRepo, mockCommentRepo, mockStorage, logrus.New())
+
+		withDoc, withoutDoc := primitive.NewObjectID(), primitive.NewObjectID()
+		mockRefRepo.On("FindDeletedBefore", mock.Anything, before, 100).Return([]model.ReferenceLink{
+			{ID: "ref-1", MongoAchievementID: withDoc.Hex()},
+			{ID: "ref-2", MongoAchievementID: withoutDoc.Hex()},
+		}, nil).Once()
+		mockRefRepo.On("FindDeletedBefore", mock.Anything, before, 100).Return([]model.ReferenceLink{}, nil).Once()
+
+		mockAchievementRepo.On("FindById", mock.Anything, withDoc.Hex()).Return(&model.AchievementMongo{
+			ID:          withDoc,
+			Attachments: []model.Attachment{{StorageKey: "achievements/1_sertifikat.pdf"}},
+		}, nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-1").Return([]model.AchievementComment{
+			{Attachments: []model.Attachment{{FileURL: "/uploads/achievements/2_revisi.pdf"}}},
+		}, nil).Once()
+		mockAchievementRepo.On("Delete", mock.Anything, withDoc.Hex()).Return(nil).Once()
+		mockRefRepo.On("Purge", mock.Anything, "ref-1").Return(nil).Once()
+		mockStorage.On("Delete", mock.Anything, "achievements/1_sertifikat.pdf").Return(nil).Once()
+		mockStorage.On("Delete", mock.Anything, "achievements/2_revisi.pdf").Return(nil).Once()
+
+		// Dokumen Mongo sudah hilang: reference tetap dihapus
+		mockAchievementRepo.On("FindById", mock.Anything, withoutDoc.Hex()).Return(nil, repository.ErrAchievementNotFound).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-2").Return([]model.AchievementComment{}, nil).Once()
+		mockRefRepo.On("Purge", mock.Anything, "ref-2").Return(nil).Once()
+
+		purged, err := purger.Purge(context.Background(), before)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, purged)
+		mockAchievementRepo.AssertExpectations(t)
+		mockRefRepo.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Error Reference Failed Puts Document Back", func(t *testing.T) {
+		mockAchievementRepo := new(MockAchievementRepo)
+		mockRefRepo := new(MockReferenceRepo)
+		mockCommentRepo := new(MockCommentRepo)
+		mockStorage := new(MockFileStorage)
+		purger := service.NewPurger(mockAchievementRepo, mockRefRepo, mockCommentRepo, mockStorage, logrus.New())
+
+		doc := &model.AchievementMongo{ID: primitive.NewObjectID(), Title: "Gemastik"}
+		mockRefRepo.On("FindDeletedBefore", mock.Anything, before, 100).Return([]model.ReferenceLink{
+			{ID: "ref-1", MongoAchievementID: doc.ID.Hex()},
+		}, nil).Once()
+		mockAchievementRepo.On("FindById", mock.Anything, doc.ID.Hex()).Return(doc, nil).Once()
+		mockCommentRepo.On("FindByAchievementID", mock.Anything, "ref-1").Return([]model.AchievementComment{}, nil).Once()
+		mockAchievementRepo.On("Delete", mock.Anything, doc.ID.Hex()).Return(nil).Once()
+		mockRefRepo.On("Purge", mock.Anything, "ref-1").Return(errors.New("connection reset")).Once()
+		mockAchievementRepo.On("Replace", mock.Anything, *doc).Return(nil).Once()
+
+		purged, err := purger.Purge(context.Background(), before)
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, purged)
+		mockStorage.AssertNumberOfCalls(t, "Delete", 0)
+		mockAchievementRepo.AssertExpectations(t)
+		mockRefRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,91 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"prisma/app/model"
+	"prisma/app/service"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReconciler_Run(t *testing.T) {
+	mockStorage := new(MockFileStorage)
+	setup := func() (*MockAchievementRepo, *MockReferenceRepo, service.Reconciler) {
+		mockAchievementRepo := new(MockAchievementRepo)
+		mockRefRepo := new(MockReferenceRepo)
+		return mockAchievementRepo, mockRefRepo, service.NewReconciler(mockAchievementRepo, mockRefRepo, mockStorage, logrus.New())
+	}
+
+	// Dua batch dokumen Mongo (doc-3 yatim) dan dua batch reference (ref-2 menggantung)
+	expectScan := func(mockAchievementRepo *MockAchievementRepo, mockRefRepo *MockReferenceRepo) {
+		mockAchievementRepo.On("FindIDsAfter", mock.Anything, "", mock.Anything, 2).Return([]string{"doc-1", "doc-2"}, nil).Once()
+		mockAchievementRepo.On("FindIDsAfter", mock.Anything, "doc-2", mock.Anything, 2).Return([]string{"doc-3"}, nil).Once()
+		mockAchievementRepo.On("FindIDsAfter", mock.Anything, "doc-3", mock.Anything, 2).Return([]string{}, nil).Once()
+		mockRefRepo.On("FindExistingMongoIDs", mock.Anything, []string{"doc-1", "doc-2"}).Return([]string{"doc-1", "doc-2"}, nil).Once()
+		mockRefRepo.On("FindExistingMongoIDs", mock.Anything, []string{"doc-3"}).Return([]string{}, nil).Once()
+
+		mockRefRepo.On("FindLinks", mock.Anything, "", 2).Return([]model.ReferenceLink{
+			{ID: "ref-1", MongoAchievementID: "doc-1"},
+			{ID: "ref-2", MongoAchievementID: "doc-gone"},
+		}, nil).Once()
+		mockRefRepo.On("FindLinks", mock.Anything, "ref-2", 2).Return([]model.ReferenceLink{
+			{ID: "ref-3", MongoAchievementID: "doc-2"},
+		}, nil).Once()
+		mockRefRepo.On("FindLinks", mock.Anything, "ref-3", 2).Return([]model.ReferenceLink{}, nil).Once()
+		mockAchievementRepo.On("FindExistingIDs", mock.Anything, []string{"doc-1", "doc-gone"}).Return([]string{"doc-1"}, nil).Once()
+		mockAchievementRepo.On("FindExistingIDs", mock.Anything, []string{"doc-2"}).Return([]string{"doc-2"}, nil).Once()
+	}
+
+	t.Run("Success Report Only", func(t *testing.T) {
+		mockAchievementRepo, mockRefRepo, reconciler := setup()
+		expectScan(mockAchievementRepo, mockRefRepo)
+
+		report, err := reconciler.Run(context.Background(), model.ReconcileRequest{BatchSize: 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, report.ScannedDocuments)
+		assert.Equal(t, 3, report.ScannedReferences)
+		assert.Equal(t, []string{"doc-3"}, report.OrphanDocuments)
+		assert.Equal(t, []string{"ref-2"}, report.DanglingReferences)
+		assert.False(t, report.Repaired)
+		mockAchievementRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		mockRefRepo.AssertNotCalled(t, "MarkBroken", mock.Anything, mock.Anything)
+		mockAchievementRepo.AssertExpectations(t)
+		mockRefRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success Repair", func(t *testing.T) {
+		mockAchievementRepo, mockRefRepo, reconciler := setup()
+		expectScan(mockAchievementRepo, mockRefRepo)
+		mockAchievementRepo.On("FindById", mock.Anything, "doc-3").Return(&model.AchievementMongo{
+			Attachments: []model.Attachment{{StorageKey: "uploads/doc-3/sertifikat.pdf", PreviewKey: "uploads/doc-3/sertifikat.pdf.preview.jpg"}},
+		}, nil).Once()
+		mockAchievementRepo.On("Delete", mock.Anything, "doc-3").Return(nil).Once()
+		mockStorage.On("Delete", mock.Anything, "uploads/doc-3/sertifikat.pdf").Return(nil).Once()
+		mockStorage.On("Delete", mock.Anything, "uploads/doc-3/sertifikat.pdf.preview.jpg").Return(nil).Once()
+		mockRefRepo.On("MarkBroken", mock.Anything, []string{"ref-2"}).Return(nil).Once()
+
+		report, err := reconciler.Run(context.Background(), model.ReconcileRequest{Repair: true, BatchSize: 2})
+
+		assert.NoError(t, err)
+		assert.True(t, report.Repaired)
+		mockAchievementRepo.AssertExpectations(t)
+		mockRefRepo.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("Error Stops Scan", func(t *testing.T) {
+		mockAchievementRepo, _, reconciler := setup()
+		mockAchievementRepo.On("FindIDsAfter", mock.Anything, "", mock.Anything, 2).Return(nil, errors.New("mongo down")).Once()
+
+		report, err := reconciler.Run(context.Background(), model.ReconcileRequest{BatchSize: 2})
+
+		assert.Error(t, err)
+		assert.Equal(t, 0, report.ScannedDocuments)
+	})
+}
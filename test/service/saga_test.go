@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"prisma/app/service"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaga_Run(t *testing.T) {
+	step := func(name string, calls *[]string, fail error, undoFail error) service.SagaStep {
+		return service.SagaStep{
+			Name: name,
+			Action: func(ctx context.Context) error {
+				*calls = append(*calls, name)
+				return fail
+			},
+			Compensate: func(ctx context.Context) error {
+				*calls = append(*calls, "undo "+name)
+				return undoFail
+			},
+		}
+	}
+
+	t.Run("Success Runs Every Step", func(t *testing.T) {
+		var calls []string
+		err := service.NewSaga(logrus.New()).
+			Step(step("mongo", &calls, nil, nil)).
+			Step(step("postgres", &calls, nil, nil)).
+			Run(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"mongo", "postgres"}, calls)
+	})
+
+	t.Run("Error Compensates Completed Steps In Reverse", func(t *testing.T) {
+		var calls []string
+		cause := errors.New("insert failed")
+		err := service.NewSaga(logrus.New()).
+			Step(step("first", &calls, nil, nil)).
+			Step(step("second", &calls, nil, nil)).
+			Step(step("third", &calls, cause, nil)).
+			Run(context.Background())
+
+		var sagaErr *service.SagaError
+		assert.ErrorAs(t, err, &sagaErr)
+		assert.ErrorIs(t, err, cause)
+		assert.Equal(t, "third", sagaErr.Step)
+		assert.Empty(t, sagaErr.Compensations)
+		assert.Equal(t, []string{"first", "second", "third", "undo second", "undo first"}, calls)
+	})
+
+	t.Run("Error Reports Failed Compensation", func(t *testing.T) {
+		var calls []string
+		err := service.NewSaga(logrus.New()).
+			Step(step("mongo", &calls, nil, errors.New("mongo down"))).
+			Step(step("postgres", &calls, errors.New("commit failed"), nil)).
+			Run(context.Background())
+
+		var sagaErr *service.SagaError
+		assert.ErrorAs(t, err, &sagaErr)
+		assert.Len(t, sagaErr.Compensations, 1)
+		assert.Contains(t, err.Error(), "mongo down")
+	})
+
+	t.Run("Compensation Ignores Cancelled Request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		var undoErr error
+		err := service.NewSaga(logrus.New()).
+			Step(service.SagaStep{
+				Name:   "mongo",
+				Action: func(ctx context.Context) error { return nil },
+				Compensate: func(ctx context.Context) error {
+					undoErr = ctx.Err()
+					return nil
+				},
+			}).
+			Step(service.SagaStep{
+				Name: "postgres",
+				Action: func(ctx context.Context) error {
+					cancel()
+					return ctx.Err()
+				},
+			}).
+			Run(ctx)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NoError(t, undoErr)
+	})
+}
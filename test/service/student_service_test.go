@@ -0,0 +1,97 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"prisma/app/model"
+	"prisma/app/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock Analytics Repository
+type MockAnalyticsRepo struct {
+	mock.Mock
+}
+
+func (m *MockAnalyticsRepo) Statistics(ctx context.Context) ([]model.Statistics, error) {
+	return nil, nil
+}
+func (m *MockAnalyticsRepo) Reporting(ctx context.Context, id string) ([]*model.Statistics, error) {
+	return nil, nil
+}
+func (m *MockAnalyticsRepo) Points(ctx context.Context, id string) ([]model.PeriodPoints, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.PeriodPoints), args.Error(1)
+}
+
+func TestStudentServiceImpl_Points(t *testing.T) {
+	mockStudentRepo := new(MockStudentRepo)
+	mockAnalyticsRepo := new(MockAnalyticsRepo)
+	svc := service.NewStudentService(mockStudentRepo, new(MockReferenceRepo), mockAnalyticsRepo, service.NewAccessPolicy())
+
+	claims := &model.Claims{UserID: "user-123", Role: "mahasiswa"}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		ctx := context.WithValue(c.UserContext(), "user", claims)
+		c.SetUserContext(ctx)
+		return c.Next()
+	})
+	app.Get("/students/:id/points", svc.Points)
+
+	owner := &model.ResourceOwner{StudentID: "student-id-1", UserID: "user-123", AdvisorUserID: "lecturer-user-1"}
+
+	t.Run("Success Own Points", func(t *testing.T) {
+		mockStudentRepo.On("FindOwner", mock.Anything, "student-id-1").Return(owner, nil).Once()
+		mockAnalyticsRepo.On("Points", mock.Anything, "student-id-1").Return([]model.PeriodPoints{{Points: 30}, {Points: 20}}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/students/student-id-1/points", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var respBody model.WebResponse[model.StudentPoints]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.Equal(t, 50, respBody.Data.Total)
+	})
+
+	t.Run("Success Advisor Reads Advisee Points", func(t *testing.T) {
+		claims.UserID, claims.Role = "lecturer-user-1", "lecturer"
+		defer func() { claims.UserID, claims.Role = "user-123", "mahasiswa" }()
+		mockStudentRepo.On("FindOwner", mock.Anything, "student-id-1").Return(owner, nil).Once()
+		mockAnalyticsRepo.On("Points", mock.Anything, "student-id-1").Return([]model.PeriodPoints{}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/students/student-id-1/points", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Error Other Student Points", func(t *testing.T) {
+		mockStudentRepo.On("FindOwner", mock.Anything, "student-id-2").
+			Return(&model.ResourceOwner{StudentID: "student-id-2", UserID: "user-456"}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/students/student-id-2/points", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+		mockAnalyticsRepo.AssertNumberOfCalls(t, "Points", 2)
+	})
+
+	t.Run("Error Unknown Student", func(t *testing.T) {
+		mockStudentRepo.On("FindOwner", mock.Anything, "missing").Return(nil, sql.ErrNoRows).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/students/missing/points", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	})
+}
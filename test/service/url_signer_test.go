@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"prisma/app/model"
+	"prisma/app/service"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLSigner_Verify(t *testing.T) {
+	signer := service.NewURLSigner([]byte("test-secret"))
+	grant := model.AttachmentGrant{
+		AchievementID: "ref-id-1",
+		AttachmentID:  "file-1",
+		ExpiresAt:     time.Now().Add(time.Minute).Truncate(time.Second),
+	}
+
+	t.Run("Success Round Trip", func(t *testing.T) {
+		verified, err := signer.Verify(signer.Sign(grant))
+
+		assert.NoError(t, err)
+		assert.Equal(t, grant.AchievementID, verified.AchievementID)
+		assert.Equal(t, grant.AttachmentID, verified.AttachmentID)
+		assert.True(t, grant.ExpiresAt.Equal(verified.ExpiresAt))
+	})
+
+	t.Run("Error Tampered Payload", func(t *testing.T) {
+		other := signer.Sign(model.AttachmentGrant{AchievementID: "ref-id-2", AttachmentID: "file-1", ExpiresAt: grant.ExpiresAt})
+		payload, _, _ := strings.Cut(other, ".")
+		_, signature, _ := strings.Cut(signer.Sign(grant), ".")
+
+		_, err := signer.Verify(payload + "." + signature)
+
+		assert.ErrorIs(t, err, service.ErrInvalidSignature)
+	})
+
+	t.Run("Error Other Secret", func(t *testing.T) {
+		_, err := service.NewURLSigner([]byte("other-secret")).Verify(signer.Sign(grant))
+
+		assert.ErrorIs(t, err, service.ErrInvalidSignature)
+	})
+
+	t.Run("Error Malformed Token", func(t *testing.T) {
+		_, err := signer.Verify("not-a-token")
+
+		assert.ErrorIs(t, err, service.ErrInvalidSignature)
+	})
+
+	t.Run("Error Expired", func(t *testing.T) {
+		expired := grant
+		expired.ExpiresAt = time.Now().Add(-time.Second)
+
+		_, err := signer.Verify(signer.Sign(expired))
+
+		assert.ErrorIs(t, err, service.ErrLinkExpired)
+	})
+}
@@ -6,10 +6,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"prisma/app/model"
+	"prisma/app/repository"
 	"prisma/app/service"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -41,23 +43,59 @@ func (m *MockUserRepo) Update(ctx context.Context, User model.User) (*model.User
 	return nil, nil
 }
 func (m *MockUserRepo) UpdateRole(ctx context.Context, tx *sql.Tx, User model.User) (*model.User, error) {
-	return nil, nil
+	args := m.Called(ctx, tx, User)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+func (m *MockUserRepo) Delete(ctx context.Context, UserId string) error {
+	args := m.Called(ctx, UserId)
+	return args.Error(0)
 }
-func (m *MockUserRepo) Delete(ctx context.Context, UserId string) error { return nil }
 func (m *MockUserRepo) FindById(ctx context.Context, UserId string) (*model.UserProfile, error) {
-	return nil, nil
+	args := m.Called(ctx, UserId)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.UserProfile), args.Error(1)
+}
+func (m *MockUserRepo) SetActive(ctx context.Context, UserId string, active bool) error {
+	args := m.Called(ctx, UserId, active)
+	return args.Error(0)
+}
+func (m *MockUserRepo) FindAll(ctx context.Context, filter model.UserFilter) (*model.PageResult[model.User], error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.PageResult[model.User]), args.Error(1)
 }
-func (m *MockUserRepo) FindAll(ctx context.Context) (*[]model.User, error) { return nil, nil }
 func (m *MockUserRepo) FindByUsername(ctx context.Context, Username string) (*model.User, error) {
 	return nil, nil
 }
+func (m *MockUserRepo) FindByEmail(ctx context.Context, Email string) (*model.User, error) {
+	args := m.Called(ctx, Email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.User), args.Error(1)
+}
+func (m *MockUserRepo) FindPasswordHash(ctx context.Context, UserId string) (string, error) {
+	args := m.Called(ctx, UserId)
+	return args.String(0), args.Error(1)
+}
+func (m *MockUserRepo) UpdatePassword(ctx context.Context, UserId string, PasswordHash string) error {
+	args := m.Called(ctx, UserId, PasswordHash)
+	return args.Error(0)
+}
 
 // 3. Mock Lecturer Repository
 type MockLecturerRepo struct {
 	mock.Mock
 }
 
-func (m *MockLecturerRepo) FindAll(ctx context.Context) ([]model.UserProfile, error) {
+func (m *MockLecturerRepo) FindAll(ctx context.Context, page model.PageRequest) (*model.PageResult[model.UserProfile], error) {
 	return nil, nil
 }
 
@@ -102,6 +140,9 @@ func TestUserServiceImpl_Create(t *testing.T) {
 		mockUserRepo,
 		mockStudentRepo,
 		mockLecturerRepo,
+		new(MockAuthRepo),
+		new(MockSessionRepo),
+		newPasswordPolicy(),
 		db, // Inject DB mock disini
 		validate,
 		logger,
@@ -180,7 +221,7 @@ func TestUserServiceImpl_Create(t *testing.T) {
 		payload := model.UserCreateRequest{
 			Username:       "failuser",
 			Email:          "fail@test.com",
-			Password:       "pass",
+			Password:       "password123",
 			FullName:       "Fail User",
 			RoleID:         roleStudent,
 			StudentProfile: &model.StudentCreate{StudentID: "1"},
@@ -207,6 +248,26 @@ func TestUserServiceImpl_Create(t *testing.T) {
 		assert.NoError(t, sqlMock.ExpectationsWereMet())
 	})
 
+	t.Run("Error Password Policy", func(t *testing.T) {
+		payload := model.UserCreateRequest{
+			Username: "lemah",
+			Email:    "lemah@univ.ac.id",
+			Password: "pass",
+			FullName: "Password Lemah",
+			RoleID:   roleStudent,
+		}
+
+		// Ditolak sebelum transaksi dimulai
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest("POST", "/users", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
 	t.Run("Error Validation Missing Field", func(t *testing.T) {
 		// Arrange: Payload tidak lengkap (RoleID required misal)
 		payload := model.UserCreateRequest{
@@ -226,3 +287,256 @@ func TestUserServiceImpl_Create(t *testing.T) {
 		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
 	})
 }
+
+func TestUserServiceImpl_Delete(t *testing.T) {
+	mockUserRepo := new(MockUserRepo)
+	mockAuthRepo := new(MockAuthRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	svc := service.NewUserService(
+		mockUserRepo,
+		new(MockStudentRepo),
+		new(MockLecturerRepo),
+		mockAuthRepo,
+		mockSessionRepo,
+		newPasswordPolicy(),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		claims := &model.Claims{UserID: "admin-1", Role: "admin"}
+		c.SetUserContext(context.WithValue(c.UserContext(), "user", claims))
+		return c.Next()
+	})
+	app.Delete("/users/:id", svc.Delete)
+
+	t.Run("Success Invalidates Issued Tokens", func(t *testing.T) {
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "user-1").Return(nil).Once()
+		mockUserRepo.On("Delete", mock.Anything, "user-1").Return(nil).Once()
+		mockSessionRepo.On("RevokeAll", mock.Anything, "user-1").Return(1, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/users/user-1", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockAuthRepo.AssertExpectations(t)
+		mockUserRepo.AssertExpectations(t)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Bump Failed Keeps User", func(t *testing.T) {
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "user-2").Return(errors.New("redis down")).Once()
+
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/users/user-2", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+		mockUserRepo.AssertNumberOfCalls(t, "Delete", 1)
+	})
+
+	t.Run("Error Own Account", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("DELETE", "/users/admin-1", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockUserRepo.AssertNumberOfCalls(t, "Delete", 1)
+	})
+}
+
+func TestUserServiceImpl_Deactivate(t *testing.T) {
+	mockUserRepo := new(MockUserRepo)
+	mockAuthRepo := new(MockAuthRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	svc := service.NewUserService(
+		mockUserRepo,
+		new(MockStudentRepo),
+		new(MockLecturerRepo),
+		mockAuthRepo,
+		mockSessionRepo,
+		newPasswordPolicy(),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		claims := &model.Claims{UserID: "admin-1", Role: "admin"}
+		c.SetUserContext(context.WithValue(c.UserContext(), "user", claims))
+		return c.Next()
+	})
+	app.Post("/users/:id/deactivate", svc.Deactivate)
+	app.Post("/users/:id/reactivate", svc.Reactivate)
+
+	t.Run("Success Deactivate Revokes Sessions", func(t *testing.T) {
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "user-1").Return(nil).Once()
+		mockUserRepo.On("SetActive", mock.Anything, "user-1", false).Return(nil).Once()
+		mockSessionRepo.On("RevokeAll", mock.Anything, "user-1").Return(2, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/users/user-1/deactivate", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Success Reactivate", func(t *testing.T) {
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "user-1").Return(nil).Once()
+		mockUserRepo.On("SetActive", mock.Anything, "user-1", true).Return(nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/users/user-1/reactivate", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		// Sesi hanya dicabut saat menonaktifkan
+		mockSessionRepo.AssertNumberOfCalls(t, "RevokeAll", 1)
+	})
+
+	t.Run("Error User Not Found", func(t *testing.T) {
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "missing").Return(nil).Once()
+		mockUserRepo.On("SetActive", mock.Anything, "missing", false).Return(repository.ErrUserNotFound).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/users/missing/deactivate", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+		mockSessionRepo.AssertNumberOfCalls(t, "RevokeAll", 1)
+	})
+
+	t.Run("Error Database Failure", func(t *testing.T) {
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "user-2").Return(nil).Once()
+		mockUserRepo.On("SetActive", mock.Anything, "user-2", false).Return(errors.New("connection refused")).Once()
+
+		resp, err := app.Test(httptest.NewRequest("POST", "/users/user-2/deactivate", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+		mockSessionRepo.AssertNumberOfCalls(t, "RevokeAll", 1)
+	})
+
+	t.Run("Error Own Account", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("POST", "/users/admin-1/deactivate", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockUserRepo.AssertNumberOfCalls(t, "SetActive", 4)
+	})
+}
+
+func TestUserServiceImpl_FindAll(t *testing.T) {
+	mockUserRepo := new(MockUserRepo)
+	svc := service.NewUserService(
+		mockUserRepo,
+		new(MockStudentRepo),
+		new(MockLecturerRepo),
+		new(MockAuthRepo),
+		new(MockSessionRepo),
+		newPasswordPolicy(),
+		nil,
+		validator.New(),
+		logrus.New(),
+	)
+
+	app := fiber.New()
+	app.Get("/users", svc.FindAll)
+
+	t.Run("Success Filter Inactive", func(t *testing.T) {
+		mockUserRepo.On("FindAll", mock.Anything, mock.MatchedBy(func(f model.UserFilter) bool {
+			return f.Status == model.UserStatusInactive
+		})).Return(&model.PageResult[model.User]{
+			Items:      []model.User{{ID: "user-1", Username: "alumni", IsActive: false}},
+			TotalItems: 1,
+		}, nil).Once()
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/users?status=inactive", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var respBody model.WebResponse[[]model.UserResponse]
+		json.NewDecoder(resp.Body).Decode(&respBody)
+		assert.Len(t, respBody.Data, 1)
+		assert.False(t, respBody.Data[0].IsActive)
+	})
+
+	t.Run("Error Unknown Status", func(t *testing.T) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/users?status=banned", nil))
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		mockUserRepo.AssertNumberOfCalls(t, "FindAll", 1)
+	})
+}
+
+func TestUserServiceImpl_UpdateRole(t *testing.T) {
+	db, sqlMock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mockUserRepo := new(MockUserRepo)
+	mockAuthRepo := new(MockAuthRepo)
+	mockSessionRepo := new(MockSessionRepo)
+	svc := service.NewUserService(
+		mockUserRepo,
+		new(MockStudentRepo),
+		new(MockLecturerRepo),
+		mockAuthRepo,
+		mockSessionRepo,
+		newPasswordPolicy(),
+		db,
+		validator.New(),
+		logrus.New(),
+	)
+
+	app := fiber.New()
+	app.Put("/users/:id/role", svc.UpdateRole)
+
+	newRequest := func() *http.Request {
+		body, _ := json.Marshal(model.UserUpdateRole{RoleID: "33333333-3333-3333-3333-333333333333"})
+		req := httptest.NewRequest("PUT", "/users/user-1/role", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	t.Run("Success Bumps Version After Commit And Revokes Sessions", func(t *testing.T) {
+		mockUserRepo.On("FindById", mock.Anything, "user-1").Return(&model.UserProfile{
+			User:       model.User{ID: "user-1", Username: "dosen1"},
+			LecturerID: sql.NullString{String: "lecturer-1", Valid: true},
+		}, nil).Once()
+		sqlMock.ExpectBegin()
+		mockUserRepo.On("UpdateRole", mock.Anything, mock.Anything, mock.Anything).
+			Return(&model.User{ID: "user-1", RoleId: "33333333-3333-3333-3333-333333333333"}, nil).Once()
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "user-1").Return(nil).Twice()
+		sqlMock.ExpectCommit()
+		mockSessionRepo.On("RevokeAll", mock.Anything, "user-1").Return(1, nil).Once()
+
+		resp, err := app.Test(newRequest())
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		mockAuthRepo.AssertNumberOfCalls(t, "BumpTokenVersion", 2)
+		mockSessionRepo.AssertExpectations(t)
+	})
+
+	t.Run("Error Commit Failed Leaves Sessions", func(t *testing.T) {
+		mockUserRepo.On("FindById", mock.Anything, "user-1").Return(&model.UserProfile{
+			User: model.User{ID: "user-1", Username: "dosen1"},
+		}, nil).Once()
+		sqlMock.ExpectBegin()
+		mockUserRepo.On("UpdateRole", mock.Anything, mock.Anything, mock.Anything).
+			Return(&model.User{ID: "user-1", RoleId: "33333333-3333-3333-3333-333333333333"}, nil).Once()
+		mockAuthRepo.On("BumpTokenVersion", mock.Anything, "user-1").Return(nil).Once()
+		sqlMock.ExpectCommit().WillReturnError(errors.New("connection lost"))
+
+		resp, err := app.Test(newRequest())
+
+		assert.NoError(t, err)
+		assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+		mockAuthRepo.AssertNumberOfCalls(t, "BumpTokenVersion", 3)
+		mockSessionRepo.AssertNumberOfCalls(t, "RevokeAll", 1)
+	})
+}
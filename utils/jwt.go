@@ -1,21 +1,34 @@
 package utils
 
 import (
-	"errors"
 	"prisma/app/model"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateToken(User *model.User, jwtSecret []byte) (string, string, error) {
-	var AccessExpiration = time.Now().Add(60 * time.Minute)
+// AccessTokenTTL is how long an access token stays valid, whether issued at
+// login or on refresh.
+const AccessTokenTTL = 60 * time.Minute
+
+// RefreshTokenTTL is how long a refresh token stays valid. Every refresh
+// issues a new one, so an active session slides forward by this much.
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+// GenerateToken signs the access and refresh token of a new login. The
+// session ID doubles as the refresh token family, and version is the user's
+// current token version.
+func GenerateToken(User *model.User, sessionID string, version int64, jwtSecret []byte) (string, string, error) {
+	var AccessExpiration = time.Now().Add(AccessTokenTTL)
 	AccessClaims := model.Claims{
-		UserID:      User.ID,
-		Username:    User.Username,
-		FullName:    User.FullName,
-		Role:        User.RoleName,
-		Permissions: User.Permissions,
+		UserID:       User.ID,
+		Username:     User.Username,
+		FullName:     User.FullName,
+		Role:         User.RoleName,
+		Permissions:  User.Permissions,
+		SessionID:    sessionID,
+		TokenVersion: version,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(AccessExpiration),
 		},
@@ -25,25 +38,39 @@ func GenerateToken(User *model.User, jwtSecret []byte) (string, string, error) {
 	if err != nil {
 		return "", "", err
 	}
-	RefreshExpiration := time.Now().Add(7 * 24 * time.Hour)
+	refreshString, _, err := GenerateRefreshToken(AccessClaims, sessionID, jwtSecret)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessString, refreshString, nil
+}
+
+// GenerateRefreshToken signs a refresh token for the identity in claims. The
+// token belongs to family, which every rotation of it shares, and gets its
+// own ID, returned next to it, so that a rotated token can be told apart
+// from the current one.
+func GenerateRefreshToken(claims model.Claims, family string, jwtSecret []byte) (string, string, error) {
+	tokenID := uuid.NewString()
 	RefreshClaims := model.Claims{
-		UserID:      User.ID,
-		Username:    User.Username,
-		FullName:    User.FullName,
-		Role:        User.RoleName,
-		Permissions: User.Permissions,
+		UserID:       claims.UserID,
+		Username:     claims.Username,
+		FullName:     claims.FullName,
+		Role:         claims.Role,
+		Permissions:  claims.Permissions,
+		TokenVersion: claims.TokenVersion,
+		Family:       family,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(RefreshExpiration),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
 		},
 	}
 	RefreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, RefreshClaims)
-	refreshString, err := RefreshToken.SignedString([]byte(jwtSecret))
-
+	refreshString, err := RefreshToken.SignedString(jwtSecret)
 	if err != nil {
 		return "", "", err
 	}
-
-	return accessString, refreshString, nil
+	return refreshString, tokenID, nil
 }
 
 func ValidateToken(tokenString string, jwtSecret []byte) (*model.Claims, error) {
@@ -59,22 +86,3 @@ func ValidateToken(tokenString string, jwtSecret []byte) (*model.Claims, error)
 	}
 	return nil, jwt.ErrInvalidKey
 }
-
-func ExtractExpiration(tokenString string) (time.Time, error) {
-	// parse JWT tanpa cek signature
-	token, _, err := new(jwt.Parser).ParseUnverified(tokenString, &model.Claims{})
-	if err != nil {
-		return time.Time{}, err
-	}
-
-	claims, ok := token.Claims.(*model.Claims)
-	if !ok {
-		return time.Time{}, errors.New("cannot parse claims")
-	}
-
-	if claims.ExpiresAt == nil {
-		return time.Time{}, errors.New("no expiration in token")
-	}
-
-	return claims.ExpiresAt.Time, nil
-}